@@ -0,0 +1,184 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// *************************************************
+// 队列管理HTTP接口，返回JSON，挂载示例：
+//   mux.Handle("/queue/", http.StripPrefix("/queue", q.AdminHandler()))
+// 1、GET    /tasks                                  已注册任务列表：名称、说明、队列长度、深度、是否暂停
+// 2、GET    /tasks/{name}/jobs?state=&offset=&limit= 分页浏览任务，state 默认 pending，limit 默认20
+// 3、DELETE /tasks/{name}/jobs/{id}?state=          按ID删除任务
+// 4、POST   /tasks/{name}/jobs/{id}/requeue?state=  按ID将延迟中、执行中的任务立即放回待执行队列
+// 5、POST   /tasks/{name}/pause                     暂停任务
+// 6、POST   /tasks/{name}/resume                    恢复任务
+// 管理接口不做鉴权，请自行在外层包装鉴权中间件
+// *************************************************
+
+// adminDefaultLimit 浏览任务默认分页条数
+const adminDefaultLimit = 20
+
+// AdminTask 管理接口任务列表项
+type AdminTask struct {
+	Name     string `json:"name"`               // 任务名称
+	Remark   string `json:"remark"`             // 任务说明
+	Size     int64  `json:"size"`               // 队列长度
+	Pending  *int64 `json:"pending,omitempty"`  // 待执行任务数<底层驱动实现了 DepthQueueIFace 时返回>
+	Delayed  *int64 `json:"delayed,omitempty"`  // 延迟中任务数<底层驱动实现了 DepthQueueIFace 时返回>
+	Reserved *int64 `json:"reserved,omitempty"` // 执行中任务数<底层驱动实现了 DepthQueueIFace 时返回>
+	Priority bool   `json:"priority"`           // 是否高优先级任务
+	Paused   bool   `json:"paused"`             // 是否已暂停
+}
+
+// adminHandler 队列管理HTTP接口
+type adminHandler struct {
+	queue *Queue
+}
+
+// AdminHandler 获取队列管理HTTP接口
+func (q *Queue) AdminHandler() http.Handler {
+	return &adminHandler{queue: q}
+}
+
+// ServeHTTP 按路径分发管理接口
+func (h *adminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(segments) == 0 || segments[0] != "tasks" {
+		h.error(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+
+	switch {
+	case len(segments) == 1 && r.Method == http.MethodGet:
+		h.tasks(w)
+	case len(segments) == 3 && segments[2] == "jobs" && r.Method == http.MethodGet:
+		h.jobs(w, r, segments[1])
+	case len(segments) == 4 && segments[2] == "jobs" && r.Method == http.MethodDelete:
+		h.result(w, h.queue.DeleteJob(segments[1], jobState(r), segments[3]))
+	case len(segments) == 5 && segments[2] == "jobs" && segments[4] == "requeue" && r.Method == http.MethodPost:
+		h.result(w, h.queue.RequeueJob(segments[1], jobState(r), segments[3]))
+	case len(segments) == 3 && segments[2] == "pause" && r.Method == http.MethodPost:
+		h.pause(w, segments[1], true)
+	case len(segments) == 3 && segments[2] == "resume" && r.Method == http.MethodPost:
+		h.pause(w, segments[1], false)
+	default:
+		h.error(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+// tasks 已注册任务列表
+func (h *adminHandler) tasks(w http.ResponseWriter) {
+	m := h.queue.manager
+	m.lock.Lock()
+	registered := make(map[string]TaskIFace, len(m.tasks)+len(m.priorityTasks))
+	for name, task := range m.tasks {
+		registered[name] = task
+	}
+	priority := make(map[string]struct{}, len(m.priorityTasks))
+	for name, task := range m.priorityTasks {
+		registered[name] = task
+		priority[name] = struct{}{}
+	}
+	m.lock.Unlock()
+
+	names := make([]string, 0, len(registered))
+	for name := range registered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tasks := make([]AdminTask, 0, len(names))
+	for _, name := range names {
+		_, high := priority[name]
+		task := AdminTask{
+			Name:     name,
+			Remark:   registered[name].Remark(),
+			Size:     h.queue.queue.Size(name),
+			Priority: high,
+			Paused:   m.isPaused(name),
+		}
+		if depth, ok := h.queue.queue.(DepthQueueIFace); ok {
+			if pending, delayed, reserved, err := depth.Depth(name); err == nil {
+				task.Pending, task.Delayed, task.Reserved = &pending, &delayed, &reserved
+			}
+		}
+		tasks = append(tasks, task)
+	}
+
+	h.json(w, http.StatusOK, map[string]interface{}{"tasks": tasks})
+}
+
+// jobs 分页浏览任务
+func (h *adminHandler) jobs(w http.ResponseWriter, r *http.Request, name string) {
+	query := r.URL.Query()
+	offset, _ := strconv.ParseInt(query.Get("offset"), 10, 64)
+	limit, err := strconv.ParseInt(query.Get("limit"), 10, 64)
+	if err != nil || limit <= 0 {
+		limit = adminDefaultLimit
+	}
+
+	jobs, total, err := h.queue.Jobs(name, jobState(r), offset, limit)
+	if err != nil {
+		h.result(w, err)
+		return
+	}
+
+	h.json(w, http.StatusOK, map[string]interface{}{"jobs": jobs, "total": total})
+}
+
+// pause 暂停、恢复任务
+func (h *adminHandler) pause(w http.ResponseWriter, name string, paused bool) {
+	if _, exist := h.queue.manager.scheduledTask(name); !exist {
+		h.error(w, http.StatusNotFound, ErrTaskNotRegistered)
+		return
+	}
+
+	if paused {
+		h.queue.Pause(name)
+	} else {
+		h.queue.Resume(name)
+	}
+	h.json(w, http.StatusOK, map[string]interface{}{"name": name, "paused": paused})
+}
+
+// result 按错误类型输出操作结果
+func (h *adminHandler) result(w http.ResponseWriter, err error) {
+	switch {
+	case err == nil:
+		h.json(w, http.StatusOK, map[string]interface{}{"ok": true})
+	case errors.Is(err, ErrJobNotFound):
+		h.error(w, http.StatusNotFound, err)
+	case errors.Is(err, ErrJobStateInvalid):
+		h.error(w, http.StatusBadRequest, err)
+	case errors.Is(err, ErrInspectNotSupported):
+		h.error(w, http.StatusNotImplemented, err)
+	default:
+		h.error(w, http.StatusInternalServerError, err)
+	}
+}
+
+// error 输出错误
+func (h *adminHandler) error(w http.ResponseWriter, status int, err error) {
+	h.json(w, status, map[string]interface{}{"error": err.Error()})
+}
+
+// json 输出JSON
+func (h *adminHandler) json(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+// jobState 请求参数中的任务状态，默认 JobStatePending
+func jobState(r *http.Request) string {
+	if state := r.URL.Query().Get("state"); state != "" {
+		return state
+	}
+	return JobStatePending
+}
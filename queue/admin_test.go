@@ -0,0 +1,81 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+// assertDepth 断言待执行、延迟中、执行中的任务数
+func assertDepth(t *testing.T, q *Queue, name string, pending, delayed, reserved int64) {
+	t.Helper()
+
+	p, d, r, err := q.queue.(DepthQueueIFace).Depth(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != pending || d != delayed || r != reserved {
+		t.Fatalf("depth = %d/%d/%d, want %d/%d/%d", p, d, r, pending, delayed, reserved)
+	}
+}
+
+func TestTakeReservedJob(t *testing.T) {
+	for name, newDriver := range uniqueDrivers {
+		t.Run(name, func(t *testing.T) {
+			q, _ := newDriver(t)
+			task := &uniqueTask{}
+			dispatch := func(want bool) {
+				t.Helper()
+				if pushed, err := q.DispatchUnique(task, "order", "order:1", time.Hour); err != nil || pushed != want {
+					t.Fatalf("pushed = %v err %v, want %v", pushed, err, want)
+				}
+			}
+			pop := func() JobIFace {
+				t.Helper()
+				job, exist := q.queue.Pop(task.Name())
+				if !exist {
+					t.Fatal("no job popped")
+				}
+				return job
+			}
+
+			// 删除执行中的任务同时释放唯一锁
+			dispatch(true)
+			deleted := pop()
+			if err := q.DeleteJob(task.Name(), JobStateReserved, deleted.Payload().ID); err != nil {
+				t.Fatal(err)
+			}
+			dispatch(true)
+
+			// 执行进程后续的释放、删除不再生效：不放回已删除的任务，不释放新任务的唯一锁
+			if err := deleted.Release(10); err == nil {
+				t.Fatal("release of deleted job succeeded")
+			}
+			if err := deleted.Delete(); err == nil {
+				t.Fatal("delete of deleted job succeeded")
+			}
+			assertDepth(t, q, task.Name(), 1, 0, 0)
+			dispatch(false)
+
+			// 重新入队并再次取出后，原执行进程的释放、删除同样不再生效
+			requeued := pop()
+			if err := q.RequeueJob(task.Name(), JobStateReserved, requeued.Payload().ID); err != nil {
+				t.Fatal(err)
+			}
+			current := pop()
+			if err := requeued.Release(10); err == nil {
+				t.Fatal("release of requeued job succeeded")
+			}
+			if err := requeued.Delete(); err == nil {
+				t.Fatal("delete of requeued job succeeded")
+			}
+			assertDepth(t, q, task.Name(), 0, 0, 1)
+			dispatch(false)
+
+			if err := current.Delete(); err != nil {
+				t.Fatal(err)
+			}
+			assertDepth(t, q, task.Name(), 0, 0, 0)
+			dispatch(true)
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// *************************************************
+// 批次：批量投递一组任务，全部结束后投递回调任务
+// 1、批次进度计数器存储于底层驱动：redis使用hash，memory使用map
+// 2、批次内任务全部执行成功时投递then任务，首个任务最终失败时投递catch任务，全部结束时投递finally任务
+// 3、回调任务的参数均为批次ID，回调任务中可通过 Queue.BatchProgress 查询批次进度
+// *************************************************
+
+// 批次回调类型
+const (
+	batchThen    = "then"
+	batchCatch   = "catch"
+	batchFinally = "finally"
+)
+
+// Batch 批次构建器
+type Batch struct {
+	queue     *Queue
+	jobs      []Payload
+	callbacks map[string]*Payload
+	err       error // 构建过程中首个任务参数编码错误
+}
+
+// Batch 构建一个批次
+//
+//	batch := queueService.Batch()
+//	for _, uid := range uidList {
+//		batch.Add(&tasks.RecalculateTask{}, uid)
+//	}
+//	batchID, err := batch.Then(&tasks.ReportTask{}).Catch(&tasks.AlarmTask{}).Dispatch()
+func (q *Queue) Batch() *Batch {
+	return &Batch{queue: q, callbacks: make(map[string]*Payload)}
+}
+
+// Add 添加一个任务到批次
+func (b *Batch) Add(task TaskIFace, payload interface{}) *Batch {
+	job, err := b.queue.newTaskPayload(task, payload)
+	if err != nil && b.err == nil {
+		b.err = fmt.Errorf("queue %s job param marshal failed: %s", task.Name(), err.Error())
+	}
+	b.jobs = append(b.jobs, job)
+	return b
+}
+
+// Then 设置批次内任务全部执行成功时投递的任务，参数为批次ID
+func (b *Batch) Then(task TaskIFace) *Batch {
+	return b.callback(batchThen, task)
+}
+
+// Catch 设置批次内首个任务最终失败时投递的任务，参数为批次ID
+func (b *Batch) Catch(task TaskIFace) *Batch {
+	return b.callback(batchCatch, task)
+}
+
+// Finally 设置批次内任务全部结束（无论成功失败）时投递的任务，参数为批次ID
+func (b *Batch) Finally(task TaskIFace) *Batch {
+	return b.callback(batchFinally, task)
+}
+
+func (b *Batch) callback(kind string, task TaskIFace) *Batch {
+	callback := b.queue.newPayload(task, nil)
+	b.callbacks[kind] = &callback
+	return b
+}
+
+// Dispatch 投递批次内所有任务，返回批次ID
+//   - 批次内个别任务投递失败时视为该任务最终失败，返回的error为首个投递失败的错误
+func (b *Batch) Dispatch() (id string, err error) {
+	store, ok := b.queue.queue.(BatchQueueIFace)
+	if !ok {
+		return "", ErrBatchNotSupported
+	}
+	if b.err != nil {
+		return "", b.err
+	}
+	if len(b.jobs) == 0 {
+		return "", errors.New("queue batch without any task")
+	}
+
+	id = FakeUniqueID()
+	callbacks := make(map[string][]byte, len(b.callbacks))
+	for kind, callback := range b.callbacks {
+		callback.Payload = []byte(id)
+		if callbacks[kind], err = json.Marshal(callback); err != nil {
+			return "", fmt.Errorf("queue %s job param marshal failed: %s", callback.Name, err.Error())
+		}
+	}
+
+	total := int64(len(b.jobs))
+	progress := &BatchProgress{ID: id, Total: total, Pending: total, CreatedAt: time.Now().Unix()}
+	if err = store.CreateBatch(progress, callbacks, DefaultBatchTTL); err != nil {
+		return "", err
+	}
+
+	for _, job := range b.jobs {
+		job.BatchID = id
+		raw, mErr := json.Marshal(job)
+		if mErr == nil {
+			mErr = b.queue.queue.Push(job.Name, raw)
+		}
+		if mErr == nil {
+			b.queue.manager.jobDispatched(job.Name, raw)
+			continue
+		}
+
+		// 投递失败的任务计为最终失败，保障批次进度能够结束
+		if err == nil {
+			err = fmt.Errorf("queue %s batch job dispatch failed: %s", job.Name, mErr.Error())
+		}
+		fired, _ := store.FinishBatchJob(id, false)
+		for _, raw := range fired {
+			var callback Payload
+			if json.Unmarshal(raw, &callback) == nil && b.queue.queue.Push(callback.Name, raw) == nil {
+				b.queue.manager.jobDispatched(callback.Name, raw)
+			}
+		}
+	}
+
+	return id, err
+}
+
+// BatchProgress 查询批次进度
+func (q *Queue) BatchProgress(id string) (*BatchProgress, error) {
+	store, ok := q.queue.(BatchQueueIFace)
+	if !ok {
+		return nil, ErrBatchNotSupported
+	}
+	return store.BatchProgress(id)
+}
+
+// finishBatchJob 批次内任务结束：更新批次进度并投递需触发的回调任务
+func (m *manager) finishBatchJob(job JobIFace, succeeded bool) {
+	store, ok := m.queue.(BatchQueueIFace)
+	if !ok {
+		return
+	}
+
+	fired, err := store.FinishBatchJob(job.Payload().BatchID, succeeded)
+	if err != nil {
+		m.logger.Error(
+			textJobFollowFailed,
+			"queue", job.GetName(),
+			"batch_id", job.Payload().BatchID,
+			"error", err.Error(),
+		)
+		return
+	}
+
+	for _, raw := range fired {
+		var callback Payload
+		if err = json.Unmarshal(raw, &callback); err != nil {
+			continue
+		}
+		m.pushPayload(job, callback)
+	}
+}
+
+// onJobSucceeded 任务执行成功后的后续动作：任务链投递下一个任务、批次更新进度
+func (m *manager) onJobSucceeded(job JobIFace, body *RawBody) {
+	if len(job.Payload().Chain) > 0 || job.Payload().ChainFinally != nil {
+		m.dispatchChainNext(job, body)
+	}
+	if job.Payload().BatchID != "" {
+		m.finishBatchJob(job, true)
+	}
+}
+
+// onJobFailed 任务最终失败后的后续动作：任务链投递catch、finally任务，批次更新进度
+func (m *manager) onJobFailed(job JobIFace, err error) {
+	if job.Payload().ChainCatch != nil || job.Payload().ChainFinally != nil {
+		m.dispatchChainFailed(job, err)
+	}
+	if job.Payload().BatchID != "" {
+		m.finishBatchJob(job, false)
+	}
+}
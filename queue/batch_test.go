@@ -0,0 +1,67 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// namedTask 指定名称、执行成功的任务类
+type namedTask struct {
+	DefaultTaskSetting
+	name string
+}
+
+func (t *namedTask) Name() string                                    { return t.name }
+func (t *namedTask) Remark() string                                  { return t.name }
+func (t *namedTask) Execute(ctx context.Context, job *RawBody) error { return nil }
+
+// failingPushQueue 投递至指定队列时失败的memory队列
+type failingPushQueue struct {
+	*memoryQueue
+	failing string
+}
+
+func (f *failingPushQueue) Push(queue string, payload interface{}) error {
+	if queue == f.failing {
+		return errors.New("push failed")
+	}
+	return f.memoryQueue.Push(queue, payload)
+}
+
+func TestBatchDispatchPushFailed(t *testing.T) {
+	q := New(Memory, nil, nopLogger{}, 1)
+	store := &failingPushQueue{memoryQueue: q.queue.(*memoryQueue), failing: "broken"}
+	q.queue, q.manager.queue = store, store
+
+	ok, broken := &namedTask{name: "ok"}, &namedTask{name: "broken"}
+	if err := q.BootstrapOne(ok); err != nil {
+		t.Fatal(err)
+	}
+	then, catch, finally := &namedTask{name: "then"}, &namedTask{name: "catch"}, &namedTask{name: "finally"}
+	id, err := q.Batch().Add(ok, nil).Add(broken, nil).Then(then).Catch(catch).Finally(finally).Dispatch()
+	if err == nil || id == "" {
+		t.Fatalf("id %q err %v, want batch id with push error", id, err)
+	}
+
+	// 投递失败的任务计为最终失败，立即触发catch
+	progress, err := q.BatchProgress(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.Total != 2 || progress.Pending != 1 || progress.Failed != 1 {
+		t.Fatalf("progress = %+v, want 1 failed 1 pending", progress)
+	}
+	if store.Size(catch.Name()) != 1 || store.Size(finally.Name()) != 0 || store.Size(ok.Name()) != 1 {
+		t.Fatal("want ok job and catch dispatched only")
+	}
+
+	// 剩余任务执行成功后批次结束，触发finally而非then
+	runPopped(t, q, ok.Name())
+	if progress, _ = q.BatchProgress(id); !progress.Finished() || progress.Processed != 1 {
+		t.Fatalf("progress = %+v, want finished", progress)
+	}
+	if store.Size(then.Name()) != 0 || store.Size(finally.Name()) != 1 {
+		t.Fatal("want finally dispatched without then")
+	}
+}
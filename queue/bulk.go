@@ -0,0 +1,115 @@
+package queue
+
+import (
+	"fmt"
+	"time"
+)
+
+// *************************************************
+// 批量投递：一次投递多条同一任务类的任务
+// 1、底层驱动实现了 BulkQueueIFace 时批量写入：redis驱动分批pipeline，memory、file驱动单次持有锁
+// 2、未实现的驱动逐条投递
+// 3、部分任务投递失败时返回 *BulkError，逐条记录失败原因，投递成功的任务不回滚
+// *************************************************
+
+// bulkPipelineSize redis驱动单个pipeline最多写入的任务数
+const bulkPipelineSize = 1000
+
+// BulkError 批量投递中部分任务投递失败
+type BulkError struct {
+	Errors []error // 与投递参数一一对应，投递成功的为nil
+}
+
+// Error 错误信息：失败数及首个失败原因
+func (e *BulkError) Error() string {
+	failed := e.Failed()
+	if len(failed) == 0 {
+		return "queue bulk dispatch: no job failed"
+	}
+	return fmt.Sprintf("queue bulk dispatch: %d of %d jobs failed, first error: %s", len(failed), len(e.Errors), e.Errors[failed[0]].Error())
+}
+
+// Unwrap 所有失败原因，支持 errors.Is、errors.As
+func (e *BulkError) Unwrap() []error {
+	var errs []error
+	for _, err := range e.Errors {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Failed 投递失败的任务在投递参数中的下标
+func (e *BulkError) Failed() []int {
+	var failed []int
+	for idx, err := range e.Errors {
+		if err != nil {
+			failed = append(failed, idx)
+		}
+	}
+	return failed
+}
+
+// DispatchMany 批量投递队列Job任务，每个元素为一条任务的参数
+//   - 部分任务投递失败时返回 *BulkError
+func (q *Queue) DispatchMany(task TaskIFace, payloads []interface{}) error {
+	return q.dispatchMany(task, payloads, func(bulk BulkQueueIFace, raws [][]byte) []error {
+		return bulk.PushMany(task.Name(), raws)
+	}, func(raw []byte) error {
+		return q.queue.Push(task.Name(), raw)
+	})
+}
+
+// DelayMany 批量投递指定延迟时长的延迟队列Job任务，每个元素为一条任务的参数
+//   - 部分任务投递失败时返回 *BulkError
+func (q *Queue) DelayMany(task TaskIFace, payloads []interface{}, duration time.Duration) error {
+	return q.dispatchMany(task, payloads, func(bulk BulkQueueIFace, raws [][]byte) []error {
+		return bulk.LaterMany(task.Name(), duration, raws)
+	}, func(raw []byte) error {
+		return q.queue.Later(task.Name(), duration, raw)
+	})
+}
+
+// dispatchMany 生成所有任务的payload后批量写入，驱动未实现 BulkQueueIFace 时逐条写入
+//   - bulk 批量写入方法
+//   - one  逐条写入方法
+func (q *Queue) dispatchMany(task TaskIFace, payloads []interface{}, bulk func(bulk BulkQueueIFace, raws [][]byte) []error, one func(raw []byte) error) error {
+	errs := make([]error, len(payloads))
+	raws := make([][]byte, 0, len(payloads))
+	indexes := make([]int, 0, len(payloads)) // raws中每条payload在投递参数中的下标
+	for idx, payload := range payloads {
+		raw, err := q.marshalPayload(task, payload)
+		if err != nil {
+			errs[idx] = fmt.Errorf("queue %s job param marshal failed: %w", task.Name(), err)
+			continue
+		}
+		raws = append(raws, raw)
+		indexes = append(indexes, idx)
+	}
+
+	var pushed []error
+	if bulkQueue, ok := q.queue.(BulkQueueIFace); ok && len(raws) > 0 {
+		pushed = bulk(bulkQueue, raws)
+	} else {
+		pushed = make([]error, len(raws))
+		for idx, raw := range raws {
+			pushed[idx] = one(raw)
+		}
+	}
+
+	for idx, raw := range raws {
+		if idx < len(pushed) && pushed[idx] != nil {
+			errs[indexes[idx]] = pushed[idx]
+			continue
+		}
+		q.manager.jobDispatched(task.Name(), raw)
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return &BulkError{Errors: errs}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// pushFailQueue 任务参数为 fail 的任务投递失败，未实现 BulkQueueIFace，逐条投递
+type pushFailQueue struct {
+	QueueIFace
+	fail string
+}
+
+func (p *pushFailQueue) Push(queue string, payload interface{}) error {
+	var decoded Payload
+	if err := json.Unmarshal(payload.([]byte), &decoded); err != nil {
+		return err
+	}
+	if string(decoded.Payload) == p.fail {
+		return errors.New("push failed")
+	}
+	return p.QueueIFace.Push(queue, payload)
+}
+
+// bulkFailQueue 实现 BulkQueueIFace 的 pushFailQueue
+type bulkFailQueue struct {
+	*pushFailQueue
+}
+
+func (b *bulkFailQueue) PushMany(queue string, payloads [][]byte) (errs []error) {
+	errs = make([]error, len(payloads))
+	for idx, payload := range payloads {
+		errs[idx] = b.Push(queue, payload)
+	}
+	return errs
+}
+
+func (b *bulkFailQueue) LaterMany(queue string, durationTo time.Duration, payloads [][]byte) (errs []error) {
+	errs = make([]error, len(payloads))
+	for idx, payload := range payloads {
+		errs[idx] = b.Later(queue, durationTo, payload)
+	}
+	return errs
+}
+
+func TestDispatchManyPartialFailure(t *testing.T) {
+	drivers := map[string]func(mq *memoryQueue) QueueIFace{
+		"one by one": func(mq *memoryQueue) QueueIFace {
+			return &pushFailQueue{QueueIFace: mq, fail: "broken"}
+		},
+		"bulk": func(mq *memoryQueue) QueueIFace {
+			return &bulkFailQueue{&pushFailQueue{QueueIFace: mq, fail: "broken"}}
+		},
+	}
+
+	for name, newDriver := range drivers {
+		t.Run(name, func(t *testing.T) {
+			q := New(Memory, nil, nopLogger{}, 1)
+			driver := newDriver(q.queue.(*memoryQueue))
+			q.queue, q.manager.queue = driver, driver
+
+			var dispatched []string
+			q.OnJobDispatched(func(ctx context.Context, event JobEvent) {
+				dispatched = append(dispatched, string(event.Payload.Payload))
+			})
+
+			// 下标1参数编码失败（非protobuf消息），下标2投递失败
+			task := &codecTask{codec: ProtobufCodec}
+			err := q.DispatchMany(task, []interface{}{"first", codecParam{Name: "bad"}, "broken", "last"})
+			var bulkErr *BulkError
+			if !errors.As(err, &bulkErr) {
+				t.Fatalf("err = %v, want *BulkError", err)
+			}
+			if failed := bulkErr.Failed(); !reflect.DeepEqual(failed, []int{1, 2}) {
+				t.Fatalf("failed = %v, want [1 2]", failed)
+			}
+			if len(bulkErr.Errors) != 4 || len(bulkErr.Unwrap()) != 2 {
+				t.Fatalf("errors = %v, want 2 of 4 failed", bulkErr.Errors)
+			}
+
+			// 投递成功的任务不回滚，仅投递成功的任务触发投递钩子
+			if size := driver.Size(task.Name()); size != 2 {
+				t.Fatalf("size = %d, want 2", size)
+			}
+			if !reflect.DeepEqual(dispatched, []string{"first", "last"}) {
+				t.Fatalf("dispatched = %v, want [first last]", dispatched)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// *************************************************
+// 任务链：任务依次执行，前一个任务执行成功后才投递下一个任务
+// 1、后续任务以完整payload的形式随当前任务存储，消费端无需注册后续任务类即可投递
+// 2、后续任务投递时未指定参数的，以前一个任务 RawBody.SetOutput 设置的输出作为参数
+// 3、任一任务最终失败（重试耗尽）时投递catch任务，链结束时投递finally任务
+// *************************************************
+
+// ChainFailure 任务链catch、finally任务的参数：记录导致任务链终止的失败任务
+//   - catch、finally任务中通过 RawBody.Unmarshal 获取；任务链全部成功时finally任务参数中 Error 为空
+type ChainFailure struct {
+	Name  string `json:"name"`  // 失败任务名称
+	ID    string `json:"id"`    // 失败任务ID
+	Error string `json:"error"` // 失败的错误信息
+}
+
+// Chain 任务链构建器
+type Chain struct {
+	queue   *Queue
+	steps   []Payload
+	catch   *Payload
+	finally *Payload
+	err     error // 构建过程中首个任务参数编码错误
+}
+
+// Chain 构建一个任务链，task为任务链中第一个执行的任务
+//
+//	err := queueService.Chain(&tasks.ExportTask{}, 42).
+//		Then(&tasks.UploadTask{}, nil). // 参数为nil时以ExportTask的输出作为参数
+//		Catch(&tasks.NotifyFailedTask{}).
+//		Dispatch()
+func (q *Queue) Chain(task TaskIFace, payload interface{}) *Chain {
+	return (&Chain{queue: q}).Then(task, payload)
+}
+
+// Then 追加一个任务到任务链
+//   - payload为nil时，以前一个任务执行成功时 RawBody.SetOutput 设置的输出作为参数
+func (c *Chain) Then(task TaskIFace, payload interface{}) *Chain {
+	step, err := c.queue.newTaskPayload(task, payload)
+	if err != nil && c.err == nil {
+		c.err = fmt.Errorf("queue %s job param marshal failed: %s", task.Name(), err.Error())
+	}
+	c.steps = append(c.steps, step)
+	return c
+}
+
+// Catch 设置任务链中任一任务最终失败时投递的任务，参数为 ChainFailure
+func (c *Chain) Catch(task TaskIFace) *Chain {
+	catch := c.queue.newPayload(task, nil)
+	c.catch = &catch
+	return c
+}
+
+// Finally 设置任务链结束（全部成功或任一最终失败）时投递的任务，参数为 ChainFailure
+func (c *Chain) Finally(task TaskIFace) *Chain {
+	finally := c.queue.newPayload(task, nil)
+	c.finally = &finally
+	return c
+}
+
+// Dispatch 投递任务链：投递第一个任务，后续任务随之存储
+func (c *Chain) Dispatch() error {
+	if c.err != nil {
+		return c.err
+	}
+	if len(c.steps) == 0 {
+		return errors.New("queue chain without any task")
+	}
+
+	first := c.steps[0]
+	first.Chain = c.steps[1:]
+	first.ChainCatch = c.catch
+	first.ChainFinally = c.finally
+
+	raw, err := json.Marshal(first)
+	if err != nil {
+		return fmt.Errorf("queue %s job param marshal failed: %s", first.Name, err.Error())
+	}
+
+	if err = c.queue.queue.Push(first.Name, raw); err != nil {
+		return err
+	}
+	c.queue.manager.jobDispatched(first.Name, raw)
+	return nil
+}
+
+// dispatchChainNext 任务链中的任务执行成功：投递下一个任务，已是最后一个任务时投递finally任务
+func (m *manager) dispatchChainNext(job JobIFace, body *RawBody) {
+	payload := job.Payload()
+	if len(payload.Chain) == 0 {
+		if payload.ChainFinally != nil {
+			m.dispatchChainCallback(job, *payload.ChainFinally, ChainFailure{})
+		}
+		return
+	}
+
+	next := payload.Chain[0]
+	next.Chain = payload.Chain[1:]
+	next.ChainCatch = payload.ChainCatch
+	next.ChainFinally = payload.ChainFinally
+	if len(next.Payload) == 0 && body != nil {
+		// 前一个任务的输出为JSON
+		next.Payload = body.output
+		next.Codec, next.Compression, next.KeyID = "", "", ""
+	}
+
+	m.pushPayload(job, next)
+}
+
+// dispatchChainFailed 任务链中的任务最终失败：投递catch、finally任务
+func (m *manager) dispatchChainFailed(job JobIFace, err error) {
+	payload := job.Payload()
+	failure := ChainFailure{Name: job.GetName(), ID: payload.ID, Error: err.Error()}
+
+	if payload.ChainCatch != nil {
+		m.dispatchChainCallback(job, *payload.ChainCatch, failure)
+	}
+	if payload.ChainFinally != nil {
+		m.dispatchChainCallback(job, *payload.ChainFinally, failure)
+	}
+}
+
+// dispatchChainCallback 投递任务链catch、finally任务
+func (m *manager) dispatchChainCallback(job JobIFace, callback Payload, failure ChainFailure) {
+	callback.Payload = []byte(IFaceToString(failure))
+	m.pushPayload(job, callback)
+}
+
+// pushPayload 由消费端投递一个已构造好的payload：任务链后续任务、回调任务等
+func (m *manager) pushPayload(job JobIFace, payload Payload) {
+	// 消费端填充的参数（前一个任务的输出、回调参数）按需加密
+	err := m.seal(&payload)
+	var raw []byte
+	if err == nil {
+		raw, err = json.Marshal(payload)
+	}
+	if err == nil {
+		err = m.queue.Push(payload.Name, raw)
+	}
+	if err == nil {
+		m.jobDispatched(payload.Name, raw)
+	}
+	if err != nil {
+		m.logger.Error(
+			textJobFollowFailed,
+			"queue", job.GetName(),
+			"follow", payload.Name,
+			"payload", IFaceToString(payload),
+			"error", err.Error(),
+		)
+	}
+}
@@ -0,0 +1,58 @@
+package queue
+
+import "time"
+
+// *************************************************
+// 时钟：队列内部判断延迟任务到期、重试时刻、超时等使用的当前时刻
+// 1、默认使用系统时钟，单元测试可通过 Queue.SetClock 注入模拟时钟（参见 queuetest 包）
+// 2、底层驱动实现了 ClockQueueIFace（memory、file）时一并注入，延迟任务、重试任务按模拟时钟到期
+// 3、定时投递的tick及错过tick的补偿按该时钟计算，等待下一个tick的时长为该时钟下的间隔
+// 4、执行超时控制、looper轮询间隔等仍使用真实时间
+// *************************************************
+
+// Clock 时钟
+type Clock interface {
+	Now() time.Time // 当前时刻
+}
+
+// systemClock 系统时钟
+type systemClock struct{}
+
+// Now 当前时刻
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SetClock 设置队列内部使用的时钟，nil时恢复为系统时钟，需在投递任务、Start 之前调用
+func (q *Queue) SetClock(clock Clock) {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	q.manager.clock = clock
+	if aware, ok := q.queue.(ClockQueueIFace); ok {
+		aware.SetClock(clock)
+	}
+}
+
+// now 当前时刻：未设置时钟时为系统时刻
+func (m *manager) now() time.Time {
+	if m.clock == nil {
+		return time.Now()
+	}
+	return m.clock.Now()
+}
+
+// SetClock 设置memory队列使用的时钟
+func (m *memoryQueue) SetClock(clock Clock) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.clock = clock
+}
+
+// now 当前时刻：未设置时钟时为系统时刻
+func (m *memoryQueue) now() time.Time {
+	if m.clock == nil {
+		return time.Now()
+	}
+	return m.clock.Now()
+}
@@ -0,0 +1,233 @@
+package queue
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"sync"
+)
+
+// *************************************************
+// 任务参数编解码
+// 1、Codec 编解码器接口，内置 JSONCodec、MsgpackCodec、ProtobufCodec；其他编解码器可通过 NewCodec 包装对应库的方法后注册
+// 2、编解码器可按队列（Queue.SetCodec）或按任务类（任务类实现 TaskCodecIFace）指定，任务类优先
+// 3、编码后的参数超过阈值时可选gzip压缩（Queue.SetCompression）
+// 4、编解码器名称、压缩算法随任务payload存储，消费端按payload记录解码：新旧版本混合部署期间可共存
+// 5、标量参数（字符串、数字、[]byte等）及nil不经编解码器，始终以字符串形式存储，RawBody.Int 等方法不受影响
+// *************************************************
+
+// CompressionGzip 任务参数gzip压缩
+const CompressionGzip = "gzip"
+
+var (
+	// ErrCodecNotRegistered 任务payload记录的编解码器未注册
+	ErrCodecNotRegistered = errors.New("queue.codec.not.registered")
+	// ErrCompressionNotSupported 任务payload记录的压缩算法不支持
+	ErrCompressionNotSupported = errors.New("queue.compression.not.supported")
+	// ErrCodecProtoMessage ProtobufCodec 编解码的任务参数未实现 proto.Message
+	ErrCodecProtoMessage = errors.New("queue.codec.proto.message.required")
+)
+
+// Codec 任务参数编解码器
+type Codec interface {
+	Name() string                               // 编解码器名称，随任务payload存储，需全局唯一且保持不变
+	Marshal(v interface{}) ([]byte, error)      // 编码任务参数
+	Unmarshal(data []byte, v interface{}) error // 解码任务参数
+}
+
+// TaskCodecIFace 任务类可选实现：指定任务参数编解码器，优先于 Queue.SetCodec 设置的编解码器
+type TaskCodecIFace interface {
+	Codec() Codec
+}
+
+// JSONCodec 内置JSON编解码器，未指定编解码器时的默认行为
+var JSONCodec = NewCodec("json", json.Marshal, json.Unmarshal)
+
+// MsgpackCodec 内置MessagePack编解码器
+var MsgpackCodec = NewCodec("msgpack", msgpack.Marshal, msgpack.Unmarshal)
+
+// ProtobufCodec 内置protobuf编解码器：投递的任务参数需实现 proto.Message，解码时传入对应消息类型的指针
+var ProtobufCodec = NewCodec("protobuf", func(v interface{}) ([]byte, error) {
+	message, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrCodecProtoMessage, v)
+	}
+	return proto.Marshal(message)
+}, func(data []byte, v interface{}) error {
+	message, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrCodecProtoMessage, v)
+	}
+	return proto.Unmarshal(data, message)
+})
+
+// codecs 已注册的编解码器，消费端按名称查找解码
+var codecs = struct {
+	sync.RWMutex
+	items map[string]Codec
+}{items: map[string]Codec{
+	JSONCodec.Name():     JSONCodec,
+	MsgpackCodec.Name():  MsgpackCodec,
+	ProtobufCodec.Name(): ProtobufCodec,
+}}
+
+// funcCodec 以编解码方法构造的编解码器
+type funcCodec struct {
+	name      string
+	marshal   func(v interface{}) ([]byte, error)
+	unmarshal func(data []byte, v interface{}) error
+}
+
+func (c *funcCodec) Name() string                               { return c.name }
+func (c *funcCodec) Marshal(v interface{}) ([]byte, error)      { return c.marshal(v) }
+func (c *funcCodec) Unmarshal(data []byte, v interface{}) error { return c.unmarshal(data, v) }
+
+// NewCodec 以编解码方法构造编解码器，例如：
+//
+//	queue.NewCodec("cbor", cbor.Marshal, cbor.Unmarshal)
+func NewCodec(name string, marshal func(v interface{}) ([]byte, error), unmarshal func(data []byte, v interface{}) error) Codec {
+	return &funcCodec{name: name, marshal: marshal, unmarshal: unmarshal}
+}
+
+// RegisterCodec 注册编解码器，消费端需注册生产端使用的所有编解码器才能解码
+//   - Queue.SetCodec 设置的编解码器自动注册；任务类 TaskCodecIFace 指定的编解码器在 Bootstrap 时自动注册
+func RegisterCodec(codec Codec) {
+	codecs.Lock()
+	defer codecs.Unlock()
+	codecs.items[codec.Name()] = codec
+}
+
+// lookupCodec 按名称查找编解码器，名称为空时为 JSONCodec
+func lookupCodec(name string) (Codec, error) {
+	if name == "" {
+		return JSONCodec, nil
+	}
+
+	codecs.RLock()
+	defer codecs.RUnlock()
+	if codec, ok := codecs.items[name]; ok {
+		return codec, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrCodecNotRegistered, name)
+}
+
+// SetCodec 设置队列默认的任务参数编解码器，nil时恢复为 JSONCodec
+func (q *Queue) SetCodec(codec Codec) {
+	if codec != nil {
+		RegisterCodec(codec)
+	}
+	q.codec = codec
+}
+
+// SetCompression 设置任务参数编码后超过threshold字节时gzip压缩，小于等于0不压缩
+func (q *Queue) SetCompression(threshold int) {
+	q.compressThreshold = threshold
+}
+
+// newTaskPayload 初始化创建队列内部存储的payload结构，按编解码器编码、按需压缩、加密任务参数
+func (q *Queue) newTaskPayload(task TaskIFace, taskParam interface{}) (Payload, error) {
+	payload := q.newPayload(task, taskParam)
+
+	codec := q.codec
+	if taskCodec, ok := task.(TaskCodecIFace); ok && taskCodec.Codec() != nil {
+		codec = taskCodec.Codec()
+	}
+	if taskParam != nil && !isScalar(taskParam) && codec != nil && codec.Name() != JSONCodec.Name() {
+		body, err := codec.Marshal(taskParam)
+		if err != nil {
+			return payload, err
+		}
+		payload.Payload = body
+		payload.Codec = codec.Name()
+	}
+
+	if q.compressThreshold > 0 && len(payload.Payload) > q.compressThreshold {
+		body, err := gzipCompress(payload.Payload)
+		if err != nil {
+			return payload, err
+		}
+		payload.Payload = body
+		payload.Compression = CompressionGzip
+	}
+
+	if err := q.manager.seal(&payload); err != nil {
+		return payload, err
+	}
+
+	return payload, nil
+}
+
+// marshalPayload 初始化创建生成队列内部存储的payload字符串
+func (q *Queue) marshalPayload(task TaskIFace, taskParam interface{}) ([]byte, error) {
+	payload, err := q.newTaskPayload(task, taskParam)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(payload)
+}
+
+// decodeBody 解密、解压任务参数构造 RawBody，失败时 RawBody 携带原始参数
+//   - keyring 为nil时无法解密已加密的任务参数
+func (payload *Payload) decodeBody(keyring *Keyring) (*RawBody, error) {
+	body := &RawBody{queue: payload.Name, ID: payload.ID, payload: payload.Payload, codec: payload.Codec}
+
+	plaintext, err := payload.open(keyring)
+	if err != nil {
+		return body, err
+	}
+	body.payload = plaintext
+
+	switch payload.Compression {
+	case "":
+	case CompressionGzip:
+		decompressed, err := gzipDecompress(plaintext)
+		if err != nil {
+			return body, err
+		}
+		body.payload = decompressed
+	default:
+		return body, fmt.Errorf("%w: %s", ErrCompressionNotSupported, payload.Compression)
+	}
+
+	return body, nil
+}
+
+// isScalar 标量参数：以字符串形式存储，不经编解码器
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case string, []byte, bool,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return true
+	}
+	return false
+}
+
+// gzipCompress gzip压缩
+func gzipCompress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// gzipDecompress gzip解压
+func gzipDecompress(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
@@ -0,0 +1,110 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// nopLogger 丢弃所有日志
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, keyValues ...string) {}
+func (nopLogger) Info(msg string, keyValues ...string)  {}
+func (nopLogger) Warn(msg string, keyValues ...string)  {}
+func (nopLogger) Error(msg string, keyValues ...string) {}
+
+// codecTask 指定编解码器的任务类
+type codecTask struct {
+	DefaultTaskSetting
+	codec Codec
+}
+
+func (t *codecTask) Name() string                                    { return "codec" }
+func (t *codecTask) Remark() string                                  { return "codec" }
+func (t *codecTask) Execute(ctx context.Context, job *RawBody) error { return nil }
+func (t *codecTask) Codec() Codec                                    { return t.codec }
+
+type codecParam struct {
+	Name string            `json:"name" msgpack:"name"`
+	Tags []string          `json:"tags" msgpack:"tags"`
+	Meta map[string]string `json:"meta" msgpack:"meta"`
+}
+
+// roundTrip 按任务类的编解码器和压缩阈值编码任务参数，再按payload记录解码至result
+func roundTrip(t *testing.T, codec Codec, threshold int, param, result interface{}) Payload {
+	t.Helper()
+
+	q := New(Memory, nil, nopLogger{}, 1)
+	q.SetCompression(threshold)
+
+	payload, err := q.newTaskPayload(&codecTask{codec: codec}, param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := payload.decodeBody(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = body.Unmarshal(result); err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	param := codecParam{
+		Name: strings.Repeat("name", 64),
+		Tags: []string{"a", "b"},
+		Meta: map[string]string{"k": "v"},
+	}
+
+	for _, codec := range []Codec{JSONCodec, MsgpackCodec} {
+		for _, threshold := range []int{0, 16} {
+			var result codecParam
+			payload := roundTrip(t, codec, threshold, param, &result)
+			if !reflect.DeepEqual(result, param) {
+				t.Fatalf("%s threshold %d: result = %+v, want %+v", codec.Name(), threshold, result, param)
+			}
+			if compressed := payload.Compression == CompressionGzip; compressed != (threshold > 0) {
+				t.Fatalf("%s threshold %d: compression = %q", codec.Name(), threshold, payload.Compression)
+			}
+			if codec != JSONCodec && payload.Codec != codec.Name() {
+				t.Fatalf("%s: payload codec = %q", codec.Name(), payload.Codec)
+			}
+		}
+	}
+}
+
+func TestProtobufCodecRoundTrip(t *testing.T) {
+	param, err := structpb.NewStruct(map[string]interface{}{
+		"name": strings.Repeat("name", 64),
+		"tags": []interface{}{"a", "b"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, threshold := range []int{0, 16} {
+		result := &structpb.Struct{}
+		payload := roundTrip(t, ProtobufCodec, threshold, param, result)
+		if !proto.Equal(result, param) {
+			t.Fatalf("threshold %d: result = %v, want %v", threshold, result, param)
+		}
+		if payload.Codec != ProtobufCodec.Name() {
+			t.Fatalf("payload codec = %q", payload.Codec)
+		}
+	}
+
+	// 非 proto.Message 参数
+	if _, err = ProtobufCodec.Marshal(codecParam{}); !errors.Is(err, ErrCodecProtoMessage) {
+		t.Fatalf("marshal err = %v, want ErrCodecProtoMessage", err)
+	}
+	if err = ProtobufCodec.Unmarshal(nil, &codecParam{}); !errors.Is(err, ErrCodecProtoMessage) {
+		t.Fatalf("unmarshal err = %v, want ErrCodecProtoMessage", err)
+	}
+}
@@ -8,7 +8,6 @@ package queue
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"strconv"
 	"time"
@@ -20,6 +19,13 @@ const (
 	DefaultMaxExecuteDuration = 900 * time.Second      // job任务执行时长极限预警值：15分钟
 	DefaultMaxTries           = 1                      // 默认最大重试次数：1次<即不重试>
 	DefaultRetryInterval      = 60                     // 默认下次任务重试间隔：1分钟<即可多次执行任务失败后下一次尝试是在60秒后>
+	DefaultExecuteGrace       = 3 * time.Second        // 默认任务执行超时后等待任务类响应context主动退出的宽限时长
+	DefaultUniqueTTL          = time.Hour              // 唯一任务投递未指定有效期时唯一锁的默认有效期
+	DefaultBatchTTL           = 7 * 24 * time.Hour     // 批次进度记录的有效期
+	DefaultNotifyMaxIdle      = 5 * time.Second        // 通知模式下looper空闲时的默认最长等待时长
+	DefaultStatusTTL          = 24 * time.Hour         // 可追踪任务状态记录的默认有效期
+	DefaultHeartbeatInterval  = 30 * time.Second       // 执行中任务心跳延长保留时刻的默认间隔
+	maxAttemptHistory         = 20                     // payload中保留的失败尝试记录最大条数
 )
 
 var (
@@ -29,15 +35,62 @@ var (
 	ErrMaxAttemptsExceeded = errors.New("queue.max.execute.attempts")
 	// ErrAbortForWaitingPrevJobFinish 等待上一次任务执行结束退出
 	ErrAbortForWaitingPrevJobFinish = errors.New("queue.abort.for.waiting.prev.job.finish")
+	// ErrJobExecuteTimeout 任务执行超时：FailedJobHandler 中可通过 errors.Is 判断
+	ErrJobExecuteTimeout = errors.New("queue.job.execute.timeout")
+	// ErrJobExecutePanic 任务执行发生panic：FailedJobHandler 中可通过 errors.Is 判断
+	ErrJobExecutePanic = errors.New("queue.job.execute.panic")
+	// ErrDeadLetterNotSupported 队列底层驱动未实现死信存储
+	ErrDeadLetterNotSupported = errors.New("queue.dead.letter.not.supported")
+	// ErrDeadJobNotFound 死信任务不存在
+	ErrDeadJobNotFound = errors.New("queue.dead.job.not.found")
+	// ErrUniqueNotSupported 队列底层驱动未实现唯一任务投递
+	ErrUniqueNotSupported = errors.New("queue.unique.not.supported")
+	// ErrBatchNotSupported 队列底层驱动未实现批次
+	ErrBatchNotSupported = errors.New("queue.batch.not.supported")
+	// ErrBatchNotFound 批次不存在或已过期
+	ErrBatchNotFound = errors.New("queue.batch.not.found")
+	// ErrTaskNotRegistered 任务类未注册
+	ErrTaskNotRegistered = errors.New("queue.task.not.registered")
+	// ErrStatusNotSupported 队列底层驱动未实现任务状态存储
+	ErrStatusNotSupported = errors.New("queue.status.not.supported")
+	// ErrMigrateNotSupported 队列底层驱动未实现命名空间迁移
+	ErrMigrateNotSupported = errors.New("queue.migrate.not.supported")
+	// ErrNamespaceConflict 命名空间迁移时目标命名空间下已存在同名数据
+	ErrNamespaceConflict = errors.New("queue.namespace.conflict")
+	// ErrInspectNotSupported 队列底层驱动未实现任务浏览管理
+	ErrInspectNotSupported = errors.New("queue.inspect.not.supported")
+	// ErrJobNotFound 任务不存在：已被执行、删除或状态已变化
+	ErrJobNotFound = errors.New("queue.job.not.found")
+	// ErrHandoffNotSupported 底层驱动的job不支持交还：未实现 HandoffJobIFace
+	ErrHandoffNotSupported = errors.New("queue.handoff.not.supported")
+	// ErrJobStateInvalid 任务状态参数不合法
+	ErrJobStateInvalid = errors.New("queue.job.state.invalid")
+)
+
+// 任务所处状态，用于任务浏览管理
+const (
+	JobStatePending  = "pending"  // 待执行
+	JobStateDelayed  = "delayed"  // 延迟中、等待重试
+	JobStateReserved = "reserved" // 执行中
 )
 
 // 任务输出相关文案变量统一定义：便于日志追踪
 var (
-	textJobProcessing = "queue.job.processing"   // job开始执行标记文案
-	textJobProcessed  = "queue.job.processed"    // job已执行成功标记文案
-	textJobFailed     = "queue.job.failed"       // job已执行失败标记文案<任务类返回了error>
-	textJobTooLong    = "queue.execute.too.long" // job多次尝试执行检查距离上次执行时间差已经大于设置的最大执行时长
-	textJobFailedLog  = "queue.failed.log"       // job执行失败标记文案
+	textJobProcessing   = "queue.job.processing"    // job开始执行标记文案
+	textJobProcessed    = "queue.job.processed"     // job已执行成功标记文案
+	textJobFailed       = "queue.job.failed"        // job已执行失败标记文案<任务类返回了error>
+	textJobTooLong      = "queue.execute.too.long"  // job多次尝试执行检查距离上次执行时间差已经大于设置的最大执行时长
+	textJobFailedLog    = "queue.failed.log"        // job执行失败标记文案
+	textJobPanic        = "queue.execute.panic"     // job执行发生panic标记文案
+	textJobTimeout      = "queue.job.timeout"       // job执行超时标记文案<开始等待宽限期>
+	textJobAbandoned    = "queue.job.abandoned"     // job执行超时且宽限期内未退出，worker放弃等待的标记文案
+	textJobLate         = "queue.job.late.return"   // 已被放弃的job执行迟到返回的标记文案<结果被丢弃>
+	textJobBuryFailed   = "queue.job.bury.failed"   // job写入死信存储失败标记文案
+	textJobFollowFailed = "queue.job.follow.failed" // job结束后投递任务链后续任务、批次回调任务失败标记文案
+	textJobLimitFailed  = "queue.job.limit.failed"  // job获取、释放限流名额失败标记文案
+	textScheduleFailed  = "queue.schedule.failed"   // 定时投递认领tick、投递任务失败标记文案
+	textJobHeartbeat    = "queue.job.heartbeat"     // 执行中job心跳延长保留时刻、限流名额失败标记文案
+	textJobHandoff      = "queue.job.handoff"       // 排空停机时交还执行中job标记文案
 )
 
 // region queue队列抽象
@@ -71,6 +124,178 @@ type QueueIFace interface {
 	GetConnection() (connection interface{}, err error)
 }
 
+// DeadLetterIFace 死信存储契约：最终执行失败的任务保存完整payload以便后续排查、重新入队
+//   - 队列底层驱动可选实现，未实现的驱动最终失败的任务仅触发 FailedJobHandler
+type DeadLetterIFace interface {
+	// Bury 保存一条死信任务
+	// @param queue 队列的名称
+	// @param job   死信任务
+	Bury(queue string, job *DeadJob) (err error)
+	// DeadJobs 按失败时刻倒序分页获取死信任务
+	// @param queue  队列的名称
+	// @param offset 偏移量
+	// @param limit  获取条数
+	DeadJobs(queue string, offset, limit int64) (jobs []*DeadJob, total int64, err error)
+	// RequeueDead 将一条死信任务重新投递到队列，尝试次数重新计数
+	// @param queue 队列的名称
+	// @param id    任务ID
+	RequeueDead(queue string, id string) (err error)
+	// RequeueAllDead 将队列下所有死信任务重新投递到队列
+	// @param queue 队列的名称
+	RequeueAllDead(queue string) (count int64, err error)
+	// PurgeDead 清理指定时刻之前失败的死信任务
+	// @param queue  队列的名称
+	// @param before 失败时刻早于该时刻的死信任务将被清理
+	PurgeDead(queue string, before time.Time) (count int64, err error)
+}
+
+// UniqueQueueIFace 唯一任务投递契约：同一唯一key的任务待执行、延迟中、执行中时不再重复投递
+//   - 队列底层驱动可选实现
+//   - 唯一key对应的锁在任务删除（执行成功或最终失败）时由job释放，ttl仅为任务丢失时的兜底有效期
+type UniqueQueueIFace interface {
+	// PushUnique 原子的检查唯一锁并投递一条任务到队列
+	// @param queue   队列的名称
+	// @param key     任务唯一key
+	// @param ttl     唯一锁有效期
+	// @param id      任务ID，作为唯一锁的持有者
+	// @param payload 投递进队列的参数负载
+	PushUnique(queue string, key string, ttl time.Duration, id string, payload interface{}) (pushed bool, err error)
+}
+
+// BatchQueueIFace 批次契约：批次进度计数器的存储，队列底层驱动可选实现
+type BatchQueueIFace interface {
+	// CreateBatch 创建批次进度记录
+	// @param progress  批次初始进度
+	// @param callbacks 批次回调任务：then、catch、finally => 回调任务payload
+	// @param ttl       批次进度记录有效期
+	CreateBatch(progress *BatchProgress, callbacks map[string][]byte, ttl time.Duration) (err error)
+	// FinishBatchJob 批次内一个任务结束，原子的更新进度并返回需投递的回调任务payload
+	// @param id        批次ID
+	// @param succeeded 任务是否执行成功
+	FinishBatchJob(id string, succeeded bool) (callbacks [][]byte, err error)
+	// BatchProgress 查询批次进度
+	// @param id 批次ID
+	BatchProgress(id string) (progress *BatchProgress, err error)
+}
+
+// NotifiableQueueIFace 任务就绪通知契约：用于替代looper无任务时的轮询休眠
+//   - 队列底层驱动可选实现，未实现的驱动仍使用随机休眠轮询
+type NotifiableQueueIFace interface {
+	// Wait 阻塞等待直至任一队列有新任务投递、延迟或保留任务到期、ctx取消或达到最长等待时长
+	//  - 返回时不保证一定有可执行的任务，由looper再次pop确认
+	// @param ctx     取消等待的上下文
+	// @param queues  等待的队列名称列表
+	// @param maxWait 最长等待时长
+	Wait(ctx context.Context, queues []string, maxWait time.Duration)
+	// StopWait 释放等待通知占用的资源（如订阅连接），looper退出后调用
+	StopWait()
+}
+
+// InspectableQueueIFace 任务浏览管理契约：浏览待执行、延迟中、执行中的任务，按ID删除或立即重新入队
+//   - 队列底层驱动可选实现，未实现的驱动管理接口返回 ErrInspectNotSupported
+type InspectableQueueIFace interface {
+	// Jobs 分页浏览指定状态的任务
+	// @param queue  队列的名称
+	// @param state  任务状态：JobStatePending、JobStateDelayed、JobStateReserved
+	// @param offset 偏移量
+	// @param limit  获取条数
+	Jobs(queue, state string, offset, limit int64) (jobs []*JobInfo, total int64, err error)
+	// DeleteJob 按ID删除指定状态的任务，任务不存在返回 ErrJobNotFound
+	// @param queue 队列的名称
+	// @param state 任务状态
+	// @param id    任务ID
+	DeleteJob(queue, state, id string) (err error)
+	// RequeueJob 将延迟中或执行中的任务立即放回待执行队列，任务不存在返回 ErrJobNotFound
+	// @param queue 队列的名称
+	// @param state 任务状态：JobStateDelayed、JobStateReserved
+	// @param id    任务ID
+	RequeueJob(queue, state, id string) (err error)
+}
+
+// ScheduleStoreIFace 定时投递契约：集群中每个tick仅由一个节点认领投递，并记录最近认领的tick用于停机补偿
+//   - 队列底层驱动可选实现，未实现的驱动由各进程独立投递且停机期间错过的tick无法补偿
+type ScheduleStoreIFace interface {
+	// LastTick 获取最近认领的tick
+	// @param name 定时投递名称
+	// @return tick 最近认领的tick时刻UNIX时间戳，未认领过为0
+	LastTick(name string) (tick int64, err error)
+
+	// ClaimTick 认领tick：tick晚于最近认领的tick时原子更新并返回true
+	// @param name 定时投递名称
+	// @param tick tick时刻UNIX时间戳
+	ClaimTick(name string, tick int64) (claimed bool, err error)
+}
+
+// StatusStoreIFace 任务状态存储契约：记录可追踪任务的状态、进度和结果，队列底层驱动可选实现
+type StatusStoreIFace interface {
+	// SetStatus 更新任务状态记录的指定字段，未指定的字段保持不变，记录不存在时创建
+	// @param id     任务ID
+	// @param fields 字段名 => 字段值
+	// @param ttl    记录有效期，每次更新重新计算
+	SetStatus(id string, fields map[string]string, ttl time.Duration) (err error)
+	// GetStatus 获取任务状态记录的所有字段，记录不存在或已过期返回 ErrJobNotFound
+	// @param id 任务ID
+	GetStatus(id string) (fields map[string]string, err error)
+}
+
+// BulkQueueIFace 批量投递契约：一次写入多条任务，队列底层驱动可选实现，未实现的驱动逐条投递
+type BulkQueueIFace interface {
+	// PushMany 批量投递任务到队列，返回与payloads一一对应的投递结果，投递成功的为nil
+	// @param queue    队列的名称
+	// @param payloads 任务payload
+	PushMany(queue string, payloads [][]byte) (errs []error)
+	// LaterMany 批量投递延迟指定时长后执行的延迟任务，返回与payloads一一对应的投递结果，投递成功的为nil
+	// @param queue      队列的名称
+	// @param durationTo 延迟时长
+	// @param payloads   任务payload
+	LaterMany(queue string, durationTo time.Duration, payloads [][]byte) (errs []error)
+}
+
+// MigratorQueueIFace 命名空间迁移契约：将旧命名空间下的队列数据迁移至当前命名空间，队列底层驱动可选实现
+type MigratorQueueIFace interface {
+	// MigrateNamespace 迁移队列数据，目标命名空间下已存在同名数据时跳过并返回 ErrNamespaceConflict
+	// @param from   旧命名空间，为空表示未设置命名空间
+	// @param queues 需迁移的队列名称
+	MigrateNamespace(from string, queues []string) (moved int64, err error)
+}
+
+// ClockQueueIFace 时钟注入契约：底层驱动使用注入的时钟判断延迟任务、重试任务到期，队列底层驱动可选实现
+type ClockQueueIFace interface {
+	// SetClock 设置底层驱动使用的时钟
+	// @param clock 时钟
+	SetClock(clock Clock)
+}
+
+// DepthQueueIFace 队列深度契约：分别获取待执行、延迟中、执行中的任务数，用于指标采集
+//   - 队列底层驱动可选实现，未实现的驱动以 Size 作为待执行任务数
+type DepthQueueIFace interface {
+	// Depth 获取队列深度
+	// @param queue 队列的名称
+	Depth(queue string) (pending, delayed, reserved int64, err error)
+}
+
+// LimiterQueueIFace 任务限流契约：单个任务的最大并发执行数 && 令牌桶限速
+//   - 队列底层驱动可选实现，未实现的驱动使用进程内限流
+type LimiterQueueIFace interface {
+	// AcquireSlot 尝试获取一个执行名额：并发数未达上限且令牌桶有令牌时获取成功
+	// @param queue 队列的名称
+	// @param slot  名额ID
+	// @param limit 限流规则
+	// @param ttl   名额有效期：持有者崩溃时名额到期自动释放
+	AcquireSlot(queue, slot string, limit TaskLimit, ttl time.Duration) (ok bool, err error)
+	// ReleaseSlot 释放执行名额
+	// @param queue  队列的名称
+	// @param slot   名额ID
+	// @param limit  限流规则
+	// @param refund 是否退还令牌：获取名额后未取到任务时退还
+	ReleaseSlot(queue, slot string, limit TaskLimit, refund bool) (err error)
+	// ExtendSlot 将执行名额的有效期延长至当前时刻+ttl，已晚于该时刻时保持不变，名额不存在时不做处理
+	// @param queue 队列的名称
+	// @param slot  名额ID
+	// @param ttl   名额有效期
+	ExtendSlot(queue, slot string, ttl time.Duration) (err error)
+}
+
 // endregion
 
 // region job任务抽象
@@ -93,6 +318,21 @@ type JobIFace interface {
 	Payload() (payload *Payload)     // 获取任务执行参数payload
 }
 
+// HeartbeatJobIFace 执行中任务心跳契约：延长任务的保留时刻，避免执行中的任务被视为超时重新取出，队列底层驱动的job可选实现
+type HeartbeatJobIFace interface {
+	// Heartbeat 将任务保留时刻延长至当前时刻+lease，已晚于该时刻时保持不变
+	//   - 任务已不处于本次保留状态（已删除、已释放或已被重新取出）时返回 ErrJobNotFound
+	// @param lease 保留时长
+	Heartbeat(lease time.Duration) (err error)
+}
+
+// HandoffJobIFace 执行中任务交还契约：将执行中的任务放回待执行队列头部，本次执行不计入尝试次数，队列底层驱动的job可选实现
+type HandoffJobIFace interface {
+	// Handoff 交还任务，交还后其他消费者可立即取出执行
+	//   - 任务已不处于本次保留状态（已删除、已释放或已被重新取出）时返回 ErrJobNotFound
+	Handoff() (err error)
+}
+
 // endregion
 
 // region 日志接口定义
@@ -124,9 +364,17 @@ type Logger interface {
 // RawBody 队列execute执行时传递给执行方法的参数Raw结构：job任务参数的包装器
 //  - ID 内部标记队列任务的唯一ID，使用UUID生成
 type RawBody struct {
-	queue   string // 队列名
-	payload []byte // 调度队列塞入的数据体
-	ID      string // 队列内部唯一标识符ID
+	queue   string          // 队列名
+	payload []byte          // 调度队列塞入的数据体
+	output  []byte          // 任务执行输出，任务链中作为下一个任务的参数
+	codec   string          // 任务参数编解码器名称，为空时为JSON
+	status  *statusReporter // 可追踪任务的状态记录器，非可追踪任务为nil
+	ID      string          // 队列内部唯一标识符ID
+}
+
+// Name 任务所属队列名称，即任务类 Name() 返回值
+func (rawBody *RawBody) Name() string {
+	return rawBody.queue
 }
 
 // Int 任务参数数据转int
@@ -167,7 +415,18 @@ func (rawBody *RawBody) Uint64() uint64 {
 //  - result 具体类型的指针引用变量，转换成功将自动填充
 //  - 转换成功填充result返回nil，转换失败时返回error
 func (rawBody *RawBody) Unmarshal(result interface{}) error {
-	return json.Unmarshal(rawBody.payload, result)
+	codec, err := lookupCodec(rawBody.codec)
+	if err != nil {
+		return err
+	}
+	return codec.Unmarshal(rawBody.payload, result)
+}
+
+// SetOutput 设置任务执行输出
+//  - 任务链中下一个任务投递时未指定参数的，执行成功后以该输出作为下一个任务的参数
+//  - 输出的转换规则与投递任务参数一致：标量直接转字符串，其他类型json序列化
+func (rawBody *RawBody) SetOutput(output interface{}) {
+	rawBody.output = []byte(IFaceToString(output))
 }
 
 // endregion
@@ -176,20 +435,78 @@ func (rawBody *RawBody) Unmarshal(result interface{}) error {
 
 // Payload 存储于队列中的job任务结构
 type Payload struct {
-	Name          string `json:"Name"`          // 队列名称
-	ID            string `json:"ID"`            // 任务ID
-	MaxTries      int64  `json:"MaxTries"`      // 任务最大尝试次数，默认1
-	RetryInterval int64  `json:"RetryInterval"` // 当任务最大允许尝试次数大于0时，下次尝试之前的间隔时长，单位：秒
-	Attempts      int64  `json:"Attempts"`      // 任务已被尝试执行的的次数
-	Payload       []byte `json:"Payload"`       // 任务参数比特字面量，可decode成具体job被execute时的类型
-	PopTime       int64  `json:"PopTime"`       // 任务首次被取出执行的时间戳，取出的时候才去设置
-	Timeout       int64  `json:"Timeout"`       // 任务最大执行超时时长，单位：秒
-	TimeoutAt     int64  `json:"TimeoutAt"`     // 任务超时时刻时间戳，被执行时刻才会去设置
+	Name          string          `json:"Name"`                   // 队列名称
+	ID            string          `json:"ID"`                     // 任务ID
+	MaxTries      int64           `json:"MaxTries"`               // 任务最大尝试次数，默认1
+	RetryInterval int64           `json:"RetryInterval"`          // 当任务最大允许尝试次数大于0时，下次尝试之前的间隔时长，单位：秒
+	Attempts      int64           `json:"Attempts"`               // 任务已被尝试执行的的次数
+	Payload       []byte          `json:"Payload"`                // 任务参数比特字面量，可decode成具体job被execute时的类型
+	PopTime       int64           `json:"PopTime"`                // 任务首次被取出执行的时间戳，取出的时候才去设置
+	Timeout       int64           `json:"Timeout"`                // 任务最大执行超时时长，单位：秒
+	TimeoutAt     int64           `json:"TimeoutAt"`              // 任务超时时刻时间戳，被执行时刻才会去设置
+	RetryDelay    int64           `json:"RetryDelay"`             // 最近一次重试前的延迟时长，单位：秒；任务被释放重试时设置
+	UniqueKey     string          `json:"UniqueKey,omitempty"`    // 唯一任务的唯一key，通过 DispatchUnique 投递时设置
+	Chain         []Payload       `json:"Chain,omitempty"`        // 任务链中当前任务之后待执行的任务，当前任务执行成功后投递下一个
+	ChainCatch    *Payload        `json:"ChainCatch,omitempty"`   // 任务链中任一任务最终失败时投递的任务
+	ChainFinally  *Payload        `json:"ChainFinally,omitempty"` // 任务链结束（全部成功或任一最终失败）时投递的任务
+	BatchID       string          `json:"BatchID,omitempty"`      // 所属批次ID
+	Codec         string          `json:"Codec,omitempty"`        // 任务参数编解码器名称，为空时为JSON
+	Compression   string          `json:"Compression,omitempty"`  // 任务参数压缩算法，为空时未压缩
+	KeyID         string          `json:"KeyID,omitempty"`        // 任务参数加密密钥ID，为空时未加密
+	Tracked       bool            `json:"Tracked,omitempty"`      // 是否记录任务状态，通过 DispatchTracked 投递时设置
+	History       []AttemptRecord `json:"History,omitempty"`      // 任务失败尝试记录，最多保留最近 maxAttemptHistory 条
+}
+
+// AttemptRecord 任务单次执行失败的记录
+type AttemptRecord struct {
+	Attempt  int64  `json:"Attempt"`  // 第几次尝试
+	Error    string `json:"Error"`    // 失败的错误信息
+	FailedAt int64  `json:"FailedAt"` // 失败时刻时间戳
+}
+
+// JobInfo 任务浏览管理中的任务信息
+type JobInfo struct {
+	State   string  `json:"state"`   // 任务状态
+	TimeAt  int64   `json:"time_at"` // 延迟任务的执行时刻、执行中任务的超时时刻，待执行任务为0
+	Payload Payload `json:"payload"` // 任务payload
+}
+
+// DeadJob 死信任务：最终执行失败的任务
+type DeadJob struct {
+	Payload  Payload `json:"Payload"`  // 任务完整payload，含失败尝试记录 Payload.History
+	Error    string  `json:"Error"`    // 最后一次失败的错误信息
+	FailedAt int64   `json:"FailedAt"` // 最终失败时刻时间戳
 }
 
 // RawBody PayLoad结构体获取载体实体
 func (payload *Payload) RawBody() *RawBody {
-	return &RawBody{queue: payload.Name, ID: payload.ID, payload: payload.Payload}
+	body, _ := payload.decodeBody(nil)
+	return body
+}
+
+// BatchProgress 批次进度
+type BatchProgress struct {
+	ID         string `json:"id"`          // 批次ID
+	Total      int64  `json:"total"`       // 批次内任务总数
+	Pending    int64  `json:"pending"`     // 未结束的任务数
+	Processed  int64  `json:"processed"`   // 执行成功的任务数
+	Failed     int64  `json:"failed"`      // 最终失败的任务数
+	CreatedAt  int64  `json:"created_at"`  // 批次创建时刻时间戳
+	FinishedAt int64  `json:"finished_at"` // 批次全部结束时刻时间戳，未结束为0
+}
+
+// Finished 批次内任务是否已全部结束
+func (p *BatchProgress) Finished() bool {
+	return p.Pending <= 0
+}
+
+// requeuePayload 死信任务重新入队的payload：尝试次数等执行状态重置，失败尝试记录保留
+func (job *DeadJob) requeuePayload() Payload {
+	payload := job.Payload
+	payload.Attempts = 0
+	payload.PopTime = 0
+	payload.TimeoutAt = 0
+	return payload
 }
 
 // FailedJobHandler 失败任务记录|处理回调方法
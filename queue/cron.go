@@ -0,0 +1,68 @@
+package queue
+
+import (
+	"fmt"
+	"github.com/robfig/cron/v3"
+	"time"
+)
+
+// *************************************************
+// 定时投递使用的cron表达式解析，基于 github.com/robfig/cron/v3 与crond模块一致
+// 1、6段：秒 分 时 日 月 周；5段：分 时 日 月 周，秒固定为0
+// 2、支持 * ? , - / 及月份、星期英文缩写（JAN-DEC、SUN-SAT），星期取值0-6，0为周日
+// 3、日、周同时指定时满足其一即可，与标准cron一致
+// 4、预定义：@yearly(@annually) @monthly @weekly @daily(@midnight) @hourly @every <duration>
+//    @every 按Unix时间对齐，集群中各节点计算出的时刻一致
+// *************************************************
+
+// cronParser 秒可选的cron表达式解析器
+var cronParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// cronSchedule cron表达式解析结果
+type cronSchedule interface {
+	Next(t time.Time) time.Time // t之后（不含t）的下一个触发时刻，无触发时刻时返回零值
+}
+
+// locatedSpec 按指定时区计算触发时刻的cron表达式
+type locatedSpec struct {
+	schedule cron.Schedule
+	location *time.Location
+}
+
+// everySpec @every 固定间隔
+type everySpec struct {
+	every time.Duration
+}
+
+// parseCron 解析cron表达式
+func parseCron(spec string, location *time.Location) (cronSchedule, error) {
+	if location == nil {
+		location = time.Local
+	}
+
+	schedule, err := cronParser.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("queue cron spec %q: %w", spec, err)
+	}
+
+	// @every 的间隔不足1秒时解析结果为1秒
+	if every, ok := schedule.(cron.ConstantDelaySchedule); ok {
+		return &everySpec{every: every.Delay}, nil
+	}
+	return &locatedSpec{schedule: schedule, location: location}, nil
+}
+
+// Next t之后按时区计算的下一个触发时刻，返回值与t同时区
+func (s *locatedSpec) Next(t time.Time) time.Time {
+	next := s.schedule.Next(t.In(s.location))
+	if next.IsZero() {
+		return next
+	}
+	return next.In(t.Location())
+}
+
+// Next t之后按Unix时间对齐的下一个触发时刻
+func (s *everySpec) Next(t time.Time) time.Time {
+	every := int64(s.every / time.Second)
+	return time.Unix((t.Unix()/every+1)*every, 0).In(t.Location())
+}
@@ -0,0 +1,118 @@
+package queue
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// deadLetterQueue 实现死信存储的底层驱动
+type deadLetterQueue interface {
+	QueueIFace
+	DeadLetterIFace
+}
+
+// deadLetterDrivers 实现死信存储的底层驱动构造方法
+var deadLetterDrivers = map[string]func(t *testing.T) deadLetterQueue{
+	"memory": func(t *testing.T) deadLetterQueue {
+		return &memoryQueue{}
+	},
+	"redis": func(t *testing.T) deadLetterQueue {
+		r, _ := newTestRedisQueue(t)
+		return r
+	},
+}
+
+// deadJob 构造失败时刻为failedAt的死信任务
+func deadJob(queue, id string, failedAt int64) *DeadJob {
+	return &DeadJob{
+		Payload: Payload{
+			Name:     queue,
+			ID:       id,
+			MaxTries: 3,
+			Attempts: 3,
+			Timeout:  60,
+			History:  []AttemptRecord{{Attempt: 3, Error: "failed", FailedAt: failedAt}},
+		},
+		Error:    "failed",
+		FailedAt: failedAt,
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	for name, newDriver := range deadLetterDrivers {
+		t.Run(name, func(t *testing.T) {
+			q := newDriver(t)
+			for i, id := range []string{"1", "2", "3", "4"} {
+				if err := q.Bury("a", deadJob("a", id, int64(100*(i+1)))); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// 按失败时刻倒序分页
+			jobs, total, err := q.DeadJobs("a", 1, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 4 || len(jobs) != 2 || jobs[0].Payload.ID != "3" || jobs[1].Payload.ID != "2" {
+				t.Fatalf("dead jobs = %+v total %d, want 3,2 of 4", jobs, total)
+			}
+			if jobs, _, _ = q.DeadJobs("a", 4, 2); len(jobs) != 0 {
+				t.Fatalf("dead jobs = %+v, want none past the end", jobs)
+			}
+
+			// 重新入队：尝试次数重新计数，失败尝试记录保留
+			if err = q.RequeueDead("a", "4"); err != nil {
+				t.Fatal(err)
+			}
+			if err = q.RequeueDead("a", "4"); !errors.Is(err, ErrDeadJobNotFound) {
+				t.Fatalf("requeue again err = %v, want ErrDeadJobNotFound", err)
+			}
+			job, exist := q.Pop("a")
+			if !exist || job.Payload().ID != "4" || job.Attempts() != 1 || len(job.Payload().History) != 1 {
+				t.Fatalf("requeued job exist %v, want job 4 at first attempt with history", exist)
+			}
+			if err = job.Delete(); err != nil {
+				t.Fatal(err)
+			}
+
+			// 清理指定时刻之前失败的死信任务
+			count, err := q.PurgeDead("a", time.Unix(100, 0))
+			if err != nil || count != 1 {
+				t.Fatalf("purge count = %d err %v, want 1", count, err)
+			}
+			if _, total, _ = q.DeadJobs("a", 0, 10); total != 2 {
+				t.Fatalf("total = %d, want 2 after purge", total)
+			}
+
+			// 全部重新入队
+			if count, err = q.RequeueAllDead("a"); err != nil || count != 2 {
+				t.Fatalf("requeue all count = %d err %v, want 2", count, err)
+			}
+			if _, total, _ = q.DeadJobs("a", 0, 10); total != 0 {
+				t.Fatalf("total = %d, want 0 after requeue all", total)
+			}
+			if size := q.Size("a"); size != 2 {
+				t.Fatalf("size = %d, want 2 requeued jobs", size)
+			}
+		})
+	}
+}
+
+func TestFileQueueDeadLetterNotJournaled(t *testing.T) {
+	dir := t.TempDir()
+	f := newTestFileQueue(t, dir)
+	if err := f.Bury("a", deadJob("a", "1", 100)); err != nil {
+		t.Fatal(err)
+	}
+	if _, total, _ := f.DeadJobs("a", 0, 10); total != 1 {
+		t.Fatalf("total = %d, want 1", total)
+	}
+	_ = f.file.Close()
+
+	// 死信仅保存在内存中：重启后丢失
+	restarted := newTestFileQueue(t, dir)
+	if _, total, _ := restarted.DeadJobs("a", 0, 10); total != 0 {
+		t.Fatalf("total after restart = %d, want dead letters not journaled", total)
+	}
+}
@@ -0,0 +1,142 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// *************************************************
+// 排空停机：停止取出新任务，截止时刻前等待执行中的任务完成，截止时刻仍在执行的任务主动交还
+// 1、交还的任务放回待执行队列头部且不计入尝试次数，其他节点可立即取出，无需等待任务超时时刻
+// 2、交还后本节点迟到的执行结果被丢弃，不再删除、释放任务，任务类需自主实现业务逻辑幂等
+// 3、底层驱动的job需实现 HandoffJobIFace（redis、database、memory、file均已实现），否则任务仍需等待超时后被重新取出
+// *************************************************
+
+// ShutdownReport 排空停机结果
+type ShutdownReport struct {
+	Drained   bool          // 执行中的任务是否均在截止时刻前执行完毕
+	Duration  time.Duration // 停机耗时
+	HandedOff []HandoffJob  // 截止时刻仍在执行的任务及其交还结果
+}
+
+// HandoffJob 截止时刻仍在执行的任务
+type HandoffJob struct {
+	Queue    string // 队列名称
+	ID       string // 任务ID
+	Attempts int64  // 被交还的执行是第几次尝试，交还成功后该次尝试不计入尝试次数
+	Err      error  // 交还失败的原因，nil为交还成功
+}
+
+// Drain 排空停机：截止时刻为ctx的截止时刻，ctx结束时仍在执行的任务交还待执行队列
+//   - 返回的error为交还失败的原因汇总，交还明细见 ShutdownReport.HandedOff
+// @param ctx 超时上下文
+func (q *Queue) Drain(ctx context.Context) (*ShutdownReport, error) {
+	return q.manager.drain(ctx)
+}
+
+// drain 排空停机：等待执行中的任务完成，截止时刻交还仍在执行的任务
+func (m *manager) drain(ctx context.Context) (report *ShutdownReport, err error) {
+	startAt := time.Now()
+	report = &ShutdownReport{}
+	shutdownErr := m.shutDown(ctx)
+
+	// 停机时已pop尚未交给worker而交还的job：无论是否排空均记入停机结果
+	m.lock.Lock()
+	report.HandedOff = append(report.HandedOff, m.handoffs...)
+	m.lock.Unlock()
+
+	if shutdownErr == nil {
+		report.Drained = true
+		report.Duration = time.Since(startAt)
+		return report, nil
+	}
+
+	var errs []error
+	m.runningJobs.Range(func(key, _ interface{}) bool {
+		job := key.(JobIFace)
+		handled, err := m.handoffJob(job)
+		if !handled {
+			return true
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+		report.HandedOff = append(report.HandedOff, HandoffJob{
+			Queue:    job.GetName(),
+			ID:       job.Payload().ID,
+			Attempts: job.Attempts(),
+			Err:      err,
+		})
+		return true
+	})
+	report.Duration = time.Since(startAt)
+
+	return report, errors.Join(errs...)
+}
+
+// handoffJob 交还执行中的job：先于执行协程认领job，认领成功后迟到的执行结果被丢弃
+//   - 执行协程已认领（任务恰好执行完毕正在收尾）时返回 handled=false，无需记入停机结果
+//   - 未实现 HandoffJobIFace 的job不认领，仍由执行协程收尾，返回 ErrHandoffNotSupported
+//   - 交还失败时任务仍处于执行中状态，到达超时时刻后被重新取出
+func (m *manager) handoffJob(job JobIFace) (handled bool, err error) {
+	handoff, ok := job.(HandoffJobIFace)
+	if !ok {
+		return true, ErrHandoffNotSupported
+	}
+
+	if _, claimed := m.runningJobs.LoadAndDelete(job); !claimed {
+		return false, nil
+	}
+
+	if err = handoff.Handoff(); err != nil {
+		m.logger.Error(
+			textJobHandoff,
+			"queue", job.GetName(),
+			"payload", IFaceToString(job.Payload()),
+			"error", err.Error(),
+		)
+		return true, err
+	}
+
+	m.logger.Warn(textJobHandoff, "queue", job.GetName(), "payload", IFaceToString(job.Payload()))
+	m.releaseJobSlot(job)
+	m.trackJob(job, nil, JobStatusPending, nil)
+	return true, nil
+}
+
+// returnJob 交还已pop尚未交给worker的job，交还成功返回true
+func (m *manager) returnJob(job JobIFace) bool {
+	handoff, ok := job.(HandoffJobIFace)
+	if !ok || handoff.Handoff() != nil {
+		return false
+	}
+
+	m.logger.Info(textJobHandoff, "queue", job.GetName(), "payload", IFaceToString(job.Payload()))
+	m.releaseJobSlot(job)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.handoffs = append(m.handoffs, HandoffJob{
+		Queue:    job.GetName(),
+		ID:       job.Payload().ID,
+		Attempts: job.Attempts(),
+	})
+	return true
+}
+
+// claimJob 执行结束认领job的收尾权，job已在排空停机时被交还则返回false，迟到的执行结果被丢弃
+func (m *manager) claimJob(job JobIFace, workerID int64) bool {
+	if _, loaded := m.runningJobs.LoadAndDelete(job); loaded {
+		return true
+	}
+
+	m.logger.Warn(
+		textJobHandoff,
+		"queue", job.GetName(),
+		"worker_id", IFaceToString(workerID),
+		"payload", IFaceToString(job.Payload()),
+		"result", "discarded",
+	)
+	return false
+}
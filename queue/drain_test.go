@@ -0,0 +1,93 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// blockTask 阻塞执行直至 release 关闭的任务类
+type blockTask struct {
+	DefaultTaskSetting
+	started chan struct{}
+	release chan struct{}
+}
+
+func (t *blockTask) Name() string   { return "block" }
+func (t *blockTask) Remark() string { return "block" }
+func (t *blockTask) Execute(ctx context.Context, job *RawBody) error {
+	close(t.started)
+	<-t.release
+	return nil
+}
+
+// returnPopped 模拟looper停机时交还已pop尚未交给worker的job
+func returnPopped(t *testing.T, q *Queue, name, id string) {
+	t.Helper()
+
+	pushTestJob(t, q.queue, name, id)
+	job, exist := q.queue.Pop(name)
+	if !exist || !q.manager.returnJob(job) {
+		t.Fatalf("return popped job %s failed", id)
+	}
+}
+
+func TestDrainReportsReturnedJobsWhenDrained(t *testing.T) {
+	q := New(Memory, nil, nopLogger{}, 1)
+	returnPopped(t, q, "a", "1")
+
+	report, err := q.Drain(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Drained {
+		t.Fatal("report not drained")
+	}
+	if len(report.HandedOff) != 1 || report.HandedOff[0].ID != "1" || report.HandedOff[0].Err != nil {
+		t.Fatalf("handed off = %+v, want returned job 1", report.HandedOff)
+	}
+}
+
+func TestDrainHandsOffRunningJobsAtDeadline(t *testing.T) {
+	task := &blockTask{started: make(chan struct{}), release: make(chan struct{})}
+	defer close(task.release)
+
+	q := New(Memory, nil, nopLogger{}, 1)
+	if err := q.BootstrapOne(task); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Dispatch(task, "running"); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-task.started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("job not started")
+	}
+	returnPopped(t, q, "a", "1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	report, err := q.Drain(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Drained {
+		t.Fatal("report drained, want running job handed off at deadline")
+	}
+	if len(report.HandedOff) != 2 {
+		t.Fatalf("handed off = %+v, want returned and running jobs", report.HandedOff)
+	}
+	if report.HandedOff[0].ID != "1" || report.HandedOff[1].Queue != task.Name() || report.HandedOff[1].Err != nil {
+		t.Fatalf("handed off = %+v, want returned job 1 then running job", report.HandedOff)
+	}
+
+	// 交还的任务回到待执行队列且不计入尝试次数
+	job, exist := q.queue.Pop(task.Name())
+	if !exist || job.Attempts() != 1 {
+		t.Fatalf("pop handed off job exist %v, want attempts 1", exist)
+	}
+}
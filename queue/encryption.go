@@ -0,0 +1,154 @@
+package queue
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// *************************************************
+// 任务参数加密存储：AES-GCM加密 Payload.Payload，底层驱动中仅存储密文
+// 1、Keyring 密钥环：一个当前加密密钥 + 若干仅用于解密的历史密钥，按密钥ID区分
+// 2、加密密钥ID随任务payload存储，消费端按记录的密钥ID解密；密钥轮换后历史密钥需保留至旧任务全部消费完毕
+// 3、处理顺序：编码 -> 压缩 -> 加密；消费端在任务类 Execute 之前透明解密
+// 4、任务名称作为附加认证数据，密文无法挪用到其他队列
+// *************************************************
+
+var (
+	// ErrEncryptionKeyNotFound 任务payload记录的密钥ID不在密钥环中 或 未设置密钥环
+	ErrEncryptionKeyNotFound = errors.New("queue.encryption.key.not.found")
+	// ErrEncryptionKeyInvalid 密钥长度不合法，需为16、24、32字节
+	ErrEncryptionKeyInvalid = errors.New("queue.encryption.key.invalid")
+)
+
+// Keyring AES-GCM密钥环，并发安全
+type Keyring struct {
+	lock   sync.RWMutex
+	active string                 // 当前加密密钥ID
+	keys   map[string]cipher.AEAD // 密钥ID => AEAD
+}
+
+// NewKeyring 实例化密钥环
+//   - id  当前加密密钥ID，随任务payload存储，请勿使用敏感信息
+//   - key AES密钥，16、24、32字节分别对应AES-128、AES-192、AES-256
+func NewKeyring(id string, key []byte) (*Keyring, error) {
+	keyring := &Keyring{keys: make(map[string]cipher.AEAD)}
+	if err := keyring.Rotate(id, key); err != nil {
+		return nil, err
+	}
+	return keyring, nil
+}
+
+// AddKey 添加仅用于解密的历史密钥
+func (k *Keyring) AddKey(id string, key []byte) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	k.keys[id] = aead
+	return nil
+}
+
+// Rotate 轮换密钥：添加新密钥并作为当前加密密钥，原密钥保留用于解密
+func (k *Keyring) Rotate(id string, key []byte) error {
+	if err := k.AddKey(id, key); err != nil {
+		return err
+	}
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	k.active = id
+	return nil
+}
+
+// RemoveKey 移除历史密钥，当前加密密钥不可移除
+func (k *Keyring) RemoveKey(id string) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if id != k.active {
+		delete(k.keys, id)
+	}
+}
+
+// encrypt 使用当前加密密钥加密，返回密钥ID和 nonce+密文
+func (k *Keyring) encrypt(plaintext, additional []byte) (id string, ciphertext []byte, err error) {
+	k.lock.RLock()
+	id, aead := k.active, k.keys[k.active]
+	k.lock.RUnlock()
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", nil, err
+	}
+	return id, aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+// decrypt 使用指定ID的密钥解密 nonce+密文
+func (k *Keyring) decrypt(id string, ciphertext, additional []byte) ([]byte, error) {
+	k.lock.RLock()
+	aead, ok := k.keys[id]
+	k.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrEncryptionKeyNotFound, id)
+	}
+
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("queue encrypted payload too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, additional)
+}
+
+// newAEAD 以AES密钥构造GCM
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, ErrEncryptionKeyInvalid
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SetEncryption 设置密钥环开启任务参数加密存储，需在投递任务、Start 之前调用；nil关闭加密
+//   - nil同时关闭解密，已加密的任务执行失败并返回 ErrEncryptionKeyNotFound，需保留密钥环设置直至加密任务全部消费完毕
+func (q *Queue) SetEncryption(keyring *Keyring) {
+	q.manager.keyring = keyring
+}
+
+// seal 加密任务参数：未设置密钥环、参数为空或已加密时不处理
+func (m *manager) seal(payload *Payload) error {
+	if m.keyring == nil || len(payload.Payload) == 0 || payload.KeyID != "" {
+		return nil
+	}
+
+	id, ciphertext, err := m.keyring.encrypt(payload.Payload, []byte(payload.Name))
+	if err != nil {
+		return err
+	}
+	payload.Payload = ciphertext
+	payload.KeyID = id
+	return nil
+}
+
+// open 解密任务参数：未加密的任务原样返回
+func (payload *Payload) open(keyring *Keyring) ([]byte, error) {
+	if payload.KeyID == "" {
+		return payload.Payload, nil
+	}
+	if keyring == nil {
+		return nil, fmt.Errorf("%w: %s", ErrEncryptionKeyNotFound, payload.KeyID)
+	}
+	return keyring.decrypt(payload.KeyID, payload.Payload, []byte(payload.Name))
+}
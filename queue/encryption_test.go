@@ -0,0 +1,129 @@
+package queue
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var (
+	testKeyOld = bytes.Repeat([]byte("o"), 32)
+	testKeyNew = bytes.Repeat([]byte("n"), 16)
+)
+
+// sealedPayload 以指定密钥环、压缩阈值构造加密的任务payload
+func sealedPayload(t *testing.T, keyring *Keyring, threshold int, param interface{}) Payload {
+	t.Helper()
+
+	q := New(Memory, nil, nopLogger{}, 1)
+	q.SetCompression(threshold)
+	q.SetEncryption(keyring)
+
+	payload, err := q.newTaskPayload(&codecTask{}, param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+// openPayload 解密解码任务参数，解密失败返回error
+func openPayload(payload Payload, keyring *Keyring) (param codecParam, err error) {
+	body, err := payload.decodeBody(keyring)
+	if err != nil {
+		return param, err
+	}
+	return param, body.Unmarshal(&param)
+}
+
+func TestEncryptionRoundTrip(t *testing.T) {
+	param := codecParam{Name: "alice", Tags: []string{"vip"}, Meta: map[string]string{"token": "secret"}}
+
+	for _, threshold := range []int{0, 1} {
+		keyring, err := NewKeyring("2024-01", testKeyOld)
+		if err != nil {
+			t.Fatal(err)
+		}
+		payload := sealedPayload(t, keyring, threshold, param)
+
+		// 底层驱动中仅存储密文
+		if payload.KeyID != "2024-01" || bytes.Contains(payload.Payload, []byte("secret")) {
+			t.Fatalf("threshold %d key %q payload %s, want sealed", threshold, payload.KeyID, payload.Payload)
+		}
+		if compressed := payload.Compression != ""; compressed != (threshold > 0) {
+			t.Fatalf("threshold %d compression = %q", threshold, payload.Compression)
+		}
+
+		got, err := openPayload(payload, keyring)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, param) {
+			t.Fatalf("threshold %d param = %+v, want %+v", threshold, got, param)
+		}
+
+		// 未设置密钥环时无法解密
+		if _, err = openPayload(payload, nil); !errors.Is(err, ErrEncryptionKeyNotFound) {
+			t.Fatalf("open without keyring err = %v, want ErrEncryptionKeyNotFound", err)
+		}
+	}
+
+	// 未加密的任务原样解码
+	plain := sealedPayload(t, nil, 0, param)
+	keyring, _ := NewKeyring("2024-01", testKeyOld)
+	if got, err := openPayload(plain, keyring); plain.KeyID != "" || err != nil || !reflect.DeepEqual(got, param) {
+		t.Fatalf("plain param = %+v err %v, want %+v", got, err, param)
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	param := codecParam{Name: "alice"}
+	keyring, err := NewKeyring("2024-01", testKeyOld)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := sealedPayload(t, keyring, 0, param)
+
+	// 轮换后新任务使用新密钥加密，旧任务仍可解密
+	if err = keyring.Rotate("2024-06", testKeyNew); err != nil {
+		t.Fatal(err)
+	}
+	current := sealedPayload(t, keyring, 0, param)
+	if current.KeyID != "2024-06" {
+		t.Fatalf("key = %q, want rotated key", current.KeyID)
+	}
+	for _, payload := range []Payload{old, current} {
+		if got, err := openPayload(payload, keyring); err != nil || got.Name != "alice" {
+			t.Fatalf("key %s param = %+v err %v, want decrypted", payload.KeyID, got, err)
+		}
+	}
+
+	// 当前加密密钥不可移除；移除历史密钥后旧任务无法解密
+	keyring.RemoveKey("2024-06")
+	keyring.RemoveKey("2024-01")
+	if _, err = openPayload(old, keyring); !errors.Is(err, ErrEncryptionKeyNotFound) {
+		t.Fatalf("open removed key err = %v, want ErrEncryptionKeyNotFound", err)
+	}
+	if _, err = openPayload(current, keyring); err != nil {
+		t.Fatalf("open active key err = %v", err)
+	}
+
+	// 重新载入历史密钥后可再次解密
+	if err = keyring.AddKey("2024-01", testKeyOld); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = openPayload(old, keyring); err != nil {
+		t.Fatalf("open re-added key err = %v", err)
+	}
+
+	// 任务名称作为附加认证数据：密文挪用到其他队列时解密失败
+	moved := current
+	moved.Name = "other"
+	if _, err = openPayload(moved, keyring); err == nil || errors.Is(err, ErrEncryptionKeyNotFound) {
+		t.Fatalf("open moved payload err = %v, want authentication failure", err)
+	}
+
+	if err = keyring.Rotate("bad", []byte("short")); !errors.Is(err, ErrEncryptionKeyInvalid) {
+		t.Fatalf("rotate invalid key err = %v, want ErrEncryptionKeyInvalid", err)
+	}
+}
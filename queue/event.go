@@ -0,0 +1,143 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// *************************************************
+// 任务生命周期事件钩子：可用于接入链路追踪、自定义监控告警等
+// 1、OnJobDispatched 任务投递成功后触发
+// 2、OnJobStart 任务每次执行前触发，可返回派生的context传递给任务类 Execute 方法（例如携带追踪span）
+// 3、OnJobDone 任务每次执行结束后触发，执行失败时 JobEvent.Err 不为nil
+// 4、OnJobFailed 任务最终失败（超过最大尝试次数）时触发
+// 钩子需在 Start 之前注册，钩子在worker协程中同步执行，请勿执行耗时操作
+// *************************************************
+
+// JobEvent 任务生命周期事件
+type JobEvent struct {
+	Queue    string        // 队列名称，即任务类 Name() 返回值
+	ID       string        // 任务ID
+	Attempts int64         // 当前尝试次数，投递事件为0
+	WorkerID int64         // 执行任务的worker ID，投递事件、最终失败事件为-1
+	Payload  *Payload      // 任务payload
+	StartAt  time.Time     // 本次执行开始时刻
+	Duration time.Duration // 本次执行耗时
+	Err      error         // 执行失败的错误，执行成功为nil
+}
+
+// JobStartHook 任务执行前钩子，返回的context将传递给任务类 Execute 方法，无需派生时原样返回ctx
+type JobStartHook func(ctx context.Context, event JobEvent) context.Context
+
+// JobEventHook 任务生命周期事件钩子
+type JobEventHook func(ctx context.Context, event JobEvent)
+
+// jobHooks 已注册的任务生命周期事件钩子
+type jobHooks struct {
+	dispatched []JobEventHook
+	start      []JobStartHook
+	done       []JobEventHook
+	failed     []JobEventHook
+}
+
+// OnJobDispatched 注册任务投递成功事件钩子
+func (q *Queue) OnJobDispatched(hook JobEventHook) {
+	q.manager.hooks.dispatched = append(q.manager.hooks.dispatched, hook)
+}
+
+// OnJobStart 注册任务执行前事件钩子
+func (q *Queue) OnJobStart(hook JobStartHook) {
+	q.manager.hooks.start = append(q.manager.hooks.start, hook)
+}
+
+// OnJobDone 注册任务执行结束事件钩子
+func (q *Queue) OnJobDone(hook JobEventHook) {
+	q.manager.hooks.done = append(q.manager.hooks.done, hook)
+}
+
+// OnJobFailed 注册任务最终失败事件钩子
+func (q *Queue) OnJobFailed(hook JobEventHook) {
+	q.manager.hooks.failed = append(q.manager.hooks.failed, hook)
+}
+
+// jobDispatched 任务投递成功：指标计数 && 触发钩子
+func (m *manager) jobDispatched(name string, raw []byte) {
+	if m.metrics != nil {
+		m.metrics.IncDispatched(name)
+	}
+	if len(m.hooks.dispatched) == 0 {
+		return
+	}
+
+	var payload Payload
+	if json.Unmarshal(raw, &payload) != nil {
+		return
+	}
+	event := JobEvent{Queue: name, ID: payload.ID, WorkerID: -1, Payload: &payload}
+	for _, hook := range m.hooks.dispatched {
+		hook(context.Background(), event)
+	}
+}
+
+// jobStarted 任务执行前：触发钩子，返回传递给任务类的context
+func (m *manager) jobStarted(ctx context.Context, job JobIFace, workerID int64, startAt time.Time) context.Context {
+	if len(m.hooks.start) == 0 {
+		return ctx
+	}
+
+	event := m.jobEvent(job, workerID, startAt, nil)
+	for _, hook := range m.hooks.start {
+		if derived := hook(ctx, event); derived != nil {
+			ctx = derived
+		}
+	}
+	return ctx
+}
+
+// jobDone 任务执行结束：记录耗时 && 触发钩子
+func (m *manager) jobDone(ctx context.Context, job JobIFace, workerID int64, startAt time.Time, err error) {
+	if m.metrics != nil {
+		m.metrics.ObserveDuration(job.GetName(), time.Since(startAt))
+	}
+	if len(m.hooks.done) == 0 {
+		return
+	}
+
+	event := m.jobEvent(job, workerID, startAt, err)
+	for _, hook := range m.hooks.done {
+		hook(ctx, event)
+	}
+}
+
+// jobFailed 任务最终失败：指标计数 && 触发钩子
+func (m *manager) jobFailed(job JobIFace, err error) {
+	if m.metrics != nil {
+		m.metrics.IncFailed(job.GetName())
+	}
+	if len(m.hooks.failed) == 0 {
+		return
+	}
+
+	event := m.jobEvent(job, -1, time.Time{}, err)
+	for _, hook := range m.hooks.failed {
+		hook(context.Background(), event)
+	}
+}
+
+// jobEvent 构造任务生命周期事件
+func (m *manager) jobEvent(job JobIFace, workerID int64, startAt time.Time, err error) JobEvent {
+	event := JobEvent{
+		Queue:    job.GetName(),
+		ID:       job.Payload().ID,
+		Attempts: job.Attempts(),
+		WorkerID: workerID,
+		Payload:  job.Payload(),
+		StartAt:  startAt,
+		Err:      err,
+	}
+	if !startAt.IsZero() {
+		event.Duration = time.Since(startAt)
+	}
+	return event
+}
@@ -0,0 +1,81 @@
+package queue
+
+import (
+	"errors"
+	"time"
+)
+
+// *************************************************
+// 执行中任务心跳：任务执行期间周期性延长任务的保留时刻及其持有的限流名额有效期
+// 1、保留时刻仅在pop时按任务超时时长设置一次，任务取出后等待worker、超时宽限期内仍在执行时会被视为超时重新取出
+// 2、开启心跳后保留时刻持续延长至 当前时刻+心跳间隔*heartbeatLeaseFactor，只有worker崩溃的任务才会到期被重新取出
+// 3、底层驱动的job需实现 HeartbeatJobIFace（redis、database、memory、file均已实现），否则心跳不生效
+// *************************************************
+
+// heartbeatLeaseFactor 每次心跳延长的保留时长为心跳间隔的倍数，容忍偶发的心跳失败
+const heartbeatLeaseFactor = 3
+
+// SetHeartbeat 设置执行中任务心跳间隔，默认 DefaultHeartbeatInterval，小于等于0关闭心跳
+//   - 需在 Start 之前调用
+func (q *Queue) SetHeartbeat(interval time.Duration) {
+	q.manager.heartbeat = interval
+}
+
+// startHeartbeat 开始执行中job的心跳，返回停止心跳的方法
+func (m *manager) startHeartbeat(job JobIFace) (stop func()) {
+	beater, ok := job.(HeartbeatJobIFace)
+	if !ok || m.heartbeat <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(m.heartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			if !m.beat(job, beater) {
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
+}
+
+// beat 一次心跳：延长job保留时刻及其持有的限流名额有效期，job已不处于保留状态时返回false结束心跳
+func (m *manager) beat(job JobIFace, beater HeartbeatJobIFace) (alive bool) {
+	// 任务已结束：心跳与删除、释放任务并发时无需再延长
+	if job.IsDeleted() || job.IsReleased() {
+		return false
+	}
+
+	lease := m.heartbeat * heartbeatLeaseFactor
+	if err := beater.Heartbeat(lease); err != nil {
+		m.logger.Warn(
+			textJobHeartbeat,
+			"queue", job.GetName(),
+			"payload", IFaceToString(job.Payload()),
+			"error", err.Error(),
+		)
+		if errors.Is(err, ErrJobNotFound) {
+			return false
+		}
+	}
+
+	if slot, ok := m.jobSlots.Load(job); ok {
+		if err := m.limiter().ExtendSlot(job.GetName(), slot.(*jobSlot).id, lease); err != nil {
+			m.logger.Warn(textJobHeartbeat, "queue", job.GetName(), "error", err.Error())
+		}
+	}
+
+	return true
+}
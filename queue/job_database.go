@@ -0,0 +1,175 @@
+package queue
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+type JobDatabase struct {
+	database *databaseQueue // 所属数据库队列
+	rowID    int64          // 任务记录主键ID
+	version  int64          // 保留任务时的attempts字段值，乐观锁版本号
+	leaseAt  int64          // 保留超时时刻 reserved_until 的当前值，心跳延长时更新
+	lock     sync.Mutex     // 防幻读锁
+	jobProperty
+}
+
+// Release 释放任务job：job重新再试--清空保留超时时刻，可执行时刻为重试时刻
+func (job *JobDatabase) Release(delay int64) (err error) {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+
+	job.isReleased = true
+
+	// 以reserved状态的payload为基础（尝试次数等已更新）带上最新的失败尝试记录和重试延迟时长
+	var released Payload
+	if err = job.database.unmarshalPayload([]byte(job.reserved), &released); err != nil {
+		return err
+	}
+	released.History = job.payload.History
+	released.RetryDelay = delay
+	payload, err := json.Marshal(released)
+	if err != nil {
+		return err
+	}
+
+	// 版本号不一致说明任务已超时被其他消费者重新取走，与redis驱动一致不做处理
+	_, err = job.database.db.Exec(
+		"UPDATE "+job.database.quote(job.database.table)+" SET payload = ?, reserved_until = NULL, available_at = ? WHERE id = ? AND attempts = ?",
+		string(payload),
+		time.Now().Add(time.Duration(delay)*time.Second).Unix(),
+		job.rowID,
+		job.version,
+	)
+
+	return err
+}
+
+// Delete 删除任务job：任务不再执行--删除任务记录
+func (job *JobDatabase) Delete() (err error) {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+	job.isDeleted = true
+
+	_, err = job.database.db.Exec(
+		"DELETE FROM "+job.database.quote(job.database.table)+" WHERE id = ? AND attempts = ?",
+		job.rowID,
+		job.version,
+	)
+
+	return err
+}
+
+// Heartbeat 延长任务保留时刻：仅更新本次保留（版本号一致且未释放）的任务记录的 reserved_until
+func (job *JobDatabase) Heartbeat(lease time.Duration) (err error) {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+
+	leaseAt := time.Now().Add(lease).Unix()
+	if job.leaseAt >= leaseAt {
+		return nil
+	}
+
+	ret, err := job.database.db.Exec(
+		"UPDATE "+job.database.quote(job.database.table)+" SET reserved_until = ? WHERE id = ? AND attempts = ? AND reserved_until IS NOT NULL",
+		leaseAt,
+		job.rowID,
+		job.version,
+	)
+	if err != nil {
+		return err
+	}
+	if affected, err := ret.RowsAffected(); err != nil || affected != 1 {
+		return ErrJobNotFound
+	}
+	job.leaseAt = leaseAt
+	return nil
+}
+
+// Handoff 交还任务job：payload恢复为取出前的原始值，清空保留超时时刻立即可执行，本次执行不计入尝试次数
+//   - attempts 字段自增使本次保留的版本号失效，交还后迟到的删除、释放不再生效
+func (job *JobDatabase) Handoff() (err error) {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+
+	ret, err := job.database.db.Exec(
+		"UPDATE "+job.database.quote(job.database.table)+" SET payload = ?, attempts = ?, reserved_until = NULL, available_at = ? WHERE id = ? AND attempts = ? AND reserved_until IS NOT NULL",
+		job.job,
+		job.version+1,
+		time.Now().Unix(),
+		job.rowID,
+		job.version,
+	)
+	if err != nil {
+		return err
+	}
+	if affected, err := ret.RowsAffected(); err != nil || affected != 1 {
+		return ErrJobNotFound
+	}
+
+	job.isReleased = true
+	return nil
+}
+
+func (job *JobDatabase) IsDeleted() (deleted bool) {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+	return job.isDeleted
+}
+
+func (job *JobDatabase) IsReleased() (released bool) {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+	return job.isReleased
+}
+
+// Attempts 获取当前job已被尝试执行的次数
+func (job *JobDatabase) Attempts() (attempt int64) {
+	return job.payload.Attempts + 1
+}
+
+// PopTime 任务job首次被执行的时刻
+func (job *JobDatabase) PopTime() (time time.Time) {
+	return job.popTime
+}
+
+// Timeout 任务超时时长
+func (job *JobDatabase) Timeout() (time time.Duration) {
+	return job.jobProperty.timeout
+}
+
+// TimeoutAt 任务job执行超时的时刻
+func (job *JobDatabase) TimeoutAt() (time time.Time) {
+	return job.jobProperty.timeoutAt
+}
+
+func (job *JobDatabase) HasFailed() (hasFail bool) {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+	return job.hasFailed
+}
+
+func (job *JobDatabase) MarkAsFailed() {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+	job.hasFailed = true
+}
+
+func (job *JobDatabase) Failed(err error) {
+	// 数据库技术栈下实现的队列失败没有后续动作
+	// 任务失败外部记录通过初始化队列时调用 SetFailedJobHandler 设置
+	return
+}
+
+func (job *JobDatabase) GetName() (queueName string) {
+	return job.name
+}
+
+func (job *JobDatabase) Queue() (queue QueueIFace) {
+	return job.handler
+}
+
+func (job *JobDatabase) Payload() (payload *Payload) {
+	return job.payload
+}
@@ -11,63 +11,133 @@ import (
  */
 
 type JobMemory struct {
-	basic       queueBasic
-	delayed     map[string]map[string]*itemValue // 延迟map ref type
-	reserved    map[string]map[string]*itemValue // 保留map ref type
-	reservedJob Payload                          // 处理后的保留状态的job
+	memory      *memoryQueue // 所属memory队列，操作延迟、保留map需持有其锁
+	reservedJob Payload      // 处理后的保留状态的job
 	jobProperty
 }
 
 func (job *JobMemory) Release(delay int64) (err error) {
+	job.memory.lock.Lock()
+	defer job.memory.lock.Unlock()
+
 	job.isReleased = true
 
-	if _, exist := job.reserved[job.GetName()]; !exist {
+	if _, exist := job.memory.reserved[job.GetName()]; !exist {
 		return fmt.Errorf("queue %s do no exist", job.GetName())
 	}
 
-	if _, exist := job.reserved[job.GetName()][job.payload.ID]; !exist {
+	// 尝试次数不一致说明任务已超时或被重新入队后再次取出
+	if item, exist := job.memory.reserved[job.GetName()][job.payload.ID]; !exist || item.Payload.Attempts != job.reservedJob.Attempts {
 		return fmt.Errorf("queue %s do no exist this job, id=%s", job.GetName(), job.payload.ID)
 	}
 
-	if _, exist := job.delayed[job.GetName()]; !exist {
+	if _, exist := job.memory.delayed[job.GetName()]; !exist {
 		return fmt.Errorf("queue %s do no exist", job.GetName())
 	}
 
 	// 从保留队列删除
-	delete(job.reserved[job.GetName()], job.payload.ID)
+	delete(job.memory.reserved[job.GetName()], job.payload.ID)
 
-	// 移动到延迟队列
+	// 移动到延迟队列：带上最新的失败尝试记录和重试延迟时长
+	released := job.reservedJob
+	released.History = job.payload.History
+	released.RetryDelay = delay
 	itemV := itemValue{
-		Payload: job.reservedJob,
-		TimeAt:  time.Now().Add(time.Duration(delay) * time.Second).Unix(),
+		Payload: released,
+		TimeAt:  job.memory.now().Add(time.Duration(released.RetryDelay) * time.Second).Unix(),
 	}
-	job.delayed[job.GetName()][job.payload.ID] = &itemV
+	job.memory.delayed[job.GetName()][job.payload.ID] = &itemV
+	job.memory.wakeup()
 
-	return nil
+	return job.memory.journalPut(job.GetName(), journalDelayed, &itemV)
 }
 
 func (job *JobMemory) Delete() (err error) {
+	job.memory.lock.Lock()
+	defer job.memory.lock.Unlock()
+
 	job.isDeleted = true
 
-	if _, exist := job.reserved[job.GetName()]; !exist {
+	if _, exist := job.memory.reserved[job.GetName()]; !exist {
 		return fmt.Errorf("queue %s do no exist", job.GetName())
 	}
 
-	if _, exist := job.reserved[job.GetName()][job.payload.ID]; !exist {
+	// 尝试次数不一致说明任务已超时或被重新入队后再次取出
+	if item, exist := job.memory.reserved[job.GetName()][job.payload.ID]; !exist || item.Payload.Attempts != job.reservedJob.Attempts {
 		return fmt.Errorf("queue %s do no exist this job, id=%s", job.GetName(), job.payload.ID)
 	}
 
 	// 从保留队列删除
-	delete(job.reserved[job.GetName()], job.payload.ID)
+	delete(job.memory.reserved[job.GetName()], job.payload.ID)
+	if err = job.memory.journalDel(job.GetName(), job.payload.ID); err != nil {
+		return err
+	}
+
+	// 唯一任务同时释放唯一锁
+	if job.payload.UniqueKey != "" {
+		job.memory.releaseUnique(job.GetName(), job.payload.UniqueKey, job.payload.ID)
+	}
 
 	return nil
 }
 
+// Heartbeat 延长任务保留时刻：仅延长保留map中本次保留的job的超时时刻
+func (job *JobMemory) Heartbeat(lease time.Duration) (err error) {
+	job.memory.lock.Lock()
+	defer job.memory.lock.Unlock()
+
+	// 尝试次数不一致说明任务已超时被重新取出
+	item, exist := job.memory.reserved[job.GetName()][job.payload.ID]
+	if !exist || item.Payload.Attempts != job.reservedJob.Attempts {
+		return ErrJobNotFound
+	}
+
+	timeAt := job.memory.now().Add(lease).Unix()
+	if item.TimeAt >= timeAt {
+		return nil
+	}
+	item.TimeAt = timeAt
+	return job.memory.journalPut(job.GetName(), journalReserved, item)
+}
+
+// Handoff 交还任务job：从保留map移回list头部，尝试次数恢复为取出前的值
+func (job *JobMemory) Handoff() (err error) {
+	job.memory.lock.Lock()
+	defer job.memory.lock.Unlock()
+
+	// 尝试次数不一致说明任务已超时被重新取出
+	item, exist := job.memory.reserved[job.GetName()][job.payload.ID]
+	if !exist || item.Payload.Attempts != job.reservedJob.Attempts {
+		return ErrJobNotFound
+	}
+
+	job.isReleased = true
+
+	// 从保留队列删除
+	delete(job.memory.reserved[job.GetName()], job.payload.ID)
+
+	// 放回list头部：本次执行不计入尝试次数
+	handed := job.reservedJob
+	handed.Attempts--
+	itemV := &itemValue{
+		Payload: handed,
+		TimeAt:  0,
+	}
+	job.memory.list[job.GetName()].PushFront(itemV)
+	job.memory.wakeup()
+
+	return job.memory.journalPut(job.GetName(), journalFront, itemV)
+}
+
 func (job *JobMemory) IsDeleted() (deleted bool) {
+	job.memory.lock.Lock()
+	defer job.memory.lock.Unlock()
 	return job.isDeleted
 }
 
 func (job *JobMemory) IsReleased() (released bool) {
+	job.memory.lock.Lock()
+	defer job.memory.lock.Unlock()
 	return job.isReleased
 }
 
@@ -90,10 +160,14 @@ func (job *JobMemory) TimeoutAt() (time time.Time) {
 }
 
 func (job *JobMemory) HasFailed() (hasFail bool) {
+	job.memory.lock.Lock()
+	defer job.memory.lock.Unlock()
 	return job.hasFailed
 }
 
 func (job *JobMemory) MarkAsFailed() {
+	job.memory.lock.Lock()
+	defer job.memory.lock.Unlock()
 	job.hasFailed = true
 }
 
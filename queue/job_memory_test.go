@@ -0,0 +1,33 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestJobMemoryStateConcurrent(t *testing.T) {
+	m := &memoryQueue{}
+	pushTestJob(t, m, "a", "1")
+	job, _ := m.Pop("a")
+
+	// 心跳协程读取状态的同时worker删除、释放任务：go test -race 下不应报告数据竞争
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_ = job.IsDeleted() || job.IsReleased() || job.HasFailed()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		job.MarkAsFailed()
+		_ = job.Release(0)
+		_ = job.Delete()
+	}()
+	wg.Wait()
+
+	if !job.IsDeleted() || !job.IsReleased() || !job.HasFailed() {
+		t.Fatal("job state not updated")
+	}
+}
@@ -2,6 +2,7 @@ package queue
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/go-redis/redis/v8"
 	"sync"
 	"time"
@@ -27,17 +28,40 @@ func (job *JobRedis) Release(delay int64) (err error) {
 
 	job.isReleased = true
 
+	// 以reserved状态的payload为基础（尝试次数等已更新）带上最新的失败尝试记录和重试延迟时长
+	var released Payload
+	if err = job.basic.unmarshalPayload([]byte(job.reserved), &released); err != nil {
+		return err
+	}
+	released.History = job.payload.History
+	released.RetryDelay = delay
+	payload, err := json.Marshal(released)
+	if err != nil {
+		return err
+	}
+
 	ctx := context.Background()
 	// delete reserved zSet, then push it to delayed zSet
-	err = job.luaScripts.Release().Run(
+	// 已被管理接口删除、重新入队或超时重新取出的job不再释放，避免重复
+	exist, err := job.luaScripts.Release().Run(
 		ctx,
 		job.redis,
 		[]string{job.basic.delayedName(job.name), job.basic.reservedName(job.name)},
 		job.reserved,
-		time.Now().Add(time.Duration(delay)*time.Second).Unix(),
-	).Err()
-
-	return err
+		time.Now().Unix(),
+		payload,
+	).Int64()
+	if err != nil {
+		return err
+	}
+	if exist == 0 {
+		return ErrJobNotFound
+	}
+
+	// 通知等待中的looper重新计算延迟任务的到期时刻
+	job.redis.Publish(ctx, job.basic.notifyName(), job.name)
+
+	return nil
 }
 
 // Delete 删除任务job：任务不再执行--从reserved有序集合删除
@@ -48,9 +72,75 @@ func (job *JobRedis) Delete() (err error) {
 
 	// delete reserved job from zSet
 	ctx := context.Background()
-	err = job.redis.ZRem(ctx, job.basic.reservedName(job.name), job.reserved).Err()
+	if job.payload.UniqueKey == "" {
+		err = job.redis.ZRem(ctx, job.basic.reservedName(job.name), job.reserved).Err()
+		return err
+	}
+
+	// 唯一任务同时释放唯一锁：job已不在保留中时唯一锁属于重新入队的job，不释放
+	exist, err := job.luaScripts.DeleteUnique().Run(
+		ctx,
+		job.redis,
+		[]string{job.basic.reservedName(job.name), job.basic.uniqueName(job.name, job.payload.UniqueKey)},
+		job.reserved,
+		job.payload.ID,
+	).Int64()
+	if err != nil {
+		return err
+	}
+	if exist == 0 {
+		return ErrJobNotFound
+	}
+
+	return nil
+}
+
+// Heartbeat 延长任务保留时刻：仅延长reserved有序集合中本次保留的job的Score值
+func (job *JobRedis) Heartbeat(lease time.Duration) (err error) {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+
+	exist, err := job.luaScripts.ExtendScore().Run(
+		context.Background(),
+		job.redis,
+		[]string{job.basic.reservedName(job.name)},
+		job.reserved,
+		time.Now().Add(lease).Unix(),
+	).Int64()
+	if err != nil {
+		return err
+	}
+	if exist == 0 {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+// Handoff 交还任务job：从reserved有序集合丢回队列list头部，payload恢复为取出前的原始值，本次执行不计入尝试次数
+func (job *JobRedis) Handoff() (err error) {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+
+	ctx := context.Background()
+	exist, err := job.luaScripts.Handoff().Run(
+		ctx,
+		job.redis,
+		[]string{job.basic.reservedName(job.name), job.basic.name(job.name)},
+		job.reserved,
+		job.job,
+	).Int64()
+	if err != nil {
+		return err
+	}
+	if exist == 0 {
+		return ErrJobNotFound
+	}
+
+	job.isReleased = true
+	// 通知等待中的looper立即取出
+	job.redis.Publish(ctx, job.basic.notifyName(), job.name)
 
-	return err
+	return nil
 }
 
 func (job *JobRedis) IsDeleted() (deleted bool) {
@@ -0,0 +1,202 @@
+package queue
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// *************************************************
+// 任务限流：单个任务的最大并发执行数 && 令牌桶限速
+// 1、任务类可选实现 TaskConcurrencyIFace、TaskRateLimitIFace 声明限流规则
+// 2、looper在pop任务之前先获取执行名额，名额耗尽时跳过该任务的pop，不占用全局worker
+// 3、底层驱动实现了 LimiterQueueIFace 的由驱动实现限流（redis驱动跨进程生效），否则使用进程内限流
+// *************************************************
+
+// TaskConcurrencyIFace 任务类可选实现：限制任务的最大并发执行数
+type TaskConcurrencyIFace interface {
+	MaxConcurrency() int64 // 最大并发执行数，小于等于0不限制
+}
+
+// TaskRateLimitIFace 任务类可选实现：令牌桶限制任务的执行速率
+type TaskRateLimitIFace interface {
+	RateLimit() (rate float64, burst int64) // 每秒生成的令牌数 && 令牌桶容量，rate小于等于0不限制，burst小于等于0时取 ceil(rate)
+}
+
+// TaskLimit 任务限流规则
+type TaskLimit struct {
+	MaxConcurrency int64   // 最大并发执行数，小于等于0不限制
+	Rate           float64 // 每秒生成的令牌数，小于等于0不限制
+	Burst          int64   // 令牌桶容量
+}
+
+// limited 是否存在限流规则
+func (l TaskLimit) limited() bool {
+	return l.MaxConcurrency > 0 || l.Rate > 0
+}
+
+// taskLimit 获取任务类声明的限流规则
+func taskLimit(task TaskIFace) (limit TaskLimit) {
+	if t, ok := task.(TaskConcurrencyIFace); ok {
+		limit.MaxConcurrency = t.MaxConcurrency()
+	}
+	if t, ok := task.(TaskRateLimitIFace); ok {
+		limit.Rate, limit.Burst = t.RateLimit()
+		if limit.Rate > 0 && limit.Burst <= 0 {
+			limit.Burst = int64(math.Ceil(limit.Rate))
+		}
+	}
+	return limit
+}
+
+// region 进程内限流实现
+
+// tokenBucket 令牌桶
+type tokenBucket struct {
+	tokens    float64   // 当前令牌数
+	updatedAt time.Time // 令牌数更新时刻
+}
+
+// localLimiter 进程内限流：memory驱动及未实现 LimiterQueueIFace 的驱动使用
+type localLimiter struct {
+	lock    sync.Mutex
+	slots   map[string]map[string]time.Time // 队列名 => 名额ID => 名额过期时刻
+	buckets map[string]*tokenBucket         // 队列名 => 令牌桶
+}
+
+// AcquireSlot 尝试获取一个执行名额
+func (l *localLimiter) AcquireSlot(queue, slot string, limit TaskLimit, ttl time.Duration) (bool, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.slots == nil {
+		l.slots = make(map[string]map[string]time.Time)
+		l.buckets = make(map[string]*tokenBucket)
+	}
+	if _, exist := l.slots[queue]; !exist {
+		l.slots[queue] = make(map[string]time.Time)
+	}
+
+	now := time.Now()
+
+	// step1、并发数检查：先清理已过期的名额
+	if limit.MaxConcurrency > 0 {
+		for id, expireAt := range l.slots[queue] {
+			if !expireAt.After(now) {
+				delete(l.slots[queue], id)
+			}
+		}
+		if int64(len(l.slots[queue])) >= limit.MaxConcurrency {
+			return false, nil
+		}
+	}
+
+	// step2、令牌桶检查：按流逝时长补充令牌
+	if limit.Rate > 0 {
+		bucket, exist := l.buckets[queue]
+		if !exist {
+			bucket = &tokenBucket{tokens: float64(limit.Burst), updatedAt: now}
+			l.buckets[queue] = bucket
+		}
+		tokens := math.Min(float64(limit.Burst), bucket.tokens+now.Sub(bucket.updatedAt).Seconds()*limit.Rate)
+		if tokens < 1 {
+			return false, nil
+		}
+		bucket.tokens = tokens - 1
+		bucket.updatedAt = now
+	}
+
+	// step3、占用名额
+	if limit.MaxConcurrency > 0 {
+		l.slots[queue][slot] = now.Add(ttl)
+	}
+
+	return true, nil
+}
+
+// ReleaseSlot 释放执行名额
+func (l *localLimiter) ReleaseSlot(queue, slot string, limit TaskLimit, refund bool) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if slots, exist := l.slots[queue]; exist {
+		delete(slots, slot)
+	}
+	if bucket, exist := l.buckets[queue]; exist && refund {
+		bucket.tokens = math.Min(float64(limit.Burst), bucket.tokens+1)
+	}
+
+	return nil
+}
+
+// ExtendSlot 延长执行名额的有效期
+func (l *localLimiter) ExtendSlot(queue, slot string, ttl time.Duration) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	expireAt := time.Now().Add(ttl)
+	if current, exist := l.slots[queue][slot]; exist && current.Before(expireAt) {
+		l.slots[queue][slot] = expireAt
+	}
+
+	return nil
+}
+
+// endregion
+
+// region manager限流相关方法
+
+// jobSlot job持有的执行名额
+type jobSlot struct {
+	id    string    // 名额ID
+	limit TaskLimit // 限流规则
+}
+
+// limiter 获取限流实现：优先使用底层驱动实现
+func (m *manager) limiter() LimiterQueueIFace {
+	if limiter, ok := m.queue.(LimiterQueueIFace); ok {
+		return limiter
+	}
+	return &m.localLimiter
+}
+
+// acquireSlot pop任务前尝试获取执行名额
+//   - 任务无限流规则时返回 ok 为true、slot 为nil
+//   - 名额耗尽或获取出错时返回 ok 为false，本轮跳过该任务
+func (m *manager) acquireSlot(task TaskIFace) (slot *jobSlot, ok bool) {
+	limit := taskLimit(task)
+	if !limit.limited() {
+		return nil, true
+	}
+
+	slot = &jobSlot{id: FakeUniqueID(), limit: limit}
+	ok, err := m.limiter().AcquireSlot(task.Name(), slot.id, limit, task.Timeout()+m.grace+time.Minute)
+	if err != nil {
+		m.logger.Warn(textJobLimitFailed, "queue", task.Name(), "error", err.Error())
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	return slot, true
+}
+
+// releaseSlot 释放执行名额
+//   - refund 获取名额后未pop到任务时退还令牌
+func (m *manager) releaseSlot(name string, slot *jobSlot, refund bool) {
+	if slot == nil {
+		return
+	}
+	if err := m.limiter().ReleaseSlot(name, slot.id, slot.limit, refund); err != nil {
+		m.logger.Warn(textJobLimitFailed, "queue", name, "error", err.Error())
+	}
+}
+
+// releaseJobSlot job执行结束后释放其持有的执行名额
+func (m *manager) releaseJobSlot(job JobIFace) {
+	if slot, ok := m.jobSlots.LoadAndDelete(job); ok {
+		m.releaseSlot(job.GetName(), slot.(*jobSlot), false)
+	}
+}
+
+// endregion
@@ -0,0 +1,183 @@
+package queue
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// limitedTask 限流的任务类
+type limitedTask struct {
+	DefaultTaskSetting
+	limit TaskLimit
+}
+
+func (t *limitedTask) Name() string                                    { return "limited" }
+func (t *limitedTask) Remark() string                                  { return "limited" }
+func (t *limitedTask) Execute(ctx context.Context, job *RawBody) error { return nil }
+func (t *limitedTask) MaxConcurrency() int64                           { return t.limit.MaxConcurrency }
+func (t *limitedTask) RateLimit() (float64, int64)                     { return t.limit.Rate, t.limit.Burst }
+
+// testLimiter 限流实现及令牌桶时刻回拨方法，回拨模拟时间流逝
+type testLimiter struct {
+	LimiterQueueIFace
+	rewind func(queue string, d time.Duration)
+}
+
+// limiterDrivers 限流实现构造方法：进程内限流、redis驱动lua脚本限流
+var limiterDrivers = map[string]func(t *testing.T) testLimiter{
+	"local": func(t *testing.T) testLimiter {
+		l := &localLimiter{}
+		return testLimiter{l, func(queue string, d time.Duration) {
+			l.buckets[queue].updatedAt = l.buckets[queue].updatedAt.Add(-d)
+		}}
+	},
+	"redis": func(t *testing.T) testLimiter {
+		r, mr := newTestRedisQueue(t)
+		return testLimiter{r, func(queue string, d time.Duration) {
+			rewindRedisBucket(t, mr, r.limiterBucketName(queue), d)
+		}}
+	},
+}
+
+func rewindRedisBucket(t *testing.T, mr *miniredis.Miniredis, key string, d time.Duration) {
+	t.Helper()
+
+	ts, err := strconv.ParseInt(mr.HGet(key, "ts"), 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr.HSet(key, "ts", strconv.FormatInt(ts-d.Milliseconds(), 10))
+}
+
+// acquire 获取名额并断言结果
+func acquire(t *testing.T, l LimiterQueueIFace, slot string, limit TaskLimit, ttl time.Duration, want bool) {
+	t.Helper()
+
+	ok, err := l.AcquireSlot("limited", slot, limit, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok != want {
+		t.Fatalf("acquire %s = %v, want %v", slot, ok, want)
+	}
+}
+
+func TestLimiterConcurrency(t *testing.T) {
+	for name, newLimiter := range limiterDrivers {
+		t.Run(name, func(t *testing.T) {
+			l := newLimiter(t)
+			limit := TaskLimit{MaxConcurrency: 2}
+
+			acquire(t, l, "s1", limit, time.Minute, true)
+			acquire(t, l, "s2", limit, time.Minute, true)
+			acquire(t, l, "s3", limit, time.Minute, false)
+
+			// 释放后名额可再次获取
+			if err := l.ReleaseSlot("limited", "s1", limit, false); err != nil {
+				t.Fatal(err)
+			}
+			acquire(t, l, "s3", limit, time.Minute, true)
+			acquire(t, l, "s4", limit, time.Minute, false)
+
+			// 已过期的名额（执行进程崩溃未释放）不占用并发数
+			if err := l.ReleaseSlot("limited", "s3", limit, false); err != nil {
+				t.Fatal(err)
+			}
+			acquire(t, l, "crashed", limit, -time.Second, true)
+			acquire(t, l, "s5", limit, time.Minute, true)
+			acquire(t, l, "s6", limit, time.Minute, false)
+		})
+	}
+}
+
+func TestLimiterTokenBucket(t *testing.T) {
+	for name, newLimiter := range limiterDrivers {
+		t.Run(name, func(t *testing.T) {
+			l := newLimiter(t)
+			limit := TaskLimit{Rate: 1, Burst: 2}
+
+			// 令牌桶初始为满
+			acquire(t, l, "s1", limit, time.Minute, true)
+			acquire(t, l, "s2", limit, time.Minute, true)
+			acquire(t, l, "s3", limit, time.Minute, false)
+
+			// 按流逝时长补充令牌
+			l.rewind("limited", time.Second)
+			acquire(t, l, "s3", limit, time.Minute, true)
+			acquire(t, l, "s4", limit, time.Minute, false)
+
+			// 补充的令牌数不超过令牌桶容量
+			l.rewind("limited", time.Hour)
+			acquire(t, l, "s4", limit, time.Minute, true)
+			acquire(t, l, "s5", limit, time.Minute, true)
+			acquire(t, l, "s6", limit, time.Minute, false)
+
+			// 退还令牌，同样不超过令牌桶容量
+			for _, slot := range []string{"s4", "s5", "s6"} {
+				if err := l.ReleaseSlot("limited", slot, limit, true); err != nil {
+					t.Fatal(err)
+				}
+			}
+			acquire(t, l, "s7", limit, time.Minute, true)
+			acquire(t, l, "s8", limit, time.Minute, true)
+			acquire(t, l, "s9", limit, time.Minute, false)
+
+			// 不退还时释放不影响令牌数
+			if err := l.ReleaseSlot("limited", "s9", limit, false); err != nil {
+				t.Fatal(err)
+			}
+			acquire(t, l, "s9", limit, time.Minute, false)
+		})
+	}
+}
+
+func TestReserveJobRefundOnEmptyPop(t *testing.T) {
+	drivers := map[string]func(t *testing.T) QueueIFace{
+		"memory": func(t *testing.T) QueueIFace { return &memoryQueue{} },
+		"redis": func(t *testing.T) QueueIFace {
+			r, _ := newTestRedisQueue(t)
+			return r
+		},
+	}
+
+	for name, newDriver := range drivers {
+		t.Run(name, func(t *testing.T) {
+			q := New(Memory, nil, nopLogger{}, 1)
+			driver := newDriver(t)
+			q.queue, q.manager.queue = driver, driver
+			task := &limitedTask{limit: TaskLimit{MaxConcurrency: 1, Rate: 1, Burst: 1}}
+
+			// 未pop到任务时释放名额并退还令牌：多次空轮询不耗尽限额
+			for i := 0; i < 3; i++ {
+				if job, throttled := q.manager.reserveJob(task.Name(), task); job != nil || throttled {
+					t.Fatalf("empty pop %d job %v throttled %v, want neither", i, job, throttled)
+				}
+			}
+
+			pushTestJob(t, driver, task.Name(), "1")
+			pushTestJob(t, driver, task.Name(), "2")
+			job, throttled := q.manager.reserveJob(task.Name(), task)
+			if job == nil || throttled {
+				t.Fatalf("job %v throttled %v, want job reserved", job, throttled)
+			}
+
+			// 名额被占用、令牌耗尽时跳过pop
+			if again, throttled := q.manager.reserveJob(task.Name(), task); again != nil || !throttled {
+				t.Fatalf("job %v throttled %v, want throttled", again, throttled)
+			}
+			if size := driver.Size(task.Name()); size != 2 {
+				t.Fatalf("size = %d, want throttled job left in queue", size)
+			}
+
+			// 执行结束释放名额，不退还令牌
+			q.manager.releaseJobSlot(job)
+			if again, throttled := q.manager.reserveJob(task.Name(), task); again != nil || !throttled {
+				t.Fatalf("job %v throttled %v, want throttled without token", again, throttled)
+			}
+		})
+	}
+}
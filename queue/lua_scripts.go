@@ -42,13 +42,19 @@ end
 return {job, reserved}
 `)
 	release = redis.NewScript(`
--- Remove the job from the current queue...
-redis.call('zrem', KEYS[2], ARGV[1])
+-- Only release the job still reserved by this worker, it may have been deleted or requeued meanwhile...
+if redis.call('zrem', KEYS[2], ARGV[1]) == 0 then
+	return 0
+end
 
--- Add the job onto the "delayed" queue...
-redis.call('zadd', KEYS[1], ARGV[2], ARGV[1])
+-- The job become available after the retry delay recorded in its latest payload...
+local released = cjson.decode(ARGV[3])
+local availableAt = tonumber(ARGV[2]) + tonumber(released['RetryDelay'] or 0)
 
-return true
+-- Add the job with its latest payload onto the "delayed" queue...
+redis.call('zadd', KEYS[1], availableAt, ARGV[3])
+
+return 1
 `)
 	migrate = redis.NewScript(`
 -- Get all of the jobs with an expired "score"...
@@ -66,6 +72,233 @@ if(next(val) ~= nil) then
 end
 
 return val
+`)
+	pushUnique = redis.NewScript(`
+-- Only push the job when the unique lock is acquired...
+if redis.call('set', KEYS[2], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	redis.call('rpush', KEYS[1], ARGV[3])
+	return 1
+end
+
+return 0
+`)
+	deleteUnique = redis.NewScript(`
+-- Only delete the job still reserved by this worker, the lock belongs to the requeued job otherwise...
+if redis.call('zrem', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+
+-- Release the unique lock only if it is still held by this job...
+if redis.call('get', KEYS[2]) == ARGV[2] then
+	redis.call('del', KEYS[2])
+end
+
+return 1
+`)
+	finishBatch = redis.NewScript(`
+-- The batch do not exist or expired...
+if redis.call('exists', KEYS[1]) == 0 then
+	return false
+end
+
+-- All jobs of the batch already finished, ignore the duplicate finish...
+if tonumber(redis.call('hget', KEYS[1], 'Pending')) <= 0 then
+	return {}
+end
+
+local fired = {}
+local pending = redis.call('hincrby', KEYS[1], 'Pending', -1)
+local failed = 0
+
+if ARGV[1] == '1' then
+	redis.call('hincrby', KEYS[1], 'Processed', 1)
+	failed = tonumber(redis.call('hget', KEYS[1], 'Failed'))
+else
+	failed = redis.call('hincrby', KEYS[1], 'Failed', 1)
+	-- The first failed job fire the "catch" callback...
+	if failed == 1 then
+		local catch = redis.call('hget', KEYS[1], 'catch')
+		if catch then
+			table.insert(fired, catch)
+		end
+	end
+end
+
+if pending <= 0 then
+	redis.call('hset', KEYS[1], 'FinishedAt', ARGV[2])
+	-- All jobs succeeded fire the "then" callback...
+	if failed == 0 then
+		local success = redis.call('hget', KEYS[1], 'then')
+		if success then
+			table.insert(fired, success)
+		end
+	end
+	-- All jobs finished fire the "finally" callback...
+	local finally = redis.call('hget', KEYS[1], 'finally')
+	if finally then
+		table.insert(fired, finally)
+	end
+end
+
+return fired
+`)
+	acquireSlot = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local maxConcurrency = tonumber(ARGV[4])
+local rate = tonumber(ARGV[5])
+local burst = tonumber(ARGV[6])
+
+-- Remove expired slots of crashed workers, then check the concurrency...
+if maxConcurrency > 0 then
+	redis.call('zremrangebyscore', KEYS[1], '-inf', now)
+	if redis.call('zcard', KEYS[1]) >= maxConcurrency then
+		return 0
+	end
+end
+
+-- Refill the token bucket by elapsed time, then take one token...
+if rate > 0 then
+	local bucket = redis.call('hmget', KEYS[2], 'tokens', 'ts')
+	local tokens = tonumber(bucket[1])
+	local ts = tonumber(bucket[2])
+	if tokens == nil or ts == nil then
+		tokens = burst
+		ts = now
+	end
+	tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+	if tokens < 1 then
+		return 0
+	end
+	redis.call('hset', KEYS[2], 'tokens', tostring(tokens - 1), 'ts', now)
+	redis.call('pexpire', KEYS[2], math.ceil(burst / rate * 1000) + 1000)
+end
+
+-- Occupy the slot until it expired...
+if maxConcurrency > 0 then
+	redis.call('zadd', KEYS[1], ARGV[3], ARGV[2])
+end
+
+return 1
+`)
+	releaseSlot = redis.NewScript(`
+redis.call('zrem', KEYS[1], ARGV[1])
+
+-- Refund the token if the slot was not used...
+if ARGV[2] == '1' then
+	local tokens = tonumber(redis.call('hget', KEYS[2], 'tokens'))
+	if tokens ~= nil then
+		redis.call('hset', KEYS[2], 'tokens', tostring(math.min(tonumber(ARGV[3]), tokens + 1)))
+	end
+end
+
+return true
+`)
+	takeJob = redis.NewScript(`
+-- Remove the exact job from the list or zSet, the job may have been popped or moved meanwhile...
+local removed
+if ARGV[1] == 'list' then
+	removed = redis.call('lrem', KEYS[1], 1, ARGV[2])
+else
+	removed = redis.call('zrem', KEYS[1], ARGV[2])
+end
+if removed == 0 then
+	return false
+end
+
+-- Push the job onto the queue to run it immediately...
+if ARGV[3] == 'requeue' then
+	redis.call('rpush', KEYS[2], ARGV[2])
+-- Release the unique lock of the deleted job only if it is still held by this job...
+elseif KEYS[3] ~= nil and redis.call('get', KEYS[3]) == ARGV[4] then
+	redis.call('del', KEYS[3])
+end
+
+return true
+`)
+	nextAvailable = redis.NewScript(`
+-- Find the earliest score of all the given delayed and reserved zSets...
+local earliest = -1
+for i = 1, #KEYS do
+	local item = redis.call('zrange', KEYS[i], 0, 0, 'WITHSCORES')
+	if item[2] ~= nil then
+		local score = tonumber(item[2])
+		if earliest < 0 or score < earliest then
+			earliest = score
+		end
+	end
+end
+
+return earliest
+`)
+	claimTick = redis.NewScript(`
+-- Only the first claim of a tick later than the last claimed one wins...
+local last = tonumber(redis.call('hget', KEYS[1], ARGV[1]) or '0')
+if tonumber(ARGV[2]) <= last then
+	return 0
+end
+
+redis.call('hset', KEYS[1], ARGV[1], ARGV[2])
+
+return 1
+`)
+	extendScore = redis.NewScript(`
+-- Only extend the score of an existing member, never shorten it...
+local score = redis.call('zscore', KEYS[1], ARGV[1])
+if not score then
+	return 0
+end
+
+if tonumber(score) < tonumber(ARGV[2]) then
+	redis.call('zadd', KEYS[1], ARGV[2], ARGV[1])
+end
+
+return 1
+`)
+	handoff = redis.NewScript(`
+-- Only hand off the job still reserved by this worker...
+if redis.call('zrem', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+
+-- Push the job with its original payload onto the head of the queue...
+redis.call('lpush', KEYS[2], ARGV[2])
+
+return 1
+`)
+	bury = redis.NewScript(`
+-- Index the dead job by its failed time...
+redis.call('zadd', KEYS[1], ARGV[1], ARGV[2])
+
+-- Store the dead job body...
+redis.call('hset', KEYS[2], ARGV[2], ARGV[3])
+
+return true
+`)
+	requeueDead = redis.NewScript(`
+-- Only requeue the dead job still exists, avoid requeue twice...
+if redis.call('zrem', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('hdel', KEYS[2], ARGV[1])
+
+-- Push the reset payload onto the queue...
+redis.call('rpush', KEYS[3], ARGV[2])
+
+return 1
+`)
+	purgeDead = redis.NewScript(`
+-- Get all of the dead jobs failed before the given time...
+local val = redis.call('zrangebyscore', KEYS[1], '-inf', ARGV[1])
+
+if(next(val) ~= nil) then
+    redis.call('zremrangebyscore', KEYS[1], '-inf', ARGV[1])
+
+    for i = 1, #val, 100 do
+        redis.call('hdel', KEYS[2], unpack(val, i, math.min(i+99, #val)))
+    end
+end
+
+return #val
 `)
 )
 
@@ -103,10 +336,11 @@ func (lua *luaScripts) Pop() *redis.Script {
  *
  * KEYS[1] - The "delayed" queue we release jobs onto, for example: queues:foo:delayed
  * KEYS[2] - The queue the jobs are currently on, for example: queues:foo:reserved
- * ARGV[1] - The raw payload of the job to add to the "delayed" queue
- * ARGV[2] - The UNIX timestamp at which the job should become available
+ * ARGV[1] - The raw reserved payload of the job to remove from the "reserved" queue
+ * ARGV[2] - The current UNIX timestamp
+ * ARGV[3] - The latest payload of the job to add to the "delayed" queue, job become available after its "RetryDelay" seconds
  *
- * @return string
+ * @return int 1 when the job is released, 0 when the job is no longer reserved
  */
 func (lua *luaScripts) Release() *redis.Script {
 	return release
@@ -125,3 +359,202 @@ func (lua *luaScripts) Release() *redis.Script {
 func (lua *luaScripts) MigrateExpiredJobs() *redis.Script {
 	return migrate
 }
+
+// Bury
+/**
+ * Get the Lua script for storing a dead job.
+ *
+ * KEYS[1] - The dead job index zSet, for example: queues:foo:dead
+ * KEYS[2] - The dead job body hash, for example: queues:foo:dead:jobs
+ * ARGV[1] - The UNIX timestamp at which the job failed
+ * ARGV[2] - The ID of the job
+ * ARGV[3] - The raw dead job
+ *
+ * @return string
+ */
+func (lua *luaScripts) Bury() *redis.Script {
+	return bury
+}
+
+// RequeueDead
+/**
+ * Get the Lua script for moving a dead job back onto the queue.
+ *
+ * KEYS[1] - The dead job index zSet, for example: queues:foo:dead
+ * KEYS[2] - The dead job body hash, for example: queues:foo:dead:jobs
+ * KEYS[3] - The queue to push the job onto, for example: queues:foo
+ * ARGV[1] - The ID of the job
+ * ARGV[2] - The reset raw payload of the job
+ *
+ * @return int 1 requeued, 0 the dead job do not exist
+ */
+func (lua *luaScripts) RequeueDead() *redis.Script {
+	return requeueDead
+}
+
+// PurgeDead
+/**
+ * Get the Lua script for purging dead jobs failed before the given time.
+ *
+ * KEYS[1] - The dead job index zSet, for example: queues:foo:dead
+ * KEYS[2] - The dead job body hash, for example: queues:foo:dead:jobs
+ * ARGV[1] - The UNIX timestamp, dead jobs failed before it will be purged
+ *
+ * @return int the count of purged dead jobs
+ */
+func (lua *luaScripts) PurgeDead() *redis.Script {
+	return purgeDead
+}
+
+// PushUnique
+/**
+ * Get the Lua script for pushing a job onto the queue only if its unique lock is free.
+ *
+ * KEYS[1] - The queue to push the job onto, for example: queues:foo
+ * KEYS[2] - The unique lock of the job, for example: queues:foo:unique:bar
+ * ARGV[1] - The ID of the job, as the owner of the unique lock
+ * ARGV[2] - The TTL of the unique lock in milliseconds
+ * ARGV[3] - The raw payload of the job
+ *
+ * @return int 1 pushed, 0 a job with the same unique key already exists
+ */
+func (lua *luaScripts) PushUnique() *redis.Script {
+	return pushUnique
+}
+
+// DeleteUnique
+/**
+ * Get the Lua script for deleting a unique job and releasing its unique lock.
+ *
+ * KEYS[1] - The queue the job is currently on, for example: queues:foo:reserved
+ * KEYS[2] - The unique lock of the job, for example: queues:foo:unique:bar
+ * ARGV[1] - The raw reserved payload of the job
+ * ARGV[2] - The ID of the job
+ *
+ * @return int 1 when the job is deleted, 0 when the job is no longer reserved
+ */
+func (lua *luaScripts) DeleteUnique() *redis.Script {
+	return deleteUnique
+}
+
+// FinishBatch
+/**
+ * Get the Lua script for finishing a job of the batch.
+ *
+ * KEYS[1] - The batch progress hash, for example: batch:foo
+ * ARGV[1] - The job succeeded or not, 1 succeeded, 0 failed
+ * ARGV[2] - The current UNIX timestamp
+ *
+ * @return array the raw payload of callbacks need to dispatch, nil if the batch do not exist
+ */
+func (lua *luaScripts) FinishBatch() *redis.Script {
+	return finishBatch
+}
+
+// AcquireSlot
+/**
+ * Get the Lua script for acquiring an execute slot of the rate limited task.
+ *
+ * KEYS[1] - The slots zSet of the queue, for example: queues:foo:limiter:slots
+ * KEYS[2] - The token bucket hash of the queue, for example: queues:foo:limiter:bucket
+ * ARGV[1] - The current UNIX timestamp in milliseconds
+ * ARGV[2] - The ID of the slot
+ * ARGV[3] - The UNIX timestamp in milliseconds at which the slot expired
+ * ARGV[4] - The max concurrency, 0 means unlimited
+ * ARGV[5] - The tokens generated per second, 0 means unlimited
+ * ARGV[6] - The capacity of the token bucket
+ *
+ * @return int 1 acquired, 0 the budget is exhausted
+ */
+func (lua *luaScripts) AcquireSlot() *redis.Script {
+	return acquireSlot
+}
+
+// ReleaseSlot
+/**
+ * Get the Lua script for releasing an execute slot of the rate limited task.
+ *
+ * KEYS[1] - The slots zSet of the queue, for example: queues:foo:limiter:slots
+ * KEYS[2] - The token bucket hash of the queue, for example: queues:foo:limiter:bucket
+ * ARGV[1] - The ID of the slot
+ * ARGV[2] - Refund the token or not, 1 refund, 0 not
+ * ARGV[3] - The capacity of the token bucket
+ *
+ * @return string
+ */
+func (lua *luaScripts) ReleaseSlot() *redis.Script {
+	return releaseSlot
+}
+
+// NextAvailable
+/**
+ * Get the Lua script for finding the time at which the earliest delayed or reserved job will be available.
+ *
+ * KEYS   - The "delayed" and "reserved" zSets of the queues, for example: queues:foo:delayed queues:foo:reserved
+ *
+ * @return int the UNIX timestamp of the earliest job, -1 when there is no delayed or reserved job
+ */
+func (lua *luaScripts) NextAvailable() *redis.Script {
+	return nextAvailable
+}
+
+// ClaimTick
+/**
+ * Get the Lua script for claiming a tick of the schedule.
+ *
+ * KEYS[1] - The hash of the last claimed ticks, for example: queue:schedules
+ * ARGV[1] - The name of the schedule
+ * ARGV[2] - The UNIX timestamp of the tick
+ *
+ * @return int 1 when claimed, 0 when the tick is not later than the last claimed one
+ */
+func (lua *luaScripts) ClaimTick() *redis.Script {
+	return claimTick
+}
+
+// ExtendScore
+/**
+ * Get the Lua script for extending the score of an existing member in the zSet.
+ *
+ * KEYS[1] - The zSet, for example: queues:foo:reserved, queues:foo:limiter:slots
+ * ARGV[1] - The member, the reserved job or the ID of the slot
+ * ARGV[2] - The new score, only takes effect when it is later than the current one
+ *
+ * @return int 1 when the member exists, 0 when the member is not found
+ */
+func (lua *luaScripts) ExtendScore() *redis.Script {
+	return extendScore
+}
+
+// Handoff
+/**
+ * Get the Lua script for handing off a reserved job back onto the head of the queue.
+ *
+ * KEYS[1] - The name of the reserved zSet, for example: queues:foo:reserved
+ * KEYS[2] - The name of the primary queue, for example: queues:foo
+ * ARGV[1] - The reserved job
+ * ARGV[2] - The original job before reserved, the attempt is not counted
+ *
+ * @return int 1 when the job is handed off, 0 when the job is no longer reserved
+ */
+func (lua *luaScripts) Handoff() *redis.Script {
+	return handoff
+}
+
+// TakeJob
+/**
+ * Get the Lua script for removing a found job from the queue list or the zSet, and requeue it optionally.
+ *
+ * KEYS[1] - The list or zSet the job in, for example: queues:foo, queues:foo:delayed
+ * KEYS[2] - The name of the primary queue, for example: queues:foo
+ * KEYS[3] - Optional, the unique lock of the deleted job, for example: queues:foo:unique:bar
+ * ARGV[1] - The type of KEYS[1], list or zset
+ * ARGV[2] - The job
+ * ARGV[3] - Requeue the job or not, requeue or delete
+ * ARGV[4] - Optional, the ID of the deleted job which holds the unique lock
+ *
+ * @return bool true when removed, nil when the job is no longer in KEYS[1]
+ */
+func (lua *luaScripts) TakeJob() *redis.Script {
+	return takeJob
+}
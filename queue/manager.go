@@ -2,10 +2,10 @@ package queue
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/go-stack/stack"
 	"math/rand"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -29,21 +29,43 @@ func (b *atomicBool) setFalse()   { atomic.StoreInt32((*int32)(b), 0) }
 
 // manager 队列管理者，队列的调度执行和管理
 type manager struct {
-	queue            QueueIFace            // 队列底层实现实例
-	channel          chan JobIFace         // 任务类执行job的通道chan
-	logger           Logger                // 实现 Logger 接口的结构体实例的指针对象
-	concurrent       int64                 // 单个队列最大并发worker数
-	tasks            map[string]TaskIFace  // 队列名与任务类实例映射map，interface无需显式指定执指针类型，但实际传参需指针类型
-	priorityTasks    map[string]TaskIFace  // 指定的高优先级job任务
-	failedJobHandler FailedJobHandler      // 失败任务[最大尝试次数后仍然尝试失败（Execute返回了Error 或 执行导致panic）的任务]处理器
-	lock             sync.Mutex            // 并发锁
-	doneChan         chan struct{}         // 关闭队列的信号控制chan
-	inShutdown       atomicBool            // 原子态标记：是否处于优雅关闭状态中
-	inWorkingMap     sync.Map              // map[string]int64  当前正work中的jobID与workerID映射map
-	workerStatus     map[int64]*atomicBool // worker工作进程状态标记map
-	jitter           time.Duration         // 循环器抖动间隔
-	allowTasks       map[string]struct{}   // 指定可以运行的队列
-	excludeTasks     map[string]struct{}   // 指定不可运行的队列
+	queue            QueueIFace              // 队列底层实现实例
+	channel          chan JobIFace           // 任务类执行job的通道chan
+	logger           Logger                  // 实现 Logger 接口的结构体实例的指针对象
+	concurrent       int64                   // 单个队列最大并发worker数
+	tasks            map[string]TaskIFace    // 队列名与任务类实例映射map，interface无需显式指定执指针类型，但实际传参需指针类型
+	priorityTasks    map[string]TaskIFace    // 指定的高优先级job任务
+	failedJobHandler FailedJobHandler        // 失败任务[最大尝试次数后仍然尝试失败（Execute返回了Error 或 执行导致panic）的任务]处理器
+	lock             sync.Mutex              // 并发锁
+	doneChan         chan struct{}           // 关闭队列的信号控制chan
+	inShutdown       atomicBool              // 原子态标记：是否处于优雅关闭状态中
+	inWorkingMap     sync.Map                // map[string]int64  当前正work中的jobID与workerID映射map
+	workerStatus     map[int64]*atomicBool   // worker工作进程状态标记map
+	jitter           time.Duration           // 循环器抖动间隔
+	grace            time.Duration           // 任务执行超时后等待任务类主动退出的宽限时长
+	allowTasks       map[string]struct{}     // 指定可以运行的队列
+	excludeTasks     map[string]struct{}     // 指定不可运行的队列
+	pausedTasks      map[string]struct{}     // 运行时暂停的队列
+	filterLock       sync.RWMutex            // 可运行、不可运行、暂停队列的读写锁
+	deadLetter       bool                    // 最终失败的任务是否写入死信存储<底层驱动实现了 DeadLetterIFace 时生效>
+	localLimiter     localLimiter            // 进程内限流实现<底层驱动未实现 LimiterQueueIFace 时使用>
+	jobSlots         sync.Map                // map[JobIFace]*jobSlot 执行中job持有的限流名额
+	runningJobs      sync.Map                // map[JobIFace]struct{} 执行中尚未收尾的job，排空停机时交还
+	handoffs         []HandoffJob            // 停机时已pop尚未交给worker而交还的job
+	scheduler        SchedulerIFace          // looper调度器
+	notifyMode       bool                    // 是否开启通知模式<底层驱动实现了 NotifiableQueueIFace 时生效>
+	notifyMaxIdle    time.Duration           // 通知模式下looper空闲时的最长等待时长
+	metrics          MetricsCollector        // 指标收集器
+	metricsInterval  time.Duration           // 队列深度采样间隔
+	busyWorkers      int64                   // 执行任务中的worker数
+	hooks            jobHooks                // 任务生命周期事件钩子
+	keyring          *Keyring                // 任务参数加密密钥环，nil不加密
+	schedules        []*scheduleEntry        // 已注册的定时投递
+	statusTTL        time.Duration           // 可追踪任务状态记录有效期，小于等于0时为 DefaultStatusTTL
+	heartbeat        time.Duration           // 执行中任务心跳间隔，小于等于0不心跳
+	clock            Clock                   // 时钟，nil时为系统时钟
+	middlewares      []Middleware            // 全局任务执行中间件
+	taskMiddlewares  map[string][]Middleware // 任务类的任务执行中间件
 }
 
 // newManager 实例化一个manager
@@ -62,8 +84,13 @@ func newManager(queue QueueIFace, logger Logger, concurrent int64) *manager {
 		inWorkingMap:  sync.Map{},
 		lock:          sync.Mutex{},
 		jitter:        450 * time.Millisecond,
+		grace:         DefaultExecuteGrace,
+		heartbeat:     DefaultHeartbeatInterval,
 		allowTasks:    make(map[string]struct{}),
 		excludeTasks:  make(map[string]struct{}),
+		pausedTasks:   make(map[string]struct{}),
+		deadLetter:    true,
+		scheduler:     NewDefaultScheduler(),
 	}
 }
 
@@ -83,8 +110,15 @@ func (m *manager) bootstrapOne(task TaskIFace) error {
 	)
 
 	m.tasks[task.Name()] = task
+	_, high := m.priorityTasks[task.Name()]
+	m.scheduler.Register(schedulerTask(task, high))
 	m.lock.Unlock()
 
+	// 任务类指定的编解码器注册后消费端才能解码
+	if taskCodec, ok := task.(TaskCodecIFace); ok && taskCodec.Codec() != nil {
+		RegisterCodec(taskCodec.Codec())
+	}
+
 	return nil
 }
 
@@ -114,11 +148,30 @@ func (m *manager) setPriorityTask(task TaskIFace) error {
 	)
 
 	m.priorityTasks[task.Name()] = task
+	m.scheduler.Register(schedulerTask(task, true))
 	m.lock.Unlock()
 
 	return nil
 }
 
+// setScheduler 设置looper调度器，已注册的任务类同步注册至新调度器
+func (m *manager) setScheduler(scheduler SchedulerIFace) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for name, task := range m.tasks {
+		_, high := m.priorityTasks[name]
+		scheduler.Register(schedulerTask(task, high))
+	}
+	for name, task := range m.priorityTasks {
+		if _, exist := m.tasks[name]; !exist {
+			scheduler.Register(schedulerTask(task, true))
+		}
+	}
+
+	m.scheduler = scheduler
+}
+
 // start 启动队列进程工作者
 func (m *manager) start() (err error) {
 	// 队列处于关闭中状态时启动直接返回Err
@@ -129,6 +182,12 @@ func (m *manager) start() (err error) {
 	// 启动loop执行者循环调度
 	go m.startLooper()
 
+	// 启动指标定时采样
+	go m.startMetrics()
+
+	// 启动定时投递
+	m.startSchedules()
+
 	// 并发启动多个消费worker进程
 	var i int64
 	for i = 0; i < m.concurrent; i++ {
@@ -140,59 +199,186 @@ func (m *manager) start() (err error) {
 
 // startLooper 启动队列进程looper，循环触发job消费
 func (m *manager) startLooper() {
+	// 通知模式下的等待随队列关闭取消
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-m.getDoneChan():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
 	for {
 		select {
 		case <-m.getDoneChan():
 			m.logger.Info("shutdown, queue looper exited")
 			close(m.channel) // close job chan
+			if notifiable, ok := m.queue.(NotifiableQueueIFace); ok {
+				notifiable.StopWait() // 释放通知订阅：looper退出后不再等待
+			}
 			return
 		default:
-			m.looper() // continue loop all queue jobs
+			m.looper(ctx) // continue loop all queue jobs
 		}
 	}
 }
 
 // looper 轮询 && 速率控制所有队列的looper
-func (m *manager) looper() {
-	// map的range是无序的，无需再随机pop队列
-	// range本身就是随机的
+func (m *manager) looper(ctx context.Context) {
 	needSleep := true
+	throttled := false                   // 本轮是否有任务因限流被跳过
+	waiting := make(map[string]struct{}) // 本轮未pop到job的队列
+
+	// 由调度器决定本轮各任务的pop顺序与次数
+	m.scheduler.NewRound()
+	for {
+		name, ok := m.scheduler.Next()
+		if !ok {
+			break
+		}
 
-	// 高优先级任务先被轮询
-	for pName := range m.priorityTasks {
-		//检查任务是否可以运行
-		if !m.allowRun(pName) {
-			continue
+		popped := false
+		if task, exist := m.scheduledTask(name); exist && m.allowRun(name) {
+			var limited bool
+			if popped, limited = m.popJob(name, task); limited {
+				throttled = true
+			} else if !popped {
+				waiting[name] = struct{}{}
+			}
 		}
+		m.scheduler.Done(name, popped)
 
-		if job, exist := m.queue.Pop(pName); exist {
-			m.channel <- job // push job to worker for control process
+		if popped {
 			needSleep = false
 		}
 	}
 
-	for name := range m.tasks {
-		//检查任务是否可以运行
-		if !m.allowRun(name) {
-			continue
-		}
+	if !needSleep {
+		return
+	}
 
-		if job, exist := m.queue.Pop(name); exist {
-			m.channel <- job // push job to worker for control process
-			needSleep = false
+	// 通知模式：等待任务投递通知或延迟任务到期，限流跳过的任务无通知仍需轮询
+	if notifiable, ok := m.queue.(NotifiableQueueIFace); ok && m.notifyMode && !throttled {
+		m.logger.Debug("no job pop, wait for notify")
+
+		queues := make([]string, 0, len(waiting))
+		for name := range waiting {
+			queues = append(queues, name)
 		}
+		notifiable.Wait(ctx, queues, m.notifyMaxIdle)
+		return
 	}
 
 	// 所有队列都没job任务 looper随机休眠
-	if needSleep {
-		m.logger.Debug("no job pop, sleep for a while")
+	m.logger.Debug("no job pop, sleep for a while")
+
+	time.Sleep(m.looperJitter())
+}
+
+// scheduledTask 按名称获取调度器调度的任务类
+func (m *manager) scheduledTask(name string) (TaskIFace, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
 
-		time.Sleep(m.looperJitter())
+	if task, exist := m.tasks[name]; exist {
+		return task, true
+	}
+	task, exist := m.priorityTasks[name]
+	return task, exist
+}
+
+// popJob 尝试pop一个任务job投递给worker
+//   - popped    是否pop到了任务
+//   - throttled 是否因限流名额耗尽跳过了pop
+func (m *manager) popJob(name string, task TaskIFace) (popped, throttled bool) {
+	job, throttled := m.reserveJob(name, task)
+	if job == nil {
+		return false, throttled
+	}
+
+	select {
+	case m.channel <- job: // push job to worker for control process
+		return true, false
+	case <-m.getDoneChan():
+	}
+
+	// 队列关闭中：已pop尚未交给worker的job直接交还，不支持交还时仍等待worker执行
+	if m.returnJob(job) {
+		return false, false
+	}
+	m.channel <- job
+	return true, false
+}
+
+// reserveJob 获取执行名额后pop一个任务job
+//   - job       pop到的任务，未pop到时为nil
+//   - throttled 是否因限流名额耗尽跳过了pop
+func (m *manager) reserveJob(name string, task TaskIFace) (job JobIFace, throttled bool) {
+	// 任务限流名额耗尽时跳过
+	slot, ok := m.acquireSlot(task)
+	if !ok {
+		return nil, true
+	}
+
+	job, exist := m.queue.Pop(name)
+	if !exist {
+		m.releaseSlot(name, slot, true)
+		return nil, false
+	}
+
+	if slot != nil {
+		m.jobSlots.Store(job, slot)
+	}
+	return job, false
+}
+
+// runReady 在当前协程中依次pop并执行所有已就绪的任务，直至一轮中所有任务均未pop到job
+//   - 高优先级任务在前，同级任务按名称排序，执行顺序确定
+func (m *manager) runReady() (ran int) {
+	m.lock.Lock()
+	var priority, normal []string
+	for name := range m.priorityTasks {
+		priority = append(priority, name)
+	}
+	for name := range m.tasks {
+		if _, exist := m.priorityTasks[name]; !exist {
+			normal = append(normal, name)
+		}
+	}
+	m.lock.Unlock()
+	sort.Strings(priority)
+	sort.Strings(normal)
+	names := append(priority, normal...)
+
+	for {
+		popped := false
+		for _, name := range names {
+			task, exist := m.scheduledTask(name)
+			if !exist || !m.allowRun(name) {
+				continue
+			}
+			if job, _ := m.reserveJob(name, task); job != nil {
+				m.runJob(job, 0)
+				popped = true
+				ran++
+			}
+		}
+		if !popped {
+			return ran
+		}
 	}
 }
 
 // 检查任务是否可以运行
 func (m *manager) allowRun(jobName string) bool {
+	m.filterLock.RLock()
+	defer m.filterLock.RUnlock()
+
+	if _, ok := m.pausedTasks[jobName]; ok {
+		return false
+	}
 	if _, ok := m.allowTasks[jobName]; len(m.allowTasks) > 0 && !ok {
 		return false
 	}
@@ -202,6 +388,29 @@ func (m *manager) allowRun(jobName string) bool {
 	return true
 }
 
+// setPaused 运行时暂停、恢复任务
+func (m *manager) setPaused(paused bool, taskNames ...string) {
+	m.filterLock.Lock()
+	defer m.filterLock.Unlock()
+
+	for _, name := range taskNames {
+		if paused {
+			m.pausedTasks[name] = struct{}{}
+		} else {
+			delete(m.pausedTasks, name)
+		}
+	}
+}
+
+// isPaused 检查任务是否已暂停
+func (m *manager) isPaused(name string) bool {
+	m.filterLock.RLock()
+	defer m.filterLock.RUnlock()
+
+	_, ok := m.pausedTasks[name]
+	return ok
+}
+
 // startWorker 启动队列进程工作者
 func (m *manager) startWorker(workerID int64) {
 	defer func() {
@@ -217,23 +426,67 @@ func (m *manager) startWorker(workerID int64) {
 	}
 }
 
+// execution 单次job执行的状态同步器
+// 执行task的协程与worker之间通过该结构约定：worker放弃等待后，迟到返回的执行结果不得再操作job
+type execution struct {
+	lock      sync.Mutex
+	result    chan error // 执行结果通道，带1个缓冲：worker放弃等待后执行协程也不会阻塞
+	finished  bool       // 执行协程是否已返回
+	abandoned bool       // worker是否已放弃等待
+}
+
+// finish 执行协程返回时标记已完成，返回worker此前是否已放弃等待
+func (e *execution) finish(err error) (abandoned bool) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.finished = true
+	e.result <- err
+	return e.abandoned
+}
+
+// abandon worker尝试放弃等待：执行协程已返回时放弃失败返回false
+func (e *execution) abandon() bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.finished {
+		return false
+	}
+	e.abandoned = true
+	return true
+}
+
 // runJob 执行队列job，超时控制 && 尝试次数控制，执行结果控制
 func (m *manager) runJob(job JobIFace, workerID int64) {
 	// set worker is true
 	m.setWorkerStatus(workerID, true)
+	m.workerBusy(1)
+
+	// 是否由执行协程负责清理运行中标记：放弃等待的job由迟到返回的执行协程自行清理
+	var detached bool
+	// 是否已放弃等待：放弃等待的job仍在执行，由迟到返回的执行协程释放限流名额
+	var abandoned bool
 
 	// step1、任务类执行捕获可能的panic
 	defer func() {
 		// set worker execute is false
 		m.setWorkerStatus(workerID, false)
+		m.workerBusy(-1)
 
 		// delete in running map need to use lock
-		m.inWorkingMap.Delete(job.Payload().ID)
+		if !detached {
+			m.inWorkingMap.Delete(job.Payload().ID)
+		}
+		m.runningJobs.Delete(job)
+
+		// 执行已结束或未执行：释放限流名额
+		if !abandoned {
+			m.releaseJobSlot(job)
+		}
 
 		// recovery if panic
 		if err := recover(); err != nil {
 			m.logger.Error(
-				"queue.execute.panic",
+				textJobPanic,
 				"stack", stack.Trace().TrimRuntime().String(),
 				"queue", job.GetName(),
 				"worker_id", IFaceToString(workerID),
@@ -241,16 +494,8 @@ func (m *manager) runJob(job JobIFace, workerID int64) {
 				"error", IFaceToString(err),
 			)
 
-			var eErr error
-			switch t := err.(type) {
-			case error:
-				eErr = t
-			default:
-				eErr = fmt.Errorf("%s", t)
-			}
-
 			// panic: 检查任务尝试执行次数 & 标记失败状态
-			m.markJobAsFailedIfWillExceedMaxAttempts(job, eErr)
+			m.markJobAsFailedIfWillExceedMaxAttempts(job, nil, panicToError(err))
 		}
 	}()
 
@@ -259,7 +504,7 @@ func (m *manager) runJob(job JobIFace, workerID int64) {
 		return
 	}
 
-	// step2、因为没有超时主动退出机制当任务执行超时仍在执行时标记再次延迟
+	// step2、放弃等待的任务仍在执行时标记再次延迟
 	if _, exist := m.inWorkingMap.Load(job.Payload().ID); exist {
 		m.logger.Warn(
 			ErrAbortForWaitingPrevJobFinish.Error(),
@@ -268,15 +513,15 @@ func (m *manager) runJob(job JobIFace, workerID int64) {
 			"pop_time", job.PopTime().String(),
 		)
 
-		// 当前任务作为延迟任务再次投递
+		// 释放保留中的当前任务，间隔重试时长后再次执行：不能另行投递，否则保留的任务超时后再次取出会产生重复的任务
 		// warning 当前正在执行的可能执行成功这样会导致一条任务多次被成功执行，需要任务类自主实现业务逻辑幂等
-		if payload, err := json.Marshal(job.Payload()); err == nil {
-			_ = job.Queue().Later(job.GetName(), time.Duration(job.Payload().RetryInterval)*time.Second, payload)
-		}
+		_ = job.Release(job.Payload().RetryInterval)
 
 		// 触发记录可能失败日志的记录，便于回溯
 		m.recordFailedJob(job, ErrAbortForWaitingPrevJobFinish)
 
+		// 不能清理运行中标记：标记属于仍在执行的上一个job
+		detached = true
 		return
 	}
 
@@ -288,6 +533,10 @@ func (m *manager) runJob(job JobIFace, workerID int64) {
 		return
 	}
 
+	// 执行期间心跳延长任务保留时刻，避免执行中的任务被视为超时重新取出
+	stopHeartbeat := m.startHeartbeat(job)
+	defer stopHeartbeat()
+
 	// step4、execute job task with timeout control
 	m.logger.Info(
 		textJobProcessing,
@@ -300,39 +549,163 @@ func (m *manager) runJob(job JobIFace, workerID int64) {
 	ctx, cancelFunc := context.WithTimeout(context.Background(), job.Timeout())
 	defer cancelFunc()
 
-	// goroutine execute task job
-	go func() {
-		err := task.Execute(ctx, job.Payload().RawBody())
-		if err == nil {
-			// step5、任务类执行成功：删除任务即可
-			m.logger.Info(
-				textJobProcessed,
-				"queue", job.GetName(),
-				"worker_id", IFaceToString(workerID),
-				"payload", IFaceToString(job.Payload()),
-				"duration", IFaceToString(int64(time.Now().Sub(job.PopTime()))),
-			)
-			_ = job.Delete()
-		} else {
-			// step6、任务类执行失败：依赖重试设置执行重试or最终执行失败处理
-			m.logger.Error(
-				textJobFailed,
-				"queue", job.GetName(),
-				"worker_id", IFaceToString(workerID),
-				"payload", IFaceToString(job.Payload()),
-				"duration", IFaceToString(int64(time.Now().Sub(job.PopTime()))),
-			)
-			m.markJobAsFailedIfWillExceedMaxAttempts(job, err)
+	// 执行前事件钩子可派生context
+	startAt := time.Now()
+	ctx = m.jobStarted(ctx, job, workerID, startAt)
+
+	// 任务参数解密、解压失败视为本次执行失败
+	handler := m.handler(task)
+	body, decodeErr := job.Payload().decodeBody(m.keyring)
+	if decodeErr != nil {
+		handler = func(ctx context.Context, job *RawBody) error {
+			return decodeErr
 		}
-		cancelFunc()
-	}()
+	}
+	body.status = m.statusReporter(job)
+	m.trackJob(job, body.status, JobStatusRunning, nil)
+	m.runningJobs.Store(job, struct{}{})
+	exec := m.executeTask(ctx, handler, job, body, workerID)
+	settle := func(err error) {
+		m.jobDone(ctx, job, workerID, startAt, err)
+		if m.claimJob(job, workerID) {
+			m.settleJob(job, workerID, body, err)
+		}
+	}
 
 	select {
+	case err := <-exec.result:
+		// step5、超时之前任务类已返回
+		settle(err)
+		return
 	case <-ctx.Done():
-		// timeout to exit worker goroutine, but job may continue executed
-		m.markJobAsFailedIfWillExceedMaxAttempts(job, ctx.Err())
+	}
+
+	if m.metrics != nil {
+		m.metrics.IncTimeout(job.GetName())
+	}
+
+	// step6、执行超时：宽限期内等待任务类响应context主动退出
+	m.logger.Warn(
+		textJobTimeout,
+		"queue", job.GetName(),
+		"worker_id", IFaceToString(workerID),
+		"payload", IFaceToString(job.Payload()),
+		"grace", m.grace.String(),
+	)
+	grace := time.NewTimer(m.grace)
+	defer grace.Stop()
+
+	select {
+	case err := <-exec.result:
+		if err != nil {
+			err = fmt.Errorf("%w: %w", ErrJobExecuteTimeout, err)
+		}
+		settle(err)
+		return
+	case <-grace.C:
+	}
+
+	// step7、宽限期内仍未退出：放弃等待，迟到的执行结果由执行协程丢弃且不再操作job
+	if !exec.abandon() {
+		// 放弃前的瞬间执行协程恰好返回
+		err := <-exec.result
+		if err != nil {
+			err = fmt.Errorf("%w: %w", ErrJobExecuteTimeout, err)
+		}
+		settle(err)
 		return
 	}
+	detached = true
+	abandoned = true
+
+	m.logger.Error(
+		textJobAbandoned,
+		"queue", job.GetName(),
+		"worker_id", IFaceToString(workerID),
+		"payload", IFaceToString(job.Payload()),
+		"duration", IFaceToString(int64(m.now().Sub(job.PopTime()))),
+	)
+	err := fmt.Errorf("%w: %w", ErrJobExecuteTimeout, ctx.Err())
+	m.jobDone(ctx, job, workerID, startAt, err)
+	if m.claimJob(job, workerID) {
+		m.markJobAsFailedIfWillExceedMaxAttempts(job, body.status, err)
+	}
+}
+
+// executeTask 协程中执行任务类中间件执行链，执行结果通过 execution 回传
+//   - 任务类panic在执行协程内被捕获并转换为 ErrJobExecutePanic 错误
+//   - worker已放弃等待时，迟到的结果仅记录日志，并由执行协程清理运行中标记
+func (m *manager) executeTask(ctx context.Context, handler HandlerFunc, job JobIFace, body *RawBody, workerID int64) *execution {
+	exec := &execution{result: make(chan error, 1)}
+
+	go func() {
+		var err error
+		defer func() {
+			if rec := recover(); rec != nil {
+				m.logger.Error(
+					textJobPanic,
+					"stack", stack.Trace().TrimRuntime().String(),
+					"queue", job.GetName(),
+					"worker_id", IFaceToString(workerID),
+					"payload", IFaceToString(job.Payload()),
+					"error", IFaceToString(rec),
+				)
+				err = panicToError(rec)
+			}
+
+			if exec.finish(err) {
+				m.inWorkingMap.Delete(job.Payload().ID)
+				m.releaseJobSlot(job)
+				m.logger.Warn(
+					textJobLate,
+					"queue", job.GetName(),
+					"worker_id", IFaceToString(workerID),
+					"payload", IFaceToString(job.Payload()),
+					"duration", IFaceToString(int64(m.now().Sub(job.PopTime()))),
+				)
+			}
+		}()
+
+		err = handler(ctx, body)
+	}()
+
+	return exec
+}
+
+// settleJob 依据执行结果收尾job：成功删除任务，失败依赖重试设置执行重试or最终执行失败处理
+func (m *manager) settleJob(job JobIFace, workerID int64, body *RawBody, err error) {
+	if err == nil {
+		// 任务类执行成功：删除任务即可
+		m.logger.Info(
+			textJobProcessed,
+			"queue", job.GetName(),
+			"worker_id", IFaceToString(workerID),
+			"payload", IFaceToString(job.Payload()),
+			"duration", IFaceToString(int64(m.now().Sub(job.PopTime()))),
+		)
+		_ = job.Delete()
+		if m.metrics != nil {
+			m.metrics.IncProcessed(job.GetName())
+		}
+
+		// 任务链、批次：投递后续任务、更新批次进度
+		m.onJobSucceeded(job, body)
+
+		// 可追踪任务：记录执行成功，沿用 SetResult 指定的有效期
+		m.trackJob(job, body.status, JobStatusSucceeded, nil)
+		return
+	}
+
+	// 任务类执行失败：依赖重试设置执行重试or最终执行失败处理
+	m.logger.Error(
+		textJobFailed,
+		"queue", job.GetName(),
+		"worker_id", IFaceToString(workerID),
+		"payload", IFaceToString(job.Payload()),
+		"duration", IFaceToString(int64(m.now().Sub(job.PopTime()))),
+		"error", err.Error(),
+	)
+	m.markJobAsFailedIfWillExceedMaxAttempts(job, body.status, err)
 }
 
 // looperJitter looper循环器间隔抖动
@@ -350,7 +723,7 @@ func (m *manager) looperJitter() time.Duration {
 // 2、如果未超限则返回false
 func (m *manager) markJobAsFailedIfAlreadyExceedsMaxAttempts(job JobIFace) (needSop bool) {
 	// step1、执行时长检查，持续执行超过设置的超时时长则记录日志
-	if time.Now().Sub(job.PopTime()) >= job.Timeout() {
+	if m.now().Sub(job.PopTime()) >= job.Timeout() {
 		m.logger.Warn(
 			textJobTooLong,
 			"queue", job.GetName(),
@@ -365,7 +738,8 @@ func (m *manager) markJobAsFailedIfAlreadyExceedsMaxAttempts(job JobIFace) (need
 	}
 
 	// step3、其他情况：执行job前检查就不通过，移除任务&&标记任务失败（最大尝试次数超过限制、持续执行超时、脏数据、意外中断的任务 等）
-	m.failJob(job, ErrMaxAttemptsExceeded)
+	m.recordAttempt(job, ErrMaxAttemptsExceeded)
+	m.failJob(job, nil, ErrMaxAttemptsExceeded)
 
 	return true
 }
@@ -373,13 +747,14 @@ func (m *manager) markJobAsFailedIfAlreadyExceedsMaxAttempts(job JobIFace) (need
 // markJobAsFailedIfWillExceedMaxAttempts job执行`之后`检测尝试次数是否超限
 // 1、检查job执行是否超过基准时间以记录日志
 // 2、检查job执行尝试次数
-func (m *manager) markJobAsFailedIfWillExceedMaxAttempts(job JobIFace, err error) {
+// 3、status 本次执行的状态记录器，可追踪任务沿用其有效期记录重试、最终失败
+func (m *manager) markJobAsFailedIfWillExceedMaxAttempts(job JobIFace, status *statusReporter, err error) {
 	if job.IsDeleted() {
 		return
 	}
 
 	// step1、执行时长检查：超时记录超时日志
-	if time.Now().Sub(job.PopTime()) >= job.Timeout() {
+	if m.now().Sub(job.PopTime()) >= job.Timeout() {
 		m.logger.Warn(
 			textJobTooLong,
 			"queue", job.GetName(),
@@ -388,18 +763,43 @@ func (m *manager) markJobAsFailedIfWillExceedMaxAttempts(job JobIFace, err error
 		)
 	}
 
-	// step2、检查最大尝试执行次数是否超限
+	// step2、记录本次失败尝试
+	m.recordAttempt(job, err)
+
+	// step3、检查最大尝试执行次数是否超限
 	if job.Attempts() >= job.Payload().MaxTries {
 		// 超过最大重试次数：本次执行失败 && 任务类最终执行失败 && delete任务
-		m.failJob(job, err)
+		m.failJob(job, status, err)
 	} else {
 		// 任务可以重试：本次执行失败 && 任务类还可以重试 && release任务
-		_ = job.Release(job.Payload().RetryInterval)
+		_ = job.Release(m.retryDelay(job))
+		if m.metrics != nil {
+			m.metrics.IncRetried(job.GetName())
+		}
+		m.trackJob(job, status, JobStatusRetrying, err)
+	}
+}
+
+// retryDelay 计算job下次重试前的延迟时长，单位：秒
+// 1、任务类实现了 TaskRetryPolicyIFace 的由重试策略计算
+// 2、否则使用投递时记录的固定重试间隔 RetryInterval
+func (m *manager) retryDelay(job JobIFace) int64 {
+	task, ok := m.tasks[job.GetName()]
+	if !ok {
+		return job.Payload().RetryInterval
 	}
+
+	taskPolicy, ok := task.(TaskRetryPolicyIFace)
+	if !ok || taskPolicy.RetryPolicy() == nil {
+		return job.Payload().RetryInterval
+	}
+
+	return taskPolicy.RetryPolicy().NextDelay(job.Attempts(), job.Payload().RetryDelay)
 }
 
 // failJob 失败的任务触发器
-func (m *manager) failJob(job JobIFace, err error) {
+//   - status 本次执行的状态记录器，未执行的任务为nil
+func (m *manager) failJob(job JobIFace, status *statusReporter, err error) {
 	// -> 1、标记任务失败
 	job.MarkAsFailed()
 
@@ -420,8 +820,60 @@ func (m *manager) failJob(job JobIFace, err error) {
 	// -> 3、设置任务执行失败
 	job.Failed(err)
 
-	// -> 4、queue级别依赖是否有设置失败任务处理器动作
+	// -> 4、写入死信存储，保留完整payload便于排查和重新入队
+	m.buryDeadJob(job, err)
+
+	// -> 5、queue级别依赖是否有设置失败任务处理器动作
 	m.recordFailedJob(job, err)
+
+	// -> 6、任务链、批次：投递失败回调任务、更新批次进度
+	m.onJobFailed(job, err)
+
+	// -> 7、指标计数 && 最终失败事件钩子
+	m.jobFailed(job, err)
+
+	// -> 8、可追踪任务：记录最终失败
+	m.trackJob(job, status, JobStatusFailed, err)
+}
+
+// recordAttempt 记录job本次失败尝试到payload，随任务重试、进入死信一并保存
+func (m *manager) recordAttempt(job JobIFace, err error) {
+	payload := job.Payload()
+	payload.History = append(payload.History, AttemptRecord{
+		Attempt:  job.Attempts(),
+		Error:    err.Error(),
+		FailedAt: m.now().Unix(),
+	})
+	if len(payload.History) > maxAttemptHistory {
+		payload.History = payload.History[len(payload.History)-maxAttemptHistory:]
+	}
+}
+
+// buryDeadJob 最终失败的任务写入死信存储
+func (m *manager) buryDeadJob(job JobIFace, err error) {
+	store, ok := m.queue.(DeadLetterIFace)
+	if !m.deadLetter || !ok {
+		return
+	}
+
+	// payload以执行时的状态保存：尝试次数、首次执行时刻
+	payload := *job.Payload()
+	payload.Attempts = job.Attempts()
+	payload.PopTime = job.PopTime().Unix()
+
+	dead := &DeadJob{
+		Payload:  payload,
+		Error:    err.Error(),
+		FailedAt: m.now().Unix(),
+	}
+	if bErr := store.Bury(job.GetName(), dead); bErr != nil {
+		m.logger.Error(
+			textJobBuryFailed,
+			"queue", job.GetName(),
+			"payload", IFaceToString(job.Payload()),
+			"error", bErr.Error(),
+		)
+	}
 }
 
 // recordFailedJob 触发记录可能的失败任务
@@ -434,6 +886,7 @@ func (m *manager) recordFailedJob(job JobIFace, err error) {
 // shutDown 优雅停止队列
 // 1、停止轮询loop进程，不再投递job
 // 2、上下文设置的等待超时时间内尽量允许执行中的job顺利完成，超时终止的 :reserved 有序队列将在下次执行时再次投递尝试执行
+// 3、已pop尚未交给worker的job直接交还待执行队列，参见 drain
 // @param ctx 超时上下文
 func (m *manager) shutDown(ctx context.Context) (err error) {
 	m.inShutdown.setTrue()
@@ -0,0 +1,281 @@
+package queue
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// *************************************************
+// 队列指标
+// 1、MetricsCollector 指标收集器接口，可自行实现对接Prometheus client、StatsD等监控系统
+// 2、内置 Metrics 内存指标收集器，实现 http.Handler 以Prometheus文本格式输出指标
+// 3、队列深度由manager按 DefaultMetricsInterval 间隔定时采样
+// *************************************************
+
+// DefaultMetricsInterval 队列深度等指标的默认采样间隔
+const DefaultMetricsInterval = 15 * time.Second
+
+// defaultDurationBuckets 任务执行耗时直方图默认分桶，单位：秒
+var defaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 900}
+
+// MetricsCollector 队列指标收集器
+type MetricsCollector interface {
+	IncDispatched(queue string)                              // 任务投递成功
+	IncProcessed(queue string)                               // 任务执行成功
+	IncFailed(queue string)                                  // 任务最终失败
+	IncRetried(queue string)                                 // 任务执行失败后重试
+	IncTimeout(queue string)                                 // 任务执行超时
+	ObserveDuration(queue string, duration time.Duration)    // 任务单次执行耗时
+	SetDepth(queue string, pending, delayed, reserved int64) // 队列深度：待执行、延迟中、执行中任务数
+	SetBusyWorkers(busy int64)                               // 执行任务中的worker数
+}
+
+// SetMetrics 设置指标收集器，需在 Start 之前调用
+//   - collector 指标收集器，内置实现见 NewMetrics
+//   - interval  队列深度采样间隔，小于等于0时使用 DefaultMetricsInterval
+func (q *Queue) SetMetrics(collector MetricsCollector, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultMetricsInterval
+	}
+	q.manager.metrics = collector
+	q.manager.metricsInterval = interval
+}
+
+// region manager指标采集
+
+// startMetrics 定时采样队列深度直至队列关闭
+func (m *manager) startMetrics() {
+	if m.metrics == nil {
+		return
+	}
+
+	ticker := time.NewTicker(m.metricsInterval)
+	defer ticker.Stop()
+
+	for {
+		m.sampleDepth()
+
+		select {
+		case <-m.getDoneChan():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sampleDepth 采样所有已注册任务的队列深度，底层驱动未实现 DepthQueueIFace 时以队列长度作为待执行数
+func (m *manager) sampleDepth() {
+	m.lock.Lock()
+	names := make([]string, 0, len(m.tasks))
+	for name := range m.tasks {
+		names = append(names, name)
+	}
+	m.lock.Unlock()
+
+	for _, name := range names {
+		if depth, ok := m.queue.(DepthQueueIFace); ok {
+			if pending, delayed, reserved, err := depth.Depth(name); err == nil {
+				m.metrics.SetDepth(name, pending, delayed, reserved)
+			}
+			continue
+		}
+		m.metrics.SetDepth(name, m.queue.Size(name), 0, 0)
+	}
+}
+
+// workerBusy 执行任务中的worker数增减
+func (m *manager) workerBusy(delta int64) {
+	busy := atomic.AddInt64(&m.busyWorkers, delta)
+	if m.metrics != nil {
+		m.metrics.SetBusyWorkers(busy)
+	}
+}
+
+// endregion
+
+// region 内置内存指标收集器
+
+// metricsHistogram 耗时直方图
+type metricsHistogram struct {
+	counts []uint64 // 各分桶计数（非累计）
+	sum    float64  // 耗时总和，单位：秒
+	count  uint64   // 总次数
+}
+
+// Metrics 内置内存指标收集器
+//   - 实现 MetricsCollector 接口
+//   - 实现 http.Handler 接口，以Prometheus文本格式输出指标，可直接挂载到 /metrics 路由
+type Metrics struct {
+	lock       sync.Mutex
+	buckets    []float64
+	counters   map[string]map[string]uint64 // 指标名 => 队列名 => 计数
+	durations  map[string]*metricsHistogram // 队列名 => 耗时直方图
+	depths     map[string][3]int64          // 队列名 => 待执行、延迟中、执行中任务数
+	busy       int64                        // 执行任务中的worker数
+	namespace  string                       // 指标名前缀
+	counterSet []string                     // 计数器指标名，输出时保持顺序
+}
+
+// NewMetrics 实例化内置内存指标收集器
+//   - namespace 指标名前缀，为空时使用 queue
+//   - buckets   任务执行耗时直方图分桶（单位：秒，升序），为空时使用默认分桶
+func NewMetrics(namespace string, buckets ...float64) *Metrics {
+	if namespace == "" {
+		namespace = "queue"
+	}
+	if len(buckets) == 0 {
+		buckets = defaultDurationBuckets
+	}
+	// 复制后排序：不修改调用方传入的切片及默认分桶
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	metrics := &Metrics{
+		buckets:    buckets,
+		counters:   make(map[string]map[string]uint64),
+		durations:  make(map[string]*metricsHistogram),
+		depths:     make(map[string][3]int64),
+		namespace:  namespace,
+		counterSet: []string{"dispatched", "processed", "failed", "retried", "timeout"},
+	}
+	for _, name := range metrics.counterSet {
+		metrics.counters[name] = make(map[string]uint64)
+	}
+	return metrics
+}
+
+// inc 计数器自增
+func (s *Metrics) inc(name, queue string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.counters[name][queue]++
+}
+
+// IncDispatched 任务投递成功
+func (s *Metrics) IncDispatched(queue string) { s.inc("dispatched", queue) }
+
+// IncProcessed 任务执行成功
+func (s *Metrics) IncProcessed(queue string) { s.inc("processed", queue) }
+
+// IncFailed 任务最终失败
+func (s *Metrics) IncFailed(queue string) { s.inc("failed", queue) }
+
+// IncRetried 任务执行失败后重试
+func (s *Metrics) IncRetried(queue string) { s.inc("retried", queue) }
+
+// IncTimeout 任务执行超时
+func (s *Metrics) IncTimeout(queue string) { s.inc("timeout", queue) }
+
+// ObserveDuration 任务单次执行耗时
+func (s *Metrics) ObserveDuration(queue string, duration time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	histogram, exist := s.durations[queue]
+	if !exist {
+		histogram = &metricsHistogram{counts: make([]uint64, len(s.buckets))}
+		s.durations[queue] = histogram
+	}
+
+	seconds := duration.Seconds()
+	histogram.sum += seconds
+	histogram.count++
+	for idx, bound := range s.buckets {
+		if seconds <= bound {
+			histogram.counts[idx]++
+			break
+		}
+	}
+}
+
+// SetDepth 队列深度
+func (s *Metrics) SetDepth(queue string, pending, delayed, reserved int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.depths[queue] = [3]int64{pending, delayed, reserved}
+}
+
+// SetBusyWorkers 执行任务中的worker数
+func (s *Metrics) SetBusyWorkers(busy int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.busy = busy
+}
+
+// ServeHTTP 以Prometheus文本格式输出指标
+func (s *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = s.WritePrometheus(w)
+}
+
+// WritePrometheus 以Prometheus文本格式输出指标
+func (s *Metrics) WritePrometheus(w io.Writer) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var b strings.Builder
+
+	// counters
+	for _, name := range s.counterSet {
+		metric := s.namespace + "_jobs_" + name + "_total"
+		fmt.Fprintf(&b, "# HELP %s Total number of %s jobs.\n# TYPE %s counter\n", metric, name, metric)
+		for _, queue := range sortedKeys(s.counters[name]) {
+			fmt.Fprintf(&b, "%s{queue=%s} %d\n", metric, metricsLabel(queue), s.counters[name][queue])
+		}
+	}
+
+	// duration histogram
+	metric := s.namespace + "_job_duration_seconds"
+	fmt.Fprintf(&b, "# HELP %s Job execute duration in seconds.\n# TYPE %s histogram\n", metric, metric)
+	for _, queue := range sortedKeys(s.durations) {
+		histogram := s.durations[queue]
+		var cumulative uint64
+		for idx, bound := range s.buckets {
+			cumulative += histogram.counts[idx]
+			fmt.Fprintf(&b, "%s_bucket{queue=%s,le=\"%s\"} %d\n", metric, metricsLabel(queue), strconv.FormatFloat(bound, 'f', -1, 64), cumulative)
+		}
+		fmt.Fprintf(&b, "%s_bucket{queue=%s,le=\"+Inf\"} %d\n", metric, metricsLabel(queue), histogram.count)
+		fmt.Fprintf(&b, "%s_sum{queue=%s} %s\n", metric, metricsLabel(queue), strconv.FormatFloat(histogram.sum, 'f', -1, 64))
+		fmt.Fprintf(&b, "%s_count{queue=%s} %d\n", metric, metricsLabel(queue), histogram.count)
+	}
+
+	// depth gauge
+	metric = s.namespace + "_depth"
+	fmt.Fprintf(&b, "# HELP %s Number of jobs in the queue by state.\n# TYPE %s gauge\n", metric, metric)
+	for _, queue := range sortedKeys(s.depths) {
+		for idx, state := range []string{"pending", "delayed", "reserved"} {
+			fmt.Fprintf(&b, "%s{queue=%s,state=\"%s\"} %d\n", metric, metricsLabel(queue), state, s.depths[queue][idx])
+		}
+	}
+
+	// busy workers gauge
+	metric = s.namespace + "_busy_workers"
+	fmt.Fprintf(&b, "# HELP %s Number of workers executing jobs.\n# TYPE %s gauge\n%s %d\n", metric, metric, metric, s.busy)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// metricsLabel 转义Prometheus标签值
+func metricsLabel(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+// sortedKeys map的key升序列表，保持指标输出顺序稳定
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// endregion
@@ -0,0 +1,23 @@
+package queue
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewMetricsBucketsCopied(t *testing.T) {
+	buckets := []float64{5, 1, 0.5}
+	metrics := NewMetrics("", buckets...)
+
+	if !reflect.DeepEqual(buckets, []float64{5, 1, 0.5}) {
+		t.Fatalf("caller buckets = %v, want unchanged", buckets)
+	}
+	if !reflect.DeepEqual(metrics.buckets, []float64{0.5, 1, 5}) {
+		t.Fatalf("metrics buckets = %v, want sorted", metrics.buckets)
+	}
+
+	metrics.buckets[0] = 100
+	if NewMetrics("").buckets[0] == 100 {
+		t.Fatal("default buckets shared between metrics")
+	}
+}
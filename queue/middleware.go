@@ -0,0 +1,53 @@
+package queue
+
+import (
+	"context"
+)
+
+// *************************************************
+// 任务执行中间件：以类似gin的方式包裹任务类 Execute 方法，可用于统一日志、追踪、租户context等
+// 1、Use 注册全局中间件，作用于所有任务类
+// 2、UseTask 注册指定任务类的中间件
+// 3、执行顺序：全局中间件（按注册顺序） -> 任务类中间件（按注册顺序） -> 任务类 Execute
+// 中间件需在 Start 之前注册；任务类panic由worker统一捕获转换为 ErrJobExecutePanic，中间件可按需自行recover
+// *************************************************
+
+// HandlerFunc 任务执行方法签名，与任务类 Execute 方法一致
+type HandlerFunc func(ctx context.Context, job *RawBody) error
+
+// Middleware 任务执行中间件：接收下一个执行方法，返回包裹后的执行方法
+//   - 中间件内必须调用 next 才会继续执行后续中间件及任务类，不调用则视为以返回值结束本次执行
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Use 注册全局任务执行中间件
+func (q *Queue) Use(middlewares ...Middleware) {
+	q.manager.lock.Lock()
+	defer q.manager.lock.Unlock()
+	q.manager.middlewares = append(q.manager.middlewares, middlewares...)
+}
+
+// UseTask 注册指定任务类的任务执行中间件
+func (q *Queue) UseTask(task TaskIFace, middlewares ...Middleware) {
+	q.manager.lock.Lock()
+	defer q.manager.lock.Unlock()
+	if q.manager.taskMiddlewares == nil {
+		q.manager.taskMiddlewares = make(map[string][]Middleware)
+	}
+	name := task.Name()
+	q.manager.taskMiddlewares[name] = append(q.manager.taskMiddlewares[name], middlewares...)
+}
+
+// handler 组装任务类的中间件执行链
+func (m *manager) handler(task TaskIFace) HandlerFunc {
+	m.lock.Lock()
+	chain := make([]Middleware, 0, len(m.middlewares)+len(m.taskMiddlewares[task.Name()]))
+	chain = append(chain, m.middlewares...)
+	chain = append(chain, m.taskMiddlewares[task.Name()]...)
+	m.lock.Unlock()
+
+	handler := HandlerFunc(task.Execute)
+	for i := len(chain) - 1; i >= 0; i-- {
+		handler = chain[i](handler)
+	}
+	return handler
+}
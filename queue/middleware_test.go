@@ -0,0 +1,88 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// orderTask 记录执行顺序的任务类
+type orderTask struct {
+	DefaultTaskSetting
+	name  string
+	calls *[]string
+}
+
+func (t *orderTask) Name() string   { return t.name }
+func (t *orderTask) Remark() string { return t.name }
+func (t *orderTask) Execute(ctx context.Context, job *RawBody) error {
+	*t.calls = append(*t.calls, t.name)
+	return nil
+}
+
+// traceMiddleware 记录执行前后顺序的中间件
+func traceMiddleware(name string, calls *[]string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, job *RawBody) error {
+			*calls = append(*calls, name+" before")
+			err := next(ctx, job)
+			*calls = append(*calls, name+" after")
+			return err
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var calls []string
+	q := New(Memory, nil, nopLogger{}, 1)
+	task := &orderTask{name: "task", calls: &calls}
+	other := &orderTask{name: "other", calls: &calls}
+
+	// 任务类中间件先于全局中间件注册，仍在全局中间件之后执行
+	q.UseTask(task, traceMiddleware("t1", &calls), traceMiddleware("t2", &calls))
+	q.Use(traceMiddleware("g1", &calls))
+	q.UseTask(other, traceMiddleware("o1", &calls))
+	q.Use(traceMiddleware("g2", &calls))
+
+	if err := q.manager.handler(task)(context.Background(), &RawBody{}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"g1 before", "g2 before", "t1 before", "t2 before", "task", "t2 after", "t1 after", "g2 after", "g1 after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+
+	// 其他任务类的中间件不生效
+	calls = nil
+	if err := q.manager.handler(other)(context.Background(), &RawBody{}); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"g1 before", "g2 before", "o1 before", "other", "o1 after", "g2 after", "g1 after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	var calls []string
+	q := New(Memory, nil, nopLogger{}, 1)
+	task := &orderTask{name: "task", calls: &calls}
+	denied := errors.New("denied")
+
+	// 中间件不调用next：后续中间件及任务类不执行，以中间件返回值结束本次执行
+	q.Use(traceMiddleware("g1", &calls), func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, job *RawBody) error {
+			calls = append(calls, "deny")
+			return denied
+		}
+	})
+	q.UseTask(task, traceMiddleware("t1", &calls))
+
+	if err := q.manager.handler(task)(context.Background(), &RawBody{}); err != denied {
+		t.Fatalf("err = %v, want denied", err)
+	}
+	if want := []string{"g1 before", "deny", "g1 after"}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+}
@@ -0,0 +1,84 @@
+package queue
+
+import (
+	"strings"
+)
+
+// *************************************************
+// 命名空间：多个应用、环境或租户共用同一redis、数据库时隔离队列数据
+// 1、命名空间作为所有key（队列list、zSet、唯一锁、限流、通知频道、定时投递、任务状态、批次等）的前缀，以 : 分隔
+// 2、database驱动以带前缀的队列名称写入 queue 字段，memory、file驱动仅影响内部名称
+// 3、未设置命名空间时key与旧版本一致；启用命名空间后可通过 Queue.MigrateNamespace 迁移旧数据
+// *************************************************
+
+// Option 队列实例化可选设置
+type Option func(opts *options)
+
+// options 队列实例化可选设置项
+type options struct {
+	namespace string // 命名空间
+	tenant    string // 租户
+}
+
+// prefix 命名空间与租户拼接的key前缀
+func (o *options) prefix() string {
+	var parts []string
+	for _, part := range []string{o.namespace, o.tenant} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ":")
+}
+
+// WithNamespace 设置命名空间，例如应用名、环境名：app:prod
+func WithNamespace(namespace string) Option {
+	return func(opts *options) {
+		opts.namespace = namespace
+	}
+}
+
+// WithTenant 设置租户，拼接在命名空间之后：同一应用的各租户队列数据相互隔离
+func WithTenant(tenant string) Option {
+	return func(opts *options) {
+		opts.tenant = tenant
+	}
+}
+
+// namespaced 支持命名空间的底层驱动：内置驱动均通过 queueBasic 实现
+type namespaced interface {
+	setNamespace(namespace string)
+}
+
+// Namespace 队列实际使用的命名空间：命名空间与租户以 : 拼接，未设置时为空
+func (q *Queue) Namespace() string {
+	return q.namespace
+}
+
+// MigrateNamespace 将旧命名空间下已注册任务类的队列数据迁移至当前命名空间，返回迁移的key数或记录数
+//   - from 旧命名空间，为空表示未设置命名空间的旧版本数据
+//   - 需在 Bootstrap 之后、Start 之前执行，迁移期间请勿投递任务
+//   - 底层驱动未实现 MigratorQueueIFace 时返回 ErrMigrateNotSupported（memory、file驱动）
+func (q *Queue) MigrateNamespace(from string) (moved int64, err error) {
+	migrator, ok := q.queue.(MigratorQueueIFace)
+	if !ok {
+		return 0, ErrMigrateNotSupported
+	}
+	if from == q.namespace {
+		return 0, nil
+	}
+
+	q.manager.lock.Lock()
+	queues := make([]string, 0, len(q.manager.tasks)+len(q.manager.priorityTasks))
+	for name := range q.manager.tasks {
+		queues = append(queues, name)
+	}
+	for name := range q.manager.priorityTasks {
+		if _, exist := q.manager.tasks[name]; !exist {
+			queues = append(queues, name)
+		}
+	}
+	q.manager.lock.Unlock()
+
+	return migrator.MigrateNamespace(from, queues)
+}
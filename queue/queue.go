@@ -2,6 +2,7 @@ package queue
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
@@ -9,10 +10,12 @@ import (
 )
 
 // queue队列支持的底层驱动名称常量
-// 后续扩充mq、sqs、db等在此添加常量并实现 QueueIFace 接口予以关联
+// 后续扩充mq、sqs等在此添加常量并实现 QueueIFace 接口予以关联
 const (
-	Redis  = "redis"
-	Memory = "memory"
+	Redis    = "redis"
+	Memory   = "memory"
+	Database = "database" // conn 为 *sql.DB 或 DatabaseConfig
+	File     = "file"     // conn 为日志文件存储目录 或 FileConfig
 )
 
 // Queue 队列struct
@@ -22,6 +25,9 @@ type Queue struct {
 	queue      QueueIFace // 底层队列实现实体类，指针类型interface
 	manager    *manager   // 管理者对象实例
 	logger     Logger     // 队列日志记录器，统一固定使用zap
+
+	codec             Codec // 默认的任务参数编解码器，nil为 JSONCodec
+	compressThreshold int   // 任务参数编码后超过该字节数时gzip压缩，小于等于0不压缩
 }
 
 // New 初始化一个队列
@@ -29,7 +35,8 @@ type Queue struct {
 // 	@param conn       driver对应底层驱动连接器句柄，具体类型参考 QueueIFace 实体类
 // 	@param logger     实现 Logger 接口的结构体实例的指针对象
 // 	@param concurrent 单个队列最大并发消费数
-func New(driver string, conn interface{}, logger Logger, concurrent int64) *Queue {
+// 	@param opts       可选设置：WithNamespace、WithTenant
+func New(driver string, conn interface{}, logger Logger, concurrent int64, opts ...Option) *Queue {
 	var queue QueueIFace
 
 	// init specify queue driver
@@ -39,10 +46,24 @@ func New(driver string, conn interface{}, logger Logger, concurrent int64) *Queu
 	case Redis:
 		// queue = &redisQueue{connection: conn.(*redis.Client)}
 		queue = &redisQueue{luaScripts: &luaScripts{}}
+	case Database:
+		queue = &databaseQueue{}
+	case File:
+		queue = newFileQueue()
 	default:
 		panic("do not implement queue instance: " + driver)
 	}
 
+	// set namespace before connection
+	option := &options{}
+	for _, opt := range opts {
+		opt(option)
+	}
+	namespace := option.prefix()
+	if driverNS, ok := queue.(namespaced); ok {
+		driverNS.setNamespace(namespace)
+	}
+
 	// set connection
 	err := queue.SetConnection(conn)
 	if nil != err {
@@ -50,10 +71,11 @@ func New(driver string, conn interface{}, logger Logger, concurrent int64) *Queu
 	}
 
 	return &Queue{
-		driver:  driver,
-		queue:   queue,
-		manager: newManager(queue, logger, concurrent),
-		logger:  logger,
+		queueBasic: queueBasic{namespace: namespace},
+		driver:     driver,
+		queue:      queue,
+		manager:    newManager(queue, logger, concurrent),
+		logger:     logger,
 	}
 }
 
@@ -67,6 +89,116 @@ func (q *Queue) SetFailedJobHandler(failedJobHandler FailedJobHandler) {
 	q.manager.failedJobHandler = failedJobHandler
 }
 
+// SetExecuteGrace 设置任务执行超时后等待任务类主动退出的宽限时长，默认 DefaultExecuteGrace
+//   - 超时后context被取消，任务类应尽快响应 ctx.Done() 退出
+//   - 宽限期内退出的按执行结果正常收尾；宽限期后仍未退出的任务将被放弃等待并按超时失败处理
+//   - 被放弃等待的任务迟到返回时其执行结果将被丢弃，不会再操作已重新调度的任务
+func (q *Queue) SetExecuteGrace(grace time.Duration) {
+	if grace < 0 {
+		grace = 0
+	}
+	q.manager.grace = grace
+}
+
+// SetDeadLetter 设置最终失败的任务是否写入死信存储，默认写入
+//   - 死信任务保存完整payload、最后一次失败的错误信息、失败尝试记录以及失败时刻
+//   - 死信存储不会自动清理，请按需调用 PurgeDeadJobs 定期清理
+func (q *Queue) SetDeadLetter(enable bool) {
+	q.manager.deadLetter = enable
+}
+
+// SetNotifyMode 开启通知模式，需在 Start 之前调用
+//   - 默认模式下looper无任务时随机休眠450毫秒至1秒后再次轮询所有队列，存在最长约1秒的消费延迟
+//   - 通知模式下looper无任务时阻塞等待任务投递通知或延迟、保留任务到期，投递后几乎立即被消费
+//   - maxIdle 无任何通知时的最长等待时长，兜底通知丢失等情况，小于等于0时使用 DefaultNotifyMaxIdle
+//   - 底层驱动需实现 NotifiableQueueIFace（redis驱动基于Pub/Sub、memory驱动基于chan），否则仍为轮询模式
+func (q *Queue) SetNotifyMode(maxIdle time.Duration) {
+	if maxIdle <= 0 {
+		maxIdle = DefaultNotifyMaxIdle
+	}
+	q.manager.notifyMode = true
+	q.manager.notifyMaxIdle = maxIdle
+}
+
+// SetScheduler 设置looper调度器，需在 Start 之前调用
+//   - NewDefaultScheduler 默认：高优先级任务先被轮询，其余任务随机顺序轮询
+//   - NewStrictPriorityScheduler 严格优先级：任务类实现 TaskPriorityIFace 指定优先级
+//   - NewWeightedScheduler 加权轮询：任务类实现 WeightedTaskIFace 指定权重，按权重比例分配吞吐
+//   - NewFairScheduler 公平调度：有job堆积的任务之间均分吞吐
+func (q *Queue) SetScheduler(scheduler SchedulerIFace) {
+	q.manager.setScheduler(scheduler)
+}
+
+// DeadJobs 按失败时刻倒序分页获取指定队列的死信任务
+//   - name   任务名称，即任务类 Name() 返回值
+//   - offset 偏移量，从0开始
+//   - limit  获取条数
+func (q *Queue) DeadJobs(name string, offset, limit int64) (jobs []*DeadJob, total int64, err error) {
+	store, ok := q.queue.(DeadLetterIFace)
+	if !ok {
+		return nil, 0, ErrDeadLetterNotSupported
+	}
+	return store.DeadJobs(name, offset, limit)
+}
+
+// RequeueDeadJob 将一条死信任务重新投递到队列，尝试次数重新计数
+func (q *Queue) RequeueDeadJob(name, id string) error {
+	store, ok := q.queue.(DeadLetterIFace)
+	if !ok {
+		return ErrDeadLetterNotSupported
+	}
+	return store.RequeueDead(name, id)
+}
+
+// RequeueDeadJobs 将指定队列下所有死信任务重新投递到队列，返回重新投递的任务数
+func (q *Queue) RequeueDeadJobs(name string) (int64, error) {
+	store, ok := q.queue.(DeadLetterIFace)
+	if !ok {
+		return 0, ErrDeadLetterNotSupported
+	}
+	return store.RequeueAllDead(name)
+}
+
+// PurgeDeadJobs 清理指定队列下失败时长超过olderThan的死信任务，返回清理的任务数
+func (q *Queue) PurgeDeadJobs(name string, olderThan time.Duration) (int64, error) {
+	store, ok := q.queue.(DeadLetterIFace)
+	if !ok {
+		return 0, ErrDeadLetterNotSupported
+	}
+	return store.PurgeDead(name, time.Now().Add(-olderThan))
+}
+
+// Jobs 分页浏览指定队列指定状态的任务
+//   - name   任务名称，即任务类 Name() 返回值
+//   - state  任务状态：JobStatePending、JobStateDelayed、JobStateReserved
+//   - offset 偏移量，从0开始
+//   - limit  获取条数
+func (q *Queue) Jobs(name, state string, offset, limit int64) (jobs []*JobInfo, total int64, err error) {
+	store, ok := q.queue.(InspectableQueueIFace)
+	if !ok {
+		return nil, 0, ErrInspectNotSupported
+	}
+	return store.Jobs(name, state, offset, limit)
+}
+
+// DeleteJob 按任务ID删除指定队列指定状态的任务
+func (q *Queue) DeleteJob(name, state, id string) error {
+	store, ok := q.queue.(InspectableQueueIFace)
+	if !ok {
+		return ErrInspectNotSupported
+	}
+	return store.DeleteJob(name, state, id)
+}
+
+// RequeueJob 按任务ID将延迟中或执行中的任务立即放回待执行队列
+func (q *Queue) RequeueJob(name, state, id string) error {
+	store, ok := q.queue.(InspectableQueueIFace)
+	if !ok {
+		return ErrInspectNotSupported
+	}
+	return store.RequeueJob(name, state, id)
+}
+
 // endregion
 
 // region 注册任务类相关方法
@@ -99,6 +231,13 @@ func (q *Queue) ShutDown(ctx context.Context) error {
 	return q.manager.shutDown(ctx)
 }
 
+// RunReady 在当前协程中同步执行所有已就绪的任务，返回执行的任务数
+//   - 用于单元测试（参见 queuetest 包），不可与 Start 同时使用
+//   - 执行过程中投递的任务、立即重试的任务在本次调用中一并执行，延迟任务需到期后才会执行
+func (q *Queue) RunReady() int {
+	return q.manager.runReady()
+}
+
 // endregion
 
 // region 投递任务相关方法
@@ -110,7 +249,42 @@ func (q *Queue) Dispatch(task TaskIFace, payload interface{}) error {
 		return fmt.Errorf("queue %s job param marshal failed: %s", task.Name(), err.Error())
 	}
 
-	return q.queue.Push(task.Name(), queuePayload)
+	if err = q.queue.Push(task.Name(), queuePayload); err != nil {
+		return err
+	}
+	q.manager.jobDispatched(task.Name(), queuePayload)
+	return nil
+}
+
+// DispatchUnique 投递一个唯一队列Job任务
+//   - 同一任务类下唯一key相同的任务处于待执行、延迟中、执行中时不再重复投递，返回false
+//   - 唯一锁在任务执行成功或最终失败后释放，ttl为任务丢失等异常情况下唯一锁的兜底有效期，小于等于0时使用 DefaultUniqueTTL
+//   - 队列底层驱动未实现 UniqueQueueIFace 时返回 ErrUniqueNotSupported
+func (q *Queue) DispatchUnique(task TaskIFace, payload interface{}, key string, ttl time.Duration) (bool, error) {
+	unique, ok := q.queue.(UniqueQueueIFace)
+	if !ok {
+		return false, ErrUniqueNotSupported
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultUniqueTTL
+	}
+
+	queuePayload, err := q.newTaskPayload(task, payload)
+	if nil != err {
+		return false, fmt.Errorf("queue %s job param marshal failed: %s", task.Name(), err.Error())
+	}
+	queuePayload.UniqueKey = key
+	raw, err := json.Marshal(queuePayload)
+	if nil != err {
+		return false, fmt.Errorf("queue %s job param marshal failed: %s", task.Name(), err.Error())
+	}
+
+	pushed, err := unique.PushUnique(task.Name(), key, ttl, queuePayload.ID, raw)
+	if pushed {
+		q.manager.jobDispatched(task.Name(), raw)
+	}
+	return pushed, err
 }
 
 // DelayAt 投递一个指定的将来时刻执行的延迟队列Job任务
@@ -120,7 +294,11 @@ func (q *Queue) DelayAt(task TaskIFace, payload interface{}, delay time.Time) er
 		return fmt.Errorf("queue %s job param marshal failed: %s", task.Name(), err.Error())
 	}
 
-	return q.queue.LaterAt(task.Name(), delay, queuePayload)
+	if err = q.queue.LaterAt(task.Name(), delay, queuePayload); err != nil {
+		return err
+	}
+	q.manager.jobDispatched(task.Name(), queuePayload)
+	return nil
 }
 
 // Delay 投递一个指定延迟时长的延迟队列Job任务
@@ -130,7 +308,11 @@ func (q *Queue) Delay(task TaskIFace, payload interface{}, duration time.Duratio
 		return fmt.Errorf("queue %s job param marshal failed: %s", task.Name(), err.Error())
 	}
 
-	return q.queue.Later(task.Name(), duration, queuePayload)
+	if err = q.queue.Later(task.Name(), duration, queuePayload); err != nil {
+		return err
+	}
+	q.manager.jobDispatched(task.Name(), queuePayload)
+	return nil
 }
 
 // DispatchByName 按任务name投递一个队列Job任务
@@ -192,7 +374,9 @@ func (q *Queue) SetAllowTasks(taskNames ...string) {
 			continue
 		}
 		q.logger.Info("queue set-allow-task", "taskName", name)
+		q.manager.filterLock.Lock()
 		q.manager.allowTasks[name] = struct{}{}
+		q.manager.filterLock.Unlock()
 	}
 }
 
@@ -203,8 +387,31 @@ func (q *Queue) SetExcludeTasks(taskNames ...string) {
 			continue
 		}
 		q.logger.Info("queue set-exclude-task", "taskName", name)
+		q.manager.filterLock.Lock()
 		q.manager.excludeTasks[name] = struct{}{}
+		q.manager.filterLock.Unlock()
 	}
 }
 
+// Pause 运行时暂停任务：looper不再取出暂停任务的job，执行中的job不受影响，可在 Start 之后随时调用
+func (q *Queue) Pause(taskNames ...string) {
+	for _, name := range taskNames {
+		q.logger.Info("queue pause-task", "taskName", name)
+	}
+	q.manager.setPaused(true, taskNames...)
+}
+
+// Resume 运行时恢复已暂停的任务
+func (q *Queue) Resume(taskNames ...string) {
+	for _, name := range taskNames {
+		q.logger.Info("queue resume-task", "taskName", name)
+	}
+	q.manager.setPaused(false, taskNames...)
+}
+
+// IsPaused 检查任务是否已暂停
+func (q *Queue) IsPaused(taskName string) bool {
+	return q.manager.isPaused(taskName)
+}
+
 // endregion
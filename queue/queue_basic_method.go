@@ -10,31 +10,90 @@ import (
  */
 
 // queueBasic 队列基础公用方法
-type queueBasic struct{}
+type queueBasic struct {
+	namespace string // 命名空间：队列相关名称的前缀，为空时无前缀
+}
+
+// setNamespace 设置命名空间
+func (r *queueBasic) setNamespace(namespace string) {
+	r.namespace = namespace
+}
 
 // region 获取队列相关名称私有方法
 
+// key 拼接命名空间前缀
+func (r *queueBasic) key(name string) string {
+	if r.namespace == "" {
+		return name
+	}
+	return r.namespace + ":" + name
+}
+
 // name 获取队列名称
 func (r *queueBasic) name(queue string) string {
-	return queue
+	return r.key(queue)
 }
 
 // reservedName 获取队列执行中zSet名称
 func (r *queueBasic) reservedName(queue string) string {
-	return queue + ":reserved"
+	return r.key(queue + ":reserved")
 }
 
 // delayedName 获取队列延迟zSet名称
 func (r *queueBasic) delayedName(queue string) string {
-	return queue + ":delayed"
+	return r.key(queue + ":delayed")
+}
+
+// deadName 获取队列死信索引zSet名称
+func (r *queueBasic) deadName(queue string) string {
+	return r.key(queue + ":dead")
+}
+
+// deadJobsName 获取队列死信任务存储hash名称
+func (r *queueBasic) deadJobsName(queue string) string {
+	return r.key(queue + ":dead:jobs")
+}
+
+// uniqueName 获取唯一任务锁名称
+func (r *queueBasic) uniqueName(queue, key string) string {
+	return r.key(queue + ":unique:" + key)
+}
+
+// limiterSlotsName 获取任务限流执行名额zSet名称
+func (r *queueBasic) limiterSlotsName(queue string) string {
+	return r.key(queue + ":limiter:slots")
+}
+
+// limiterBucketName 获取任务限流令牌桶hash名称
+func (r *queueBasic) limiterBucketName(queue string) string {
+	return r.key(queue + ":limiter:bucket")
+}
+
+// notifyName 获取任务就绪通知频道名称
+func (r *queueBasic) notifyName() string {
+	return r.key("queue:notify")
+}
+
+// scheduleName 获取定时投递最近认领tick的hash名称
+func (r *queueBasic) scheduleName() string {
+	return r.key("queue:schedules")
+}
+
+// statusName 获取任务状态记录hash名称
+func (r *queueBasic) statusName(id string) string {
+	return r.key("status:" + id)
+}
+
+// batchName 获取批次进度hash名称
+func (r *queueBasic) batchName(id string) string {
+	return r.key("batch:" + id)
 }
 
-// marshalPayload 初始化创建生成队列内部存储的payload字符串
+// newPayload 初始化创建队列内部存储的payload结构
 // @task	  队列任务类实例
 // @taskParam 队列job参数
-// @ID	      队列job编号ID（延迟队列）
-func (r *queueBasic) marshalPayload(task TaskIFace, taskParam interface{}) ([]byte, error) {
-	return json.Marshal(Payload{
+func (r *queueBasic) newPayload(task TaskIFace, taskParam interface{}) Payload {
+	return Payload{
 		Name:          task.Name(),
 		ID:            FakeUniqueID(),
 		MaxTries:      task.MaxTries(),
@@ -44,7 +103,7 @@ func (r *queueBasic) marshalPayload(task TaskIFace, taskParam interface{}) ([]by
 		PopTime:       0,                               // 首次被取出开始执行的时间戳，取出的时候才去设置
 		Timeout:       int64(task.Timeout().Seconds()), // 最大执行秒数
 		TimeoutAt:     0,                               // 超时时刻，被执行时刻才会去设置
-	})
+	}
 }
 
 // unmarshalPayload 解析生成队列内部存储的payload字符串为struct
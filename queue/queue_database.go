@@ -0,0 +1,434 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+// 基于关系型数据库实现队列机制（MySQL 8.0+/SQLite）：
+// 一、原理
+//    每个job一行记录，available_at 记录任务可执行时刻实现延时队列，reserved_until 记录执行中任务的超时时刻实现保留队列
+// 二、producer
+//    实时队列：insert一行 available_at 为当前时刻的记录
+//    延时队列：insert一行 available_at 为延迟执行时刻的记录
+// 三、consumer/worker步骤
+//    step1、事务中取出1条 未保留且已到可执行时刻 或 保留已超时 的记录，MySQL使用`FOR UPDATE SKIP LOCKED`跳过其他消费者锁定的记录
+//    step2、将payload字段Attempts自增1，reserved_until 为任务执行超时的时刻；attempts 字段作为乐观锁版本号，更新失败视为被其他消费者取走
+//    step3、执行超时or执行失败，重试（清空 reserved_until，available_at 为重试时刻）；执行成功任务结束（删除记录）
+// ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+
+// 数据库驱动支持的SQL方言
+const (
+	DialectMySQL  = "mysql"
+	DialectSQLite = "sqlite"
+)
+
+// DefaultDatabaseTable 数据库驱动默认的任务表名
+const DefaultDatabaseTable = "jobs"
+
+// DatabaseConfig 数据库驱动连接配置，New 方法 conn 参数传 *sql.DB 时使用MySQL方言和默认表名
+type DatabaseConfig struct {
+	DB      *sql.DB // 数据库连接池
+	Dialect string  // SQL方言：DialectMySQL、DialectSQLite，默认 DialectMySQL
+	Table   string  // 任务表名，默认 DefaultDatabaseTable
+}
+
+// databaseQueue 基于关系型数据库实现的队列
+// implement QueueIFace
+type databaseQueue struct {
+	queueBasic         // 队列基础可公用方法
+	db         *sql.DB // 数据库连接池
+	dialect    string  // SQL方言
+	table      string  // 任务表名
+}
+
+// Size 获取队列长度：包含待执行、延迟和执行中的任务
+func (d *databaseQueue) Size(queue string) (size int64) {
+	_ = d.db.QueryRow(
+		"SELECT COUNT(*) FROM "+d.quote(d.table)+" WHERE queue = ?",
+		d.name(queue),
+	).Scan(&size)
+	return size
+}
+
+// Depth 获取队列深度：待执行（已到可执行时刻）、延迟中、执行中的任务数
+func (d *databaseQueue) Depth(queue string) (pending, delayed, reserved int64, err error) {
+	now := time.Now().Unix()
+	err = d.db.QueryRow(
+		"SELECT"+
+			" COALESCE(SUM(CASE WHEN reserved_until IS NULL AND available_at <= ? THEN 1 ELSE 0 END), 0),"+
+			" COALESCE(SUM(CASE WHEN reserved_until IS NULL AND available_at > ? THEN 1 ELSE 0 END), 0),"+
+			" COALESCE(SUM(CASE WHEN reserved_until IS NOT NULL THEN 1 ELSE 0 END), 0)"+
+			" FROM "+d.quote(d.table)+" WHERE queue = ?",
+		now,
+		now,
+		d.name(queue),
+	).Scan(&pending, &delayed, &reserved)
+	return pending, delayed, reserved, err
+}
+
+// stateCondition 任务状态对应的查询条件及其参数
+func (d *databaseQueue) stateCondition(state string) (condition string, args []interface{}, err error) {
+	switch state {
+	case JobStatePending:
+		return "reserved_until IS NULL AND available_at <= ?", []interface{}{time.Now().Unix()}, nil
+	case JobStateDelayed:
+		return "reserved_until IS NULL AND available_at > ?", []interface{}{time.Now().Unix()}, nil
+	case JobStateReserved:
+		return "reserved_until IS NOT NULL", nil, nil
+	}
+	return "", nil, ErrJobStateInvalid
+}
+
+// Jobs 分页浏览指定状态的任务：待执行按投递顺序，延迟中、执行中的任务按时刻升序
+func (d *databaseQueue) Jobs(queue, state string, offset, limit int64) (jobs []*JobInfo, total int64, err error) {
+	condition, args, err := d.stateCondition(state)
+	if err != nil {
+		return nil, 0, err
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	args = append([]interface{}{d.name(queue)}, args...)
+	jobs = make([]*JobInfo, 0)
+	if err = d.db.QueryRow(
+		"SELECT COUNT(*) FROM "+d.quote(d.table)+" WHERE queue = ? AND "+condition,
+		args...,
+	).Scan(&total); err != nil || limit <= 0 {
+		return jobs, total, err
+	}
+
+	order := "id ASC"
+	switch state {
+	case JobStateDelayed:
+		order = "available_at ASC, id ASC"
+	case JobStateReserved:
+		order = "reserved_until ASC, id ASC"
+	}
+	rows, err := d.db.Query(
+		"SELECT payload, COALESCE(reserved_until, available_at) FROM "+d.quote(d.table)+
+			" WHERE queue = ? AND "+condition+" ORDER BY "+order+" LIMIT ? OFFSET ?",
+		append(args, limit, offset)...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			raw    string
+			timeAt int64
+		)
+		if err = rows.Scan(&raw, &timeAt); err != nil {
+			return nil, 0, err
+		}
+		var payload Payload
+		if d.unmarshalPayload([]byte(raw), &payload) != nil {
+			continue
+		}
+		job := &JobInfo{State: state, Payload: payload}
+		if state != JobStatePending {
+			job.TimeAt = timeAt
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, total, rows.Err()
+}
+
+// DeleteJob 按ID删除指定状态的任务
+func (d *databaseQueue) DeleteJob(queue, state, id string) (err error) {
+	condition, args, err := d.stateCondition(state)
+	if err != nil {
+		return err
+	}
+
+	ret, err := d.db.Exec(
+		"DELETE FROM "+d.quote(d.table)+" WHERE queue = ? AND job_id = ? AND "+condition,
+		append([]interface{}{d.name(queue), id}, args...)...,
+	)
+	return d.affectedOne(ret, err)
+}
+
+// RequeueJob 将延迟中或执行中的任务立即放回待执行队列
+//   - 执行中的任务同时递增乐观锁版本号，原消费者后续的释放、删除操作不再生效
+func (d *databaseQueue) RequeueJob(queue, state, id string) (err error) {
+	if state != JobStateDelayed && state != JobStateReserved {
+		return ErrJobStateInvalid
+	}
+	condition, args, _ := d.stateCondition(state)
+
+	ret, err := d.db.Exec(
+		"UPDATE "+d.quote(d.table)+" SET attempts = attempts + 1, reserved_until = NULL, available_at = ? WHERE queue = ? AND job_id = ? AND "+condition,
+		append([]interface{}{time.Now().Unix(), d.name(queue), id}, args...)...,
+	)
+	return d.affectedOne(ret, err)
+}
+
+// affectedOne 按ID操作任务的影响行数为0时返回 ErrJobNotFound
+func (d *databaseQueue) affectedOne(ret sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if affected, err := ret.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+// Push 投递一条任务到队列
+func (d *databaseQueue) Push(queue string, payload interface{}) (err error) {
+	return d.LaterAt(queue, time.Now(), payload)
+}
+
+// Later 延迟指定时长后执行的延迟任务
+func (d *databaseQueue) Later(queue string, durationTo time.Duration, payload interface{}) (err error) {
+	return d.LaterAt(queue, time.Now().Add(durationTo), payload)
+}
+
+// LaterAt 指定时刻执行的延时任务
+func (d *databaseQueue) LaterAt(queue string, timeAt time.Time, payload interface{}) (err error) {
+	body := []byte(IFaceToString(payload))
+
+	var job Payload
+	if err = d.unmarshalPayload(body, &job); err != nil {
+		return err
+	}
+
+	_, err = d.db.Exec(
+		"INSERT INTO "+d.quote(d.table)+" (queue, job_id, payload, attempts, reserved_until, available_at, created_at) VALUES (?, ?, ?, ?, NULL, ?, ?)",
+		d.name(queue),
+		job.ID,
+		string(body),
+		job.Attempts,
+		timeAt.Unix(),
+		time.Now().Unix(),
+	)
+	return err
+}
+
+// Pop 取出弹出一条待执行的任务
+func (d *databaseQueue) Pop(queue string) (job JobIFace, exist bool) {
+	now := time.Now()
+	ctx := context.Background()
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// step1、取出1条 未保留且已到可执行时刻 或 保留已超时 的任务
+	query := "SELECT id, payload, attempts FROM " + d.quote(d.table) +
+		" WHERE queue = ? AND ((reserved_until IS NULL AND available_at <= ?) OR reserved_until <= ?)" +
+		" ORDER BY id ASC LIMIT 1"
+	if d.dialect == DialectMySQL {
+		query += " FOR UPDATE SKIP LOCKED"
+	}
+
+	var (
+		id       int64
+		raw      string
+		attempts int64
+	)
+	err = tx.QueryRowContext(ctx, query, d.name(queue), now.Unix(), now.Unix()).Scan(&id, &raw, &attempts)
+	if err != nil {
+		// sql.ErrNoRows or query error
+		return nil, false
+	}
+
+	// step2、将字段Attempts自增1，填充首次取出时间
+	var rJob, reserved Payload
+	if d.unmarshalPayload([]byte(raw), &rJob) != nil {
+		return nil, false
+	}
+	reserved = rJob
+	reserved.Attempts++
+	if reserved.PopTime <= 0 {
+		reserved.PopTime = now.Unix()
+	}
+	reservedBody, err := json.Marshal(reserved)
+	if err != nil {
+		return nil, false
+	}
+
+	// step3、保留任务：attempts 作为乐观锁版本号，其他消费者已取走时影响行数为0
+	leaseAt := now.Add(time.Duration(reserved.Timeout) * time.Second).Unix()
+	ret, err := tx.ExecContext(
+		ctx,
+		"UPDATE "+d.quote(d.table)+" SET payload = ?, attempts = ?, reserved_until = ? WHERE id = ? AND attempts = ?",
+		string(reservedBody),
+		attempts+1,
+		leaseAt,
+		id,
+		attempts,
+	)
+	if err != nil {
+		return nil, false
+	}
+	if affected, err := ret.RowsAffected(); err != nil || affected != 1 {
+		return nil, false
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, false
+	}
+
+	return &JobDatabase{
+		database: d,
+		rowID:    id,
+		version:  attempts + 1,
+		leaseAt:  leaseAt,
+		lock:     sync.Mutex{},
+		jobProperty: jobProperty{
+			handler:    d,
+			name:       queue,
+			job:        raw,
+			reserved:   string(reservedBody),
+			payload:    &rJob,
+			isReleased: false,
+			isDeleted:  false,
+			hasFailed:  false,
+			popTime:    time.Unix(reserved.PopTime, 0),
+			timeout:    time.Duration(reserved.Timeout) * time.Second,
+			timeoutAt:  now.Add(time.Duration(reserved.Timeout) * time.Second),
+		},
+	}, true
+}
+
+// MigrateNamespace 将旧命名空间下的任务记录的 queue 字段更新为当前命名空间下的队列名称
+func (d *databaseQueue) MigrateNamespace(from string, queues []string) (moved int64, err error) {
+	old := &queueBasic{namespace: from}
+	for _, queue := range queues {
+		ret, err := d.db.Exec(
+			"UPDATE "+d.quote(d.table)+" SET queue = ? WHERE queue = ?",
+			d.name(queue),
+			old.name(queue),
+		)
+		if err != nil {
+			return moved, err
+		}
+		affected, _ := ret.RowsAffected()
+		moved += affected
+	}
+	return moved, nil
+}
+
+// SetConnection
+// 设置数据库队列的连接器：*sql.DB 或 DatabaseConfig、*DatabaseConfig
+func (d *databaseQueue) SetConnection(connection interface{}) (err error) {
+	var config DatabaseConfig
+	switch conn := connection.(type) {
+	case *sql.DB:
+		config = DatabaseConfig{DB: conn}
+	case DatabaseConfig:
+		config = conn
+	case *DatabaseConfig:
+		config = *conn
+	default:
+		return fmt.Errorf("unsupported database queue connection: %T", connection)
+	}
+
+	if config.DB == nil {
+		return errors.New("null pointer connection instance")
+	}
+	if config.Dialect == "" {
+		config.Dialect = DialectMySQL
+	}
+	if config.Dialect != DialectMySQL && config.Dialect != DialectSQLite {
+		return fmt.Errorf("unsupported database queue dialect: %s", config.Dialect)
+	}
+	if config.Table == "" {
+		config.Table = DefaultDatabaseTable
+	}
+
+	d.db = config.DB
+	d.dialect = config.Dialect
+	d.table = config.Table
+	return nil
+}
+
+// GetConnection
+// 获取数据库队列的连接器：*sql.DB（interface）使用前需显式转换
+func (d *databaseQueue) GetConnection() (connection interface{}, err error) {
+	if d.db == nil {
+		return nil, errors.New("null pointer connection instance")
+	}
+
+	return d.db, nil
+}
+
+// quote 按SQL方言转义标识符
+func (d *databaseQueue) quote(identifier string) string {
+	if d.dialect == DialectMySQL {
+		return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+// MigrateDatabase 创建数据库驱动使用的任务表，表已存在时不做任何处理
+//   - conn 同 New 方法 Database 驱动的 conn 参数：*sql.DB 或 DatabaseConfig、*DatabaseConfig
+//   - MySQL建表语句亦可参考 stubs/jobs.sql 手动执行
+func MigrateDatabase(conn interface{}) error {
+	d := &databaseQueue{}
+	if err := d.SetConnection(conn); err != nil {
+		return err
+	}
+
+	table := d.quote(d.table)
+	var statements []string
+	switch d.dialect {
+	case DialectMySQL:
+		statements = []string{
+			"CREATE TABLE IF NOT EXISTS " + table + " (" +
+				"`id` bigint unsigned NOT NULL AUTO_INCREMENT, " +
+				"`queue` varchar(191) NOT NULL, " +
+				"`job_id` varchar(64) NOT NULL, " +
+				"`payload` longtext NOT NULL, " +
+				"`attempts` int unsigned NOT NULL DEFAULT 0, " +
+				"`reserved_until` int unsigned DEFAULT NULL, " +
+				"`available_at` int unsigned NOT NULL, " +
+				"`created_at` int unsigned NOT NULL, " +
+				"PRIMARY KEY (`id`), " +
+				"KEY `idx_queue_available` (`queue`, `available_at`), " +
+				"KEY `idx_queue_reserved` (`queue`, `reserved_until`), " +
+				"KEY `idx_job_id` (`job_id`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci",
+		}
+	case DialectSQLite:
+		statements = []string{
+			"CREATE TABLE IF NOT EXISTS " + table + " (" +
+				`"id" INTEGER PRIMARY KEY AUTOINCREMENT, ` +
+				`"queue" TEXT NOT NULL, ` +
+				`"job_id" TEXT NOT NULL, ` +
+				`"payload" TEXT NOT NULL, ` +
+				`"attempts" INTEGER NOT NULL DEFAULT 0, ` +
+				`"reserved_until" INTEGER DEFAULT NULL, ` +
+				`"available_at" INTEGER NOT NULL, ` +
+				`"created_at" INTEGER NOT NULL` +
+				")",
+			"CREATE INDEX IF NOT EXISTS " + d.quote("idx_"+d.table+"_queue_available") + " ON " + table + ` ("queue", "available_at")`,
+			"CREATE INDEX IF NOT EXISTS " + d.quote("idx_"+d.table+"_queue_reserved") + " ON " + table + ` ("queue", "reserved_until")`,
+			"CREATE INDEX IF NOT EXISTS " + d.quote("idx_"+d.table+"_job_id") + " ON " + table + ` ("job_id")`,
+		}
+	}
+
+	for _, statement := range statements {
+		if _, err := d.db.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package queue
+
+import (
+	"database/sql"
+	"encoding/json"
+	_ "github.com/mattn/go-sqlite3"
+	"testing"
+)
+
+// newTestDatabaseQueue 基于SQLite内存库的数据库驱动
+func newTestDatabaseQueue(t *testing.T) *databaseQueue {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内存库每个连接相互独立：限定单连接
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	config := DatabaseConfig{DB: db, Dialect: DialectSQLite}
+	if err = MigrateDatabase(config); err != nil {
+		t.Fatal(err)
+	}
+	d := &databaseQueue{}
+	if err = d.SetConnection(config); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func pushTestPayload(t *testing.T, d *databaseQueue, queue, id string) {
+	t.Helper()
+
+	payload, _ := json.Marshal(Payload{Name: queue, ID: id, MaxTries: 3, Timeout: 60})
+	if err := d.Push(queue, payload); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDatabaseQueuePop(t *testing.T) {
+	d := newTestDatabaseQueue(t)
+	pushTestPayload(t, d, "a", "1")
+	pushTestPayload(t, d, "a", "2")
+
+	for _, want := range []string{"1", "2"} {
+		job, exist := d.Pop("a")
+		if !exist {
+			t.Fatalf("pop job %s not exist", want)
+		}
+		if job.Payload().ID != want || job.Attempts() != 1 {
+			t.Fatalf("pop job %s attempts %d, want job %s attempts 1", job.Payload().ID, job.Attempts(), want)
+		}
+	}
+
+	// 执行中的任务不再被取出
+	if _, exist := d.Pop("a"); exist {
+		t.Fatal("pop reserved job, want none")
+	}
+	if _, _, reserved, _ := d.Depth("a"); reserved != 2 {
+		t.Fatalf("reserved = %d, want 2", reserved)
+	}
+}
+
+func TestDatabaseQueueRelease(t *testing.T) {
+	d := newTestDatabaseQueue(t)
+	pushTestPayload(t, d, "a", "1")
+
+	job, _ := d.Pop("a")
+	if err := job.Release(0); err != nil {
+		t.Fatal(err)
+	}
+	if !job.IsReleased() {
+		t.Fatal("job not marked released")
+	}
+
+	again, exist := d.Pop("a")
+	if !exist || again.Payload().ID != "1" || again.Attempts() != 2 {
+		t.Fatalf("pop released job exist %v, want job 1 attempts 2", exist)
+	}
+
+	// 延迟重试的任务到期前不可取出
+	if err := again.Release(60); err != nil {
+		t.Fatal(err)
+	}
+	if _, exist = d.Pop("a"); exist {
+		t.Fatal("pop delayed retry job, want none")
+	}
+	if _, delayed, _, _ := d.Depth("a"); delayed != 1 {
+		t.Fatalf("delayed = %d, want 1", delayed)
+	}
+}
+
+func TestDatabaseQueueDelete(t *testing.T) {
+	d := newTestDatabaseQueue(t)
+	pushTestPayload(t, d, "a", "1")
+
+	job, _ := d.Pop("a")
+	if err := job.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if !job.IsDeleted() {
+		t.Fatal("job not marked deleted")
+	}
+	if size := d.Size("a"); size != 0 {
+		t.Fatalf("size = %d, want 0", size)
+	}
+}
+
+func TestDatabaseQueueReserveExpired(t *testing.T) {
+	d := newTestDatabaseQueue(t)
+	pushTestPayload(t, d, "a", "1")
+
+	job, _ := d.Pop("a")
+
+	// 模拟执行超时：保留时刻已过期
+	if _, err := d.db.Exec(`UPDATE "jobs" SET reserved_until = 0`); err != nil {
+		t.Fatal(err)
+	}
+
+	again, exist := d.Pop("a")
+	if !exist || again.Attempts() != 2 {
+		t.Fatalf("pop expired job exist %v, want job attempts 2", exist)
+	}
+
+	// 原消费者迟到的删除、释放不再生效
+	if err := job.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if err := job.Release(0); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, reserved, _ := d.Depth("a"); reserved != 1 {
+		t.Fatalf("reserved = %d, want job still reserved by the new consumer", reserved)
+	}
+
+	if err := again.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if size := d.Size("a"); size != 0 {
+		t.Fatalf("size = %d, want 0", size)
+	}
+}
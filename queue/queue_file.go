@@ -0,0 +1,298 @@
+package queue
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+// 基于本地文件实现的持久化队列机制：
+// 一、原理
+//    队列结构与memory驱动完全一致（链表、延迟map、保留map），每次状态变更追加写入目录下的日志文件 queue.log 并fsync
+// 二、日志
+//    每行一条JSON记录：put 任务进入链表尾部/链表头部/延迟/保留结构（同一任务以最后一条为准），del 任务删除
+// 三、崩溃恢复
+//    启动时顺序回放日志重建各结构，执行中（保留）的任务重新丢回链表，末尾写入不完整的记录丢弃
+// 四、压缩
+//    日志记录数远大于存活任务数时，将当前状态写入临时文件后原子rename替换日志
+// 五、约定
+//    同一目录同一时刻仅允许一个进程使用；死信、唯一锁、批次进度等仅保存在内存中不持久化
+// ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+
+const (
+	fileJournalName      = "queue.log" // 日志文件名
+	fileCompactThreshold = 10000       // 日志记录数超过该值且超过存活任务数4倍时压缩
+)
+
+// FileConfig 文件驱动连接配置，New 方法 conn 参数亦可直接传目录路径字符串
+type FileConfig struct {
+	Dir    string // 日志文件存储目录，不存在时自动创建
+	NoSync bool   // 每次写入后不fsync：吞吐更高，但操作系统崩溃时可能丢失最近写入的记录
+}
+
+// fileJournalRecord 日志记录
+type fileJournalRecord struct {
+	Op      string   `json:"op"`                // put、del
+	Queue   string   `json:"queue"`             // 队列名
+	ID      string   `json:"id,omitempty"`      // del：任务ID
+	State   uint8    `json:"state,omitempty"`   // put：任务所处结构
+	TimeAt  int64    `json:"time_at,omitempty"` // put：延迟任务执行时刻、保留任务超时时刻
+	Payload *Payload `json:"payload,omitempty"` // put：任务payload
+}
+
+// fileQueue 基于本地文件实现的持久化队列
+// implement QueueIFace
+type fileQueue struct {
+	*memoryQueue          // 队列结构复用memory驱动
+	dir          string   // 日志文件存储目录
+	noSync       bool     // 写入后不fsync
+	file         *os.File // 日志文件句柄
+	records      int64    // 日志当前记录数
+}
+
+// newFileQueue 实例化文件驱动
+func newFileQueue() *fileQueue {
+	return &fileQueue{memoryQueue: &memoryQueue{lock: sync.Mutex{}}}
+}
+
+// put 记录任务进入指定结构，调用方持有memoryQueue的锁
+func (f *fileQueue) put(queue string, state uint8, item *itemValue) error {
+	payload := item.Payload
+	return f.write(fileJournalRecord{Op: "put", Queue: queue, State: state, TimeAt: item.TimeAt, Payload: &payload})
+}
+
+// del 记录任务删除，调用方持有memoryQueue的锁
+func (f *fileQueue) del(queue, id string) error {
+	if err := f.write(fileJournalRecord{Op: "del", Queue: queue, ID: id}); err != nil {
+		return err
+	}
+	return f.compactIfNeeded()
+}
+
+// write 追加写入一条日志记录
+func (f *fileQueue) write(record fileJournalRecord) error {
+	if f.file == nil {
+		return errors.New("null pointer connection instance")
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err = f.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	f.records++
+
+	if f.noSync {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+// compactIfNeeded 日志记录数远大于存活任务数时压缩日志
+func (f *fileQueue) compactIfNeeded() error {
+	if f.records <= fileCompactThreshold || f.records <= 4*f.live() {
+		return nil
+	}
+	return f.compact()
+}
+
+// live 当前存活任务数
+func (f *fileQueue) live() (count int64) {
+	for queue := range f.list {
+		count += int64(f.list[queue].Len() + len(f.delayed[queue]) + len(f.reserved[queue]))
+	}
+	return count
+}
+
+// compact 将当前状态写入临时文件后原子替换日志文件
+func (f *fileQueue) compact() error {
+	path := filepath.Join(f.dir, fileJournalName)
+	tmp, err := os.OpenFile(path+".tmp", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	var records int64
+	writer := bufio.NewWriter(tmp)
+	write := func(record fileJournalRecord) error {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		records++
+		_, err = writer.Write(append(line, '\n'))
+		return err
+	}
+
+	// 链表保持顺序在前，延迟、保留任务在后
+	err = func() error {
+		for queue, items := range f.list {
+			for e := items.Front(); e != nil; e = e.Next() {
+				item := e.Value.(*itemValue)
+				if err := write(fileJournalRecord{Op: "put", Queue: queue, State: journalList, Payload: &item.Payload}); err != nil {
+					return err
+				}
+			}
+		}
+		for state, structure := range map[uint8]map[string]map[string]*itemValue{journalDelayed: f.delayed, journalReserved: f.reserved} {
+			for queue, items := range structure {
+				for _, item := range items {
+					if err := write(fileJournalRecord{Op: "put", Queue: queue, State: state, TimeAt: item.TimeAt, Payload: &item.Payload}); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		return tmp.Sync()
+	}()
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path + ".tmp")
+		return err
+	}
+
+	if err = os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+	if f.file != nil {
+		_ = f.file.Close()
+	}
+	if f.file, err = os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644); err != nil {
+		return err
+	}
+	f.records = records
+
+	return nil
+}
+
+// replay 回放日志重建队列结构
+func (f *fileQueue) replay() error {
+	file, err := os.Open(filepath.Join(f.dir, fileJournalName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// step1、顺序回放：同一任务以最后一条记录为准，seq 记录写入的先后顺序
+	type replayed struct {
+		seq    int64
+		record fileJournalRecord
+	}
+	jobs := make(map[string]*replayed)
+	var seq int64
+
+	reader := bufio.NewReader(file)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			var record fileJournalRecord
+			if json.Unmarshal(line, &record) == nil {
+				seq++
+				switch record.Op {
+				case "put":
+					if record.Payload == nil {
+						continue
+					}
+					jobs[record.Queue+"\x00"+record.Payload.ID] = &replayed{seq: seq, record: record}
+				case "del":
+					delete(jobs, record.Queue+"\x00"+record.ID)
+				}
+			}
+		}
+		// 末尾不完整的记录为崩溃时写入中断，直接丢弃
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+
+	// step2、按写入顺序重建结构：依次放入链表尾部或头部即还原链表顺序，执行中的任务重新丢回链表
+	ordered := make([]*replayed, 0, len(jobs))
+	for _, job := range jobs {
+		ordered = append(ordered, job)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].seq < ordered[j].seq
+	})
+	for _, job := range ordered {
+		record := job.record
+		f.lazyInit(record.Queue)
+		switch record.State {
+		case journalDelayed:
+			f.delayed[record.Queue][record.Payload.ID] = &itemValue{Payload: *record.Payload, TimeAt: record.TimeAt}
+		case journalFront:
+			f.list[record.Queue].PushFront(&itemValue{Payload: *record.Payload, TimeAt: 0})
+		default:
+			f.list[record.Queue].PushBack(&itemValue{Payload: *record.Payload, TimeAt: 0})
+		}
+	}
+
+	return nil
+}
+
+// SetConnection
+// 设置文件队列的连接器：日志文件存储目录字符串 或 FileConfig、*FileConfig
+//   - 回放已有日志恢复任务，然后压缩日志
+func (f *fileQueue) SetConnection(connection interface{}) (err error) {
+	var config FileConfig
+	switch conn := connection.(type) {
+	case string:
+		config = FileConfig{Dir: conn}
+	case FileConfig:
+		config = conn
+	case *FileConfig:
+		config = *conn
+	default:
+		return fmt.Errorf("unsupported file queue connection: %T", connection)
+	}
+	if config.Dir == "" {
+		return errors.New("file queue directory is empty")
+	}
+	if err = os.MkdirAll(config.Dir, 0755); err != nil {
+		return err
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.dir = config.Dir
+	f.noSync = config.NoSync
+	if err = f.replay(); err != nil {
+		return err
+	}
+	if err = f.compact(); err != nil {
+		return err
+	}
+	f.journal = f
+
+	return nil
+}
+
+// GetConnection
+// 获取文件队列的连接器：日志文件存储目录（interface）使用前需显式转换
+func (f *fileQueue) GetConnection() (connection interface{}, err error) {
+	if f.dir == "" {
+		return nil, errors.New("null pointer connection instance")
+	}
+
+	return f.dir, nil
+}
@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// newTestFileQueue 基于临时目录的文件驱动
+func newTestFileQueue(t *testing.T, dir string) *fileQueue {
+	t.Helper()
+
+	f := newFileQueue()
+	if err := f.SetConnection(FileConfig{Dir: dir, NoSync: true}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = f.file.Close()
+	})
+	return f
+}
+
+func pushTestJob(t *testing.T, q QueueIFace, queue, id string) {
+	t.Helper()
+
+	payload, _ := json.Marshal(Payload{Name: queue, ID: id, MaxTries: 3, Timeout: 60})
+	if err := q.Push(queue, payload); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileQueueReplayHandoffFront(t *testing.T) {
+	dir := t.TempDir()
+	f := newTestFileQueue(t, dir)
+	pushTestJob(t, f, "a", "1")
+	pushTestJob(t, f, "a", "2")
+	pushTestJob(t, f, "a", "3")
+
+	job, _ := f.Pop("a")
+	if err := job.(HandoffJobIFace).Handoff(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重启后交还的任务仍位于链表头部
+	restarted := newTestFileQueue(t, dir)
+	for _, want := range []string{"1", "2", "3"} {
+		job, exist := restarted.Pop("a")
+		if !exist || job.Payload().ID != want {
+			t.Fatalf("pop after restart exist %v, want job %s", exist, want)
+		}
+		if job.Attempts() != 1 {
+			t.Fatalf("job %s attempts = %d, want 1", want, job.Attempts())
+		}
+	}
+}
+
+// failingJournal 写入总是失败的状态变更日志
+type failingJournal struct{}
+
+func (failingJournal) put(queue string, state uint8, item *itemValue) error {
+	return errors.New("journal failed")
+}
+
+func (failingJournal) del(queue, id string) error {
+	return errors.New("journal failed")
+}
+
+func TestMemoryQueuePopJournalFailed(t *testing.T) {
+	m := &memoryQueue{}
+	pushTestJob(t, m, "a", "1")
+
+	// 日志记录失败时pop失败，任务仍留在链表
+	m.journal = failingJournal{}
+	if _, exist := m.Pop("a"); exist {
+		t.Fatal("pop succeeded with journal failure")
+	}
+	if pending, _, reserved, _ := m.Depth("a"); pending != 1 || reserved != 0 {
+		t.Fatalf("pending %d reserved %d, want job kept pending", pending, reserved)
+	}
+
+	m.journal = nil
+	if job, exist := m.Pop("a"); !exist || job.Payload().ID != "1" {
+		t.Fatalf("pop exist %v, want job 1", exist)
+	}
+}
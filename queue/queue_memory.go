@@ -2,6 +2,8 @@ package queue
 
 import (
 	"container/list"
+	"context"
+	"sort"
 	"sync"
 	"time"
 )
@@ -24,34 +26,190 @@ type memoryQueue struct {
 	list     map[string]*list.List            // 原生链表模拟queue队列
 	delayed  map[string]map[string]*itemValue // 使用map模拟延迟队列
 	reserved map[string]map[string]*itemValue // 使用map模拟延迟队列
+	dead     map[string]map[string]*DeadJob   // 使用map模拟死信存储
+	unique   map[string]*uniqueLock           // 使用map模拟唯一任务锁
+	batches  map[string]*batchRecord          // 使用map模拟批次进度记录
+	statuses map[string]*statusRecord         // 使用map模拟任务状态记录
+	sweepAt  time.Time                        // 最近一次清理过期任务状态记录的时刻
+	notify   chan struct{}                    // 任务就绪通知信号
+	journal  memoryJournal                    // 状态变更日志，file驱动据此持久化，memory驱动为nil
+	clock    Clock                            // 时钟，nil时为系统时钟
 	lock     sync.Mutex
 }
 
+// 状态变更日志中任务所处的结构
+const (
+	journalList     uint8 = 1 // 待执行链表
+	journalDelayed  uint8 = 2 // 延迟map
+	journalReserved uint8 = 3 // 保留map
+	journalFront    uint8 = 4 // 待执行链表头部
+)
+
+// memoryJournal memory队列状态变更日志：任务进入某个结构或被删除时记录，调用方持有锁
+type memoryJournal interface {
+	put(queue string, state uint8, item *itemValue) error // 任务进入指定结构
+	del(queue, id string) error                           // 任务删除
+}
+
+// journalPut 记录任务进入指定结构，调用方需持有锁
+func (m *memoryQueue) journalPut(queue string, state uint8, item *itemValue) error {
+	if m.journal == nil {
+		return nil
+	}
+	return m.journal.put(queue, state, item)
+}
+
+// journalDel 记录任务删除，调用方需持有锁
+func (m *memoryQueue) journalDel(queue, id string) error {
+	if m.journal == nil {
+		return nil
+	}
+	return m.journal.del(queue, id)
+}
+
+// statusRecord 任务状态记录
+type statusRecord struct {
+	fields   map[string]string // 字段名 => 字段值
+	expireAt time.Time         // 记录过期时刻
+}
+
+// batchRecord 批次进度记录
+type batchRecord struct {
+	progress  BatchProgress     // 批次进度
+	callbacks map[string][]byte // 批次回调任务payload
+	expireAt  time.Time         // 记录过期时刻
+}
+
+// uniqueLock 唯一任务锁
+type uniqueLock struct {
+	id       string    // 持有锁的任务ID
+	expireAt time.Time // 锁兜底过期时刻
+}
+
 func (m *memoryQueue) Size(queue string) (size int64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	m.lazyInit(queue)
 
 	return int64(m.list[queue].Len() + len(m.delayed[queue]) + len(m.reserved[queue]))
 }
 
+// Depth 获取队列深度：待执行、延迟中、执行中的任务数
+func (m *memoryQueue) Depth(queue string) (pending, delayed, reserved int64, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.lazyInit(queue)
+
+	return int64(m.list[queue].Len()), int64(len(m.delayed[queue])), int64(len(m.reserved[queue])), nil
+}
+
 func (m *memoryQueue) Push(queue string, payload interface{}) (err error) {
 	var originPayload Payload
 	if err = m.unmarshalPayload(payload.([]byte), &originPayload); err != nil {
 		return err
 	}
 
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	m.lazyInit(queue)
 
 	item := &itemValue{
 		Payload: originPayload,
 		TimeAt:  0,
 	}
+	if err = m.journalPut(queue, journalList, item); err != nil {
+		return err
+	}
 	m.list[queue].PushBack(item)
+	m.wakeup()
 
 	return nil
 }
 
+// PushMany 批量投递任务到队列：单次持有锁写入所有任务
+func (m *memoryQueue) PushMany(queue string, payloads [][]byte) (errs []error) {
+	return m.putMany(queue, journalList, 0, payloads)
+}
+
+// LaterMany 批量投递延迟任务：单次持有锁写入所有任务
+func (m *memoryQueue) LaterMany(queue string, durationTo time.Duration, payloads [][]byte) (errs []error) {
+	return m.putMany(queue, journalDelayed, m.now().Add(durationTo).Unix(), payloads)
+}
+
+// putMany 单次持有锁将多条任务写入待执行链表或延迟map
+func (m *memoryQueue) putMany(queue string, state uint8, timeAt int64, payloads [][]byte) (errs []error) {
+	errs = make([]error, len(payloads))
+	items := make([]*itemValue, len(payloads))
+	for idx, payload := range payloads {
+		var originPayload Payload
+		if errs[idx] = m.unmarshalPayload(payload, &originPayload); errs[idx] == nil {
+			items[idx] = &itemValue{Payload: originPayload, TimeAt: timeAt}
+		}
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.lazyInit(queue)
+	for idx, item := range items {
+		if item == nil {
+			continue
+		}
+		if errs[idx] = m.journalPut(queue, state, item); errs[idx] != nil {
+			continue
+		}
+		if state == journalDelayed {
+			m.delayed[queue][item.Payload.ID] = item
+		} else {
+			m.list[queue].PushBack(item)
+		}
+	}
+	m.wakeup()
+
+	return errs
+}
+
+// PushUnique 唯一锁空闲时投递一条任务到队列
+func (m *memoryQueue) PushUnique(queue string, key string, ttl time.Duration, id string, payload interface{}) (pushed bool, err error) {
+	var originPayload Payload
+	if err = m.unmarshalPayload(payload.([]byte), &originPayload); err != nil {
+		return false, err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.lazyInit(queue)
+
+	name := m.uniqueName(queue, key)
+	if lock, exist := m.unique[name]; exist && m.now().Before(lock.expireAt) {
+		return false, nil
+	}
+	item := &itemValue{Payload: originPayload, TimeAt: 0}
+	if err = m.journalPut(queue, journalList, item); err != nil {
+		return false, err
+	}
+	m.unique[name] = &uniqueLock{id: id, expireAt: m.now().Add(ttl)}
+
+	m.list[queue].PushBack(item)
+	m.wakeup()
+
+	return true, nil
+}
+
+// releaseUnique 释放仍由指定任务持有的唯一锁，调用方需持有锁
+func (m *memoryQueue) releaseUnique(queue, key, id string) {
+	name := m.uniqueName(queue, key)
+	if lock, exist := m.unique[name]; exist && lock.id == id {
+		delete(m.unique, name)
+	}
+}
+
 func (m *memoryQueue) Later(queue string, durationTo time.Duration, payload interface{}) (err error) {
-	return m.LaterAt(queue, time.Now().Add(durationTo), payload)
+	return m.LaterAt(queue, m.now().Add(durationTo), payload)
 }
 
 func (m *memoryQueue) LaterAt(queue string, timeAt time.Time, payload interface{}) (err error) {
@@ -60,6 +218,9 @@ func (m *memoryQueue) LaterAt(queue string, timeAt time.Time, payload interface{
 		return err
 	}
 
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	m.lazyInit(queue)
 
 	item := &itemValue{
@@ -67,8 +228,13 @@ func (m *memoryQueue) LaterAt(queue string, timeAt time.Time, payload interface{
 		TimeAt:  timeAt.Unix(),
 	}
 
+	if err = m.journalPut(queue, journalDelayed, item); err != nil {
+		return err
+	}
+
 	// set to map
 	m.delayed[queue][originPayload.ID] = item
+	m.wakeup()
 
 	return nil
 }
@@ -77,7 +243,7 @@ func (m *memoryQueue) Pop(queue string) (job JobIFace, exist bool) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
-	now := time.Now()
+	now := m.now()
 	// step1、调度延迟任务
 	if m.delayed[queue] != nil {
 		m.lazyInit(queue) // 延迟队列已初始化，但是保留队列可能未初始化
@@ -90,6 +256,11 @@ func (m *memoryQueue) Pop(queue string) (job JobIFace, exist bool) {
 					TimeAt:  0,
 				}
 
+				// 先记录日志：记录失败时任务留在原结构，下次pop再尝试
+				if m.journalPut(queue, journalList, itemV) != nil {
+					continue
+				}
+
 				// delete from delay map
 				delete(m.delayed[queue], id)
 
@@ -111,6 +282,11 @@ func (m *memoryQueue) Pop(queue string) (job JobIFace, exist bool) {
 					TimeAt:  0,
 				}
 
+				// 先记录日志：记录失败时任务留在原结构，下次pop再尝试
+				if m.journalPut(queue, journalList, itemV) != nil {
+					continue
+				}
+
 				// delete from reserved map
 				delete(m.reserved[queue], id)
 
@@ -131,9 +307,6 @@ func (m *memoryQueue) Pop(queue string) (job JobIFace, exist bool) {
 		return nil, false
 	}
 
-	// 清理值
-	m.list[queue].Remove(itemV)
-
 	// 转义Payload初始化job
 	node := *itemV.Value.(*itemValue)
 	payload := node.Payload // value copy
@@ -145,13 +318,20 @@ func (m *memoryQueue) Pop(queue string) (job JobIFace, exist bool) {
 		node.Payload.PopTime = now.Unix()
 	}
 
+	// 先记录日志：记录失败时任务留在list，本次pop视为无任务
+	if m.journalPut(queue, journalReserved, &node) != nil {
+		return nil, false
+	}
+
+	// 清理值
+	m.list[queue].Remove(itemV)
+
 	// set reserved
 	m.reserved[queue][node.Payload.ID] = &node
 
 	// 转换值构造job
 	return &JobMemory{
-		reserved:    m.reserved,
-		delayed:     m.delayed,
+		memory:      m,
 		reservedJob: node.Payload,
 		jobProperty: jobProperty{
 			handler:    m,
@@ -169,6 +349,399 @@ func (m *memoryQueue) Pop(queue string) (job JobIFace, exist bool) {
 	}, true
 }
 
+// Jobs 分页浏览指定状态的任务：延迟中、执行中的任务按时刻升序
+func (m *memoryQueue) Jobs(queue, state string, offset, limit int64) (jobs []*JobInfo, total int64, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.lazyInit(queue)
+
+	all := make([]*JobInfo, 0)
+	switch state {
+	case JobStatePending:
+		for e := m.list[queue].Front(); e != nil; e = e.Next() {
+			all = append(all, &JobInfo{State: state, Payload: e.Value.(*itemValue).Payload})
+		}
+	case JobStateDelayed, JobStateReserved:
+		items := m.delayed[queue]
+		if state == JobStateReserved {
+			items = m.reserved[queue]
+		}
+		for _, item := range items {
+			all = append(all, &JobInfo{State: state, TimeAt: item.TimeAt, Payload: item.Payload})
+		}
+		sort.Slice(all, func(i, j int) bool {
+			if all[i].TimeAt != all[j].TimeAt {
+				return all[i].TimeAt < all[j].TimeAt
+			}
+			return all[i].Payload.ID < all[j].Payload.ID
+		})
+	default:
+		return nil, 0, ErrJobStateInvalid
+	}
+
+	total = int64(len(all))
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= total || limit <= 0 {
+		return []*JobInfo{}, total, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+
+	return all[offset:end], total, nil
+}
+
+// DeleteJob 按ID删除指定状态的任务
+func (m *memoryQueue) DeleteJob(queue, state, id string) (err error) {
+	return m.takeJob(queue, state, id, false)
+}
+
+// RequeueJob 将延迟中或执行中的任务立即放回待执行队列
+func (m *memoryQueue) RequeueJob(queue, state, id string) (err error) {
+	if state != JobStateDelayed && state != JobStateReserved {
+		return ErrJobStateInvalid
+	}
+	return m.takeJob(queue, state, id, true)
+}
+
+// takeJob 从指定状态中取出任务：删除 或 放回待执行队列
+func (m *memoryQueue) takeJob(queue, state, id string, requeue bool) (err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.lazyInit(queue)
+
+	var payload Payload
+	switch state {
+	case JobStatePending:
+		var found *list.Element
+		for e := m.list[queue].Front(); e != nil; e = e.Next() {
+			if e.Value.(*itemValue).Payload.ID == id {
+				found = e
+				break
+			}
+		}
+		if found == nil {
+			return ErrJobNotFound
+		}
+		payload = found.Value.(*itemValue).Payload
+		m.list[queue].Remove(found)
+	case JobStateDelayed, JobStateReserved:
+		items := m.delayed[queue]
+		if state == JobStateReserved {
+			items = m.reserved[queue]
+		}
+		item, exist := items[id]
+		if !exist {
+			return ErrJobNotFound
+		}
+		payload = item.Payload
+		delete(items, id)
+	default:
+		return ErrJobStateInvalid
+	}
+
+	if requeue {
+		item := &itemValue{Payload: payload, TimeAt: 0}
+		m.list[queue].PushBack(item)
+		m.wakeup()
+		return m.journalPut(queue, journalList, item)
+	}
+
+	if payload.UniqueKey != "" {
+		m.releaseUnique(queue, payload.UniqueKey, id)
+	}
+	return m.journalDel(queue, id)
+}
+
+// Bury 保存一条死信任务
+func (m *memoryQueue) Bury(queue string, job *DeadJob) (err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.lazyInit(queue)
+	m.dead[queue][job.Payload.ID] = job
+
+	return nil
+}
+
+// DeadJobs 按失败时刻倒序分页获取死信任务
+func (m *memoryQueue) DeadJobs(queue string, offset, limit int64) (jobs []*DeadJob, total int64, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.lazyInit(queue)
+
+	all := make([]*DeadJob, 0, len(m.dead[queue]))
+	for _, job := range m.dead[queue] {
+		all = append(all, job)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].FailedAt > all[j].FailedAt
+	})
+
+	total = int64(len(all))
+	if offset < 0 || offset >= total || limit <= 0 {
+		return make([]*DeadJob, 0), total, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+
+	return all[offset:end], total, nil
+}
+
+// RequeueDead 将一条死信任务重新投递到队列
+func (m *memoryQueue) RequeueDead(queue string, id string) (err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.lazyInit(queue)
+
+	job, exist := m.dead[queue][id]
+	if !exist {
+		return ErrDeadJobNotFound
+	}
+
+	item := &itemValue{Payload: job.requeuePayload(), TimeAt: 0}
+	if err = m.journalPut(queue, journalList, item); err != nil {
+		return err
+	}
+	delete(m.dead[queue], id)
+	m.list[queue].PushBack(item)
+	m.wakeup()
+
+	return nil
+}
+
+// RequeueAllDead 将队列下所有死信任务重新投递到队列
+func (m *memoryQueue) RequeueAllDead(queue string) (count int64, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.lazyInit(queue)
+
+	for id, job := range m.dead[queue] {
+		item := &itemValue{Payload: job.requeuePayload(), TimeAt: 0}
+		if err = m.journalPut(queue, journalList, item); err != nil {
+			break
+		}
+		delete(m.dead[queue], id)
+		m.list[queue].PushBack(item)
+		count++
+	}
+	m.wakeup()
+
+	return count, err
+}
+
+// PurgeDead 清理指定时刻之前失败的死信任务
+func (m *memoryQueue) PurgeDead(queue string, before time.Time) (count int64, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.lazyInit(queue)
+
+	for id, job := range m.dead[queue] {
+		if job.FailedAt <= before.Unix() {
+			delete(m.dead[queue], id)
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// CreateBatch 创建批次进度记录
+func (m *memoryQueue) CreateBatch(progress *BatchProgress, callbacks map[string][]byte, ttl time.Duration) (err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.batches == nil {
+		m.batches = make(map[string]*batchRecord)
+	}
+	m.batches[progress.ID] = &batchRecord{
+		progress:  *progress,
+		callbacks: callbacks,
+		expireAt:  m.now().Add(ttl),
+	}
+
+	return nil
+}
+
+// FinishBatchJob 批次内一个任务结束，更新进度并返回需投递的回调任务payload
+func (m *memoryQueue) FinishBatchJob(id string, succeeded bool) (callbacks [][]byte, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	record, err := m.batchRecord(id)
+	if err != nil {
+		return nil, err
+	}
+	if record.progress.Pending <= 0 {
+		return nil, nil
+	}
+
+	fire := func(kind string) {
+		if callback, exist := record.callbacks[kind]; exist {
+			callbacks = append(callbacks, callback)
+		}
+	}
+
+	record.progress.Pending--
+	if succeeded {
+		record.progress.Processed++
+	} else {
+		record.progress.Failed++
+		if record.progress.Failed == 1 {
+			fire(batchCatch)
+		}
+	}
+
+	if record.progress.Pending <= 0 {
+		record.progress.FinishedAt = m.now().Unix()
+		if record.progress.Failed == 0 {
+			fire(batchThen)
+		}
+		fire(batchFinally)
+	}
+
+	return callbacks, nil
+}
+
+// SetStatus 更新任务状态记录的指定字段，每分钟顺带清理一次过期记录
+func (m *memoryQueue) SetStatus(id string, fields map[string]string, ttl time.Duration) (err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := m.now()
+	if m.statuses == nil {
+		m.statuses = make(map[string]*statusRecord)
+	}
+	if now.Sub(m.sweepAt) > time.Minute {
+		for key, record := range m.statuses {
+			if now.After(record.expireAt) {
+				delete(m.statuses, key)
+			}
+		}
+		m.sweepAt = now
+	}
+
+	record, exist := m.statuses[id]
+	if !exist || now.After(record.expireAt) {
+		record = &statusRecord{fields: make(map[string]string, len(fields))}
+		m.statuses[id] = record
+	}
+	for field, value := range fields {
+		record.fields[field] = value
+	}
+	record.expireAt = now.Add(ttl)
+
+	return nil
+}
+
+// GetStatus 获取任务状态记录的所有字段
+func (m *memoryQueue) GetStatus(id string) (fields map[string]string, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	record, exist := m.statuses[id]
+	if !exist || m.now().After(record.expireAt) {
+		return nil, ErrJobNotFound
+	}
+
+	fields = make(map[string]string, len(record.fields))
+	for field, value := range record.fields {
+		fields[field] = value
+	}
+	return fields, nil
+}
+
+// BatchProgress 查询批次进度
+func (m *memoryQueue) BatchProgress(id string) (progress *BatchProgress, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	record, err := m.batchRecord(id)
+	if err != nil {
+		return nil, err
+	}
+	current := record.progress
+	return &current, nil
+}
+
+// batchRecord 获取未过期的批次进度记录，调用方需持有锁
+func (m *memoryQueue) batchRecord(id string) (*batchRecord, error) {
+	record, exist := m.batches[id]
+	if !exist {
+		return nil, ErrBatchNotFound
+	}
+	if m.now().After(record.expireAt) {
+		delete(m.batches, id)
+		return nil, ErrBatchNotFound
+	}
+	return record, nil
+}
+
+// Wait 阻塞等待任务就绪通知
+//   - 延迟或保留任务的最早到期时刻早于最长等待时长时，等待至到期时刻
+func (m *memoryQueue) Wait(ctx context.Context, queues []string, maxWait time.Duration) {
+	m.lock.Lock()
+	if m.notify == nil {
+		m.notify = make(chan struct{}, 1)
+	}
+	notify := m.notify
+	earliest := int64(-1)
+	for _, queue := range queues {
+		for _, items := range []map[string]*itemValue{m.delayed[queue], m.reserved[queue]} {
+			for _, item := range items {
+				if earliest < 0 || item.TimeAt < earliest {
+					earliest = item.TimeAt
+				}
+			}
+		}
+	}
+	m.lock.Unlock()
+
+	wait := maxWait
+	if earliest >= 0 {
+		if until := time.Unix(earliest, 0).Sub(m.now()); until < wait {
+			wait = until
+		}
+	}
+	if wait <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-notify:
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// StopWait 内存通知无需释放资源
+func (m *memoryQueue) StopWait() {}
+
+// wakeup 发送任务就绪通知，调用方需持有锁
+func (m *memoryQueue) wakeup() {
+	if m.notify == nil {
+		m.notify = make(chan struct{}, 1)
+	}
+	select {
+	case m.notify <- struct{}{}:
+	default:
+	}
+}
+
 func (m *memoryQueue) SetConnection(connection interface{}) (err error) {
 	// no code
 	return nil
@@ -190,6 +763,12 @@ func (m *memoryQueue) lazyInit(queue string) {
 	if m.delayed == nil {
 		m.delayed = make(map[string]map[string]*itemValue)
 	}
+	if m.dead == nil {
+		m.dead = make(map[string]map[string]*DeadJob)
+	}
+	if m.unique == nil {
+		m.unique = make(map[string]*uniqueLock)
+	}
 
 	// lazy init map item
 	if _, exist := m.list[queue]; !exist {
@@ -201,4 +780,7 @@ func (m *memoryQueue) lazyInit(queue string) {
 	if _, exist := m.delayed[queue]; !exist {
 		m.delayed[queue] = make(map[string]*itemValue)
 	}
+	if _, exist := m.dead[queue]; !exist {
+		m.dead[queue] = make(map[string]*DeadJob)
+	}
 }
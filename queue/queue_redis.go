@@ -2,8 +2,12 @@ package queue
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/go-redis/redis/v8"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -28,12 +32,18 @@ import (
 //    step5、执行超时or执行失败，立即走重试逻辑（从保留有序集合删除，丢到延迟有序集合）；执行成功任务结束（从保留有序集合删除）
 // ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
 
+// takeJobPageSize 按ID查找任务时单次读取的任务数
+const takeJobPageSize = 100
+
 // redisQueue 基于Redis实现的队列
 // implement QueueIFace
 type redisQueue struct {
 	queueBasic               // 队列基础可公用方法
 	connection *redis.Client // connection redis客户端实例
 	luaScripts *luaScripts   // redis lua脚本生成器
+	notifyOnce sync.Once     // 任务就绪通知频道仅订阅一次
+	notifyChan chan struct{} // 任务就绪通知信号
+	notifySub  *redis.PubSub // 任务就绪通知频道订阅
 }
 
 // Size 获取队列长度
@@ -47,10 +57,94 @@ func (r *redisQueue) Size(queue string) (size int64) {
 	return result
 }
 
+// Depth 获取队列深度：待执行、延迟中、执行中的任务数
+func (r *redisQueue) Depth(queue string) (pending, delayed, reserved int64, err error) {
+	ctx := context.Background()
+	var lLen, delayedCard, reservedCard *redis.IntCmd
+	_, err = r.connection.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		lLen = pipe.LLen(ctx, r.name(queue))
+		delayedCard = pipe.ZCard(ctx, r.delayedName(queue))
+		reservedCard = pipe.ZCard(ctx, r.reservedName(queue))
+		return nil
+	})
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return lLen.Val(), delayedCard.Val(), reservedCard.Val(), nil
+}
+
 // Push 投递一条任务到队列
 func (r *redisQueue) Push(queue string, payload interface{}) (err error) {
 	ctx := context.Background()
-	return r.connection.RPush(ctx, queue, payload).Err()
+	_, err = r.connection.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, r.name(queue), payload)
+		pipe.Publish(ctx, r.notifyName(), queue)
+		return nil
+	})
+	return err
+}
+
+// PushMany 批量投递任务到队列：按 bulkPipelineSize 分批pipeline写入，每条任务一个RPUSH命令以获取逐条结果
+func (r *redisQueue) PushMany(queue string, payloads [][]byte) (errs []error) {
+	return r.pipelineMany(queue, payloads, func(ctx context.Context, pipe redis.Pipeliner, payload []byte) {
+		pipe.RPush(ctx, r.name(queue), payload)
+	})
+}
+
+// LaterMany 批量投递延迟任务：按 bulkPipelineSize 分批pipeline写入
+func (r *redisQueue) LaterMany(queue string, durationTo time.Duration, payloads [][]byte) (errs []error) {
+	score := float64(time.Now().Add(durationTo).Unix())
+	return r.pipelineMany(queue, payloads, func(ctx context.Context, pipe redis.Pipeliner, payload []byte) {
+		pipe.ZAdd(ctx, r.delayedName(queue), &redis.Z{Score: score, Member: payload})
+	})
+}
+
+// pipelineMany 分批pipeline执行每条任务的写入命令，全部写入后发布一次任务就绪通知
+func (r *redisQueue) pipelineMany(queue string, payloads [][]byte, write func(ctx context.Context, pipe redis.Pipeliner, payload []byte)) (errs []error) {
+	errs = make([]error, len(payloads))
+	ctx := context.Background()
+
+	for start := 0; start < len(payloads); start += bulkPipelineSize {
+		end := min(start+bulkPipelineSize, len(payloads))
+		cmds, err := r.connection.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, payload := range payloads[start:end] {
+				write(ctx, pipe, payload)
+			}
+			return nil
+		})
+
+		// 命令数与任务数一致时取逐条结果，否则整批视为失败
+		for i := start; i < end; i++ {
+			if len(cmds) == end-start {
+				errs[i] = cmds[i-start].Err()
+			} else {
+				errs[i] = err
+			}
+		}
+	}
+
+	r.notify(ctx, queue)
+	return errs
+}
+
+// PushUnique 唯一锁空闲时投递一条任务到队列，检查与投递在lua脚本中原子完成
+func (r *redisQueue) PushUnique(queue string, key string, ttl time.Duration, id string, payload interface{}) (pushed bool, err error) {
+	ctx := context.Background()
+	ret, err := r.luaScripts.PushUnique().Run(
+		ctx,
+		r.connection,
+		[]string{r.name(queue), r.uniqueName(queue, key)},
+		id,
+		ttl.Milliseconds(),
+		payload,
+	).Int64()
+	if err != nil {
+		return false, err
+	}
+	if ret == 1 {
+		r.notify(ctx, queue)
+	}
+	return ret == 1, nil
 }
 
 // Later 延迟指定时长后执行的延迟任务
@@ -65,7 +159,78 @@ func (r *redisQueue) LaterAt(queue string, timeAt time.Time, payload interface{}
 		Member: payload,
 	}
 	ctx := context.Background()
-	return r.connection.ZAdd(ctx, r.delayedName(queue), &item).Err()
+	_, err = r.connection.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, r.delayedName(queue), &item)
+		pipe.Publish(ctx, r.notifyName(), queue)
+		return nil
+	})
+	return err
+}
+
+// Wait 阻塞等待任务就绪通知
+//   - 首次调用时订阅通知频道并立即返回，避免订阅之前投递的任务被遗漏
+//   - 延迟或保留任务的最早到期时刻早于最长等待时长时，等待至到期时刻
+func (r *redisQueue) Wait(ctx context.Context, queues []string, maxWait time.Duration) {
+	if r.subscribeNotify() {
+		return
+	}
+
+	wait := maxWait
+	if len(queues) > 0 {
+		keys := make([]string, 0, 2*len(queues))
+		for _, queue := range queues {
+			keys = append(keys, r.delayedName(queue), r.reservedName(queue))
+		}
+		earliest, err := r.luaScripts.NextAvailable().Run(ctx, r.connection, keys).Int64()
+		if err == nil && earliest >= 0 {
+			if until := time.Until(time.Unix(earliest, 0)); until < wait {
+				wait = until
+			}
+		}
+	}
+	if wait <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-r.notifyChan:
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// subscribeNotify 订阅任务就绪通知频道，返回本次调用是否为首次订阅
+func (r *redisQueue) subscribeNotify() (subscribed bool) {
+	r.notifyOnce.Do(func() {
+		r.notifyChan = make(chan struct{}, 1)
+		r.notifySub = r.connection.Subscribe(context.Background(), r.notifyName())
+		ch := r.notifySub.Channel()
+		go func() {
+			for range ch {
+				select {
+				case r.notifyChan <- struct{}{}:
+				default:
+				}
+			}
+		}()
+		subscribed = true
+	})
+	return subscribed
+}
+
+// StopWait 关闭任务就绪通知频道订阅，订阅连接关闭后转发协程随之退出
+func (r *redisQueue) StopWait() {
+	if r.notifySub != nil {
+		_ = r.notifySub.Close()
+	}
+}
+
+// notify 发布任务就绪通知，通知失败仅影响消费延迟故忽略错误
+func (r *redisQueue) notify(ctx context.Context, queue string) {
+	r.connection.Publish(ctx, r.notifyName(), queue)
 }
 
 // Pop 取出弹出一条待执行的任务
@@ -129,6 +294,7 @@ func (r *redisQueue) Pop(queue string) (job JobIFace, exist bool) {
 	// set job timeoutAt
 	// rJob.TimeoutAt = now.Add(time.Duration(reserved.Timeout) * time.Second).Unix()
 	return &JobRedis{
+		basic:      r.queueBasic,
 		redis:      r.connection,
 		lock:       sync.Mutex{},
 		luaScripts: r.luaScripts,
@@ -148,6 +314,519 @@ func (r *redisQueue) Pop(queue string) (job JobIFace, exist bool) {
 	}, true
 }
 
+// Jobs 分页浏览指定状态的任务：延迟中、执行中的任务按时刻升序
+func (r *redisQueue) Jobs(queue, state string, offset, limit int64) (jobs []*JobInfo, total int64, err error) {
+	ctx := context.Background()
+	jobs = make([]*JobInfo, 0)
+	if offset < 0 {
+		offset = 0
+	}
+
+	switch state {
+	case JobStatePending:
+		if total, err = r.connection.LLen(ctx, r.name(queue)).Result(); err != nil || limit <= 0 {
+			return jobs, total, err
+		}
+		members, err := r.connection.LRange(ctx, r.name(queue), offset, offset+limit-1).Result()
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, member := range members {
+			var payload Payload
+			if r.unmarshalPayload([]byte(member), &payload) == nil {
+				jobs = append(jobs, &JobInfo{State: state, Payload: payload})
+			}
+		}
+	case JobStateDelayed, JobStateReserved:
+		key := r.delayedName(queue)
+		if state == JobStateReserved {
+			key = r.reservedName(queue)
+		}
+		if total, err = r.connection.ZCard(ctx, key).Result(); err != nil || limit <= 0 {
+			return jobs, total, err
+		}
+		members, err := r.connection.ZRangeWithScores(ctx, key, offset, offset+limit-1).Result()
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, member := range members {
+			var payload Payload
+			if r.unmarshalPayload([]byte(IFaceToString(member.Member)), &payload) == nil {
+				jobs = append(jobs, &JobInfo{State: state, TimeAt: int64(member.Score), Payload: payload})
+			}
+		}
+	default:
+		return nil, 0, ErrJobStateInvalid
+	}
+
+	return jobs, total, nil
+}
+
+// DeleteJob 按ID删除指定状态的任务，唯一任务同时释放唯一锁
+func (r *redisQueue) DeleteJob(queue, state, id string) (err error) {
+	return r.takeJob(queue, state, id, false)
+}
+
+// RequeueJob 将延迟中或执行中的任务立即放回待执行队列
+func (r *redisQueue) RequeueJob(queue, state, id string) (err error) {
+	if state != JobStateDelayed && state != JobStateReserved {
+		return ErrJobStateInvalid
+	}
+	if err = r.takeJob(queue, state, id, true); err != nil {
+		return err
+	}
+	r.notify(context.Background(), queue)
+	return nil
+}
+
+// takeJob 按ID查找并取出任务：删除 或 放回待执行队列
+//   - 分页查找避免单次阻塞redis，耗时与任务数成正比，仅适用于管理接口
+//   - 查找到的任务由lua脚本原子的取出，期间已被消费或移动的任务视为不存在
+//   - 删除唯一任务时在同一lua脚本中释放其持有的唯一锁
+//   - 执行中的任务被取出后，执行进程后续的释放、删除操作不再生效
+func (r *redisQueue) takeJob(queue, state, id string, requeue bool) (err error) {
+	var key, kind string
+	switch state {
+	case JobStatePending:
+		key, kind = r.name(queue), "list"
+	case JobStateDelayed:
+		key, kind = r.delayedName(queue), "zset"
+	case JobStateReserved:
+		key, kind = r.reservedName(queue), "zset"
+	default:
+		return ErrJobStateInvalid
+	}
+
+	ctx := context.Background()
+	member, err := r.findJob(ctx, key, kind, id)
+	if err != nil {
+		return err
+	}
+
+	keys := []string{key, r.name(queue)}
+	args := []interface{}{kind, member, "requeue"}
+	if !requeue {
+		args[2] = "delete"
+		var payload Payload
+		if r.unmarshalPayload([]byte(member), &payload) == nil && payload.UniqueKey != "" {
+			keys = append(keys, r.uniqueName(queue, payload.UniqueKey))
+			args = append(args, id)
+		}
+	}
+
+	err = r.luaScripts.TakeJob().Run(ctx, r.connection, keys, args...).Err()
+	if err == redis.Nil {
+		return ErrJobNotFound
+	}
+	return err
+}
+
+// findJob 分页查找列表或有序集合中指定ID的任务：列表按下标分页，有序集合使用ZSCAN游标
+func (r *redisQueue) findJob(ctx context.Context, key, kind, id string) (member string, err error) {
+	match := func(member string) bool {
+		var payload Payload
+		return strings.Contains(member, id) && r.unmarshalPayload([]byte(member), &payload) == nil && payload.ID == id
+	}
+
+	if kind == "zset" {
+		iter := r.connection.ZScan(ctx, key, 0, "", takeJobPageSize).Iterator()
+		for iter.Next(ctx) {
+			// ZSCAN 依次返回成员和分值
+			if member = iter.Val(); match(member) {
+				return member, nil
+			}
+			iter.Next(ctx)
+		}
+		if err = iter.Err(); err != nil {
+			return "", err
+		}
+		return "", ErrJobNotFound
+	}
+
+	for start := int64(0); ; start += takeJobPageSize {
+		members, err := r.connection.LRange(ctx, key, start, start+takeJobPageSize-1).Result()
+		if err != nil {
+			return "", err
+		}
+		for _, member = range members {
+			if match(member) {
+				return member, nil
+			}
+		}
+		if len(members) < takeJobPageSize {
+			return "", ErrJobNotFound
+		}
+	}
+}
+
+// LastTick 获取定时投递最近认领的tick
+func (r *redisQueue) LastTick(name string) (tick int64, err error) {
+	tick, err = r.connection.HGet(context.Background(), r.scheduleName(), name).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return tick, err
+}
+
+// ClaimTick 认领定时投递的tick，集群中仅一个节点认领成功
+func (r *redisQueue) ClaimTick(name string, tick int64) (claimed bool, err error) {
+	ret, err := r.luaScripts.ClaimTick().Run(
+		context.Background(),
+		r.connection,
+		[]string{r.scheduleName()},
+		name,
+		tick,
+	).Int()
+	return ret == 1, err
+}
+
+// Bury 保存一条死信任务：zSet按失败时刻索引，hash存储死信任务
+func (r *redisQueue) Bury(queue string, job *DeadJob) (err error) {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	return r.luaScripts.Bury().Run(
+		ctx,
+		r.connection,
+		[]string{r.deadName(queue), r.deadJobsName(queue)},
+		job.FailedAt,
+		job.Payload.ID,
+		body,
+	).Err()
+}
+
+// DeadJobs 按失败时刻倒序分页获取死信任务
+func (r *redisQueue) DeadJobs(queue string, offset, limit int64) (jobs []*DeadJob, total int64, err error) {
+	ctx := context.Background()
+	jobs = make([]*DeadJob, 0)
+
+	if total, err = r.connection.ZCard(ctx, r.deadName(queue)).Result(); err != nil || total == 0 {
+		return jobs, total, err
+	}
+
+	ids, err := r.connection.ZRevRange(ctx, r.deadName(queue), offset, offset+limit-1).Result()
+	if err != nil || len(ids) == 0 {
+		return jobs, total, err
+	}
+
+	values, err := r.connection.HMGet(ctx, r.deadJobsName(queue), ids...).Result()
+	if err != nil {
+		return jobs, total, err
+	}
+
+	for _, value := range values {
+		raw, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var job DeadJob
+		if json.Unmarshal([]byte(raw), &job) == nil {
+			jobs = append(jobs, &job)
+		}
+	}
+
+	return jobs, total, nil
+}
+
+// RequeueDead 将一条死信任务重新投递到队列
+func (r *redisQueue) RequeueDead(queue string, id string) (err error) {
+	ctx := context.Background()
+	raw, err := r.connection.HGet(ctx, r.deadJobsName(queue), id).Result()
+	if err == redis.Nil {
+		return ErrDeadJobNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var job DeadJob
+	if err = json.Unmarshal([]byte(raw), &job); err != nil {
+		return err
+	}
+	payload, err := json.Marshal(job.requeuePayload())
+	if err != nil {
+		return err
+	}
+
+	ret, err := r.luaScripts.RequeueDead().Run(
+		ctx,
+		r.connection,
+		[]string{r.deadName(queue), r.deadJobsName(queue), r.name(queue)},
+		id,
+		payload,
+	).Int64()
+	if err != nil {
+		return err
+	}
+	if ret == 0 {
+		return ErrDeadJobNotFound
+	}
+	r.notify(ctx, queue)
+	return nil
+}
+
+// RequeueAllDead 将队列下所有死信任务重新投递到队列
+func (r *redisQueue) RequeueAllDead(queue string) (count int64, err error) {
+	ctx := context.Background()
+	for {
+		ids, err := r.connection.ZRange(ctx, r.deadName(queue), 0, 99).Result()
+		if err != nil || len(ids) == 0 {
+			return count, err
+		}
+
+		for _, id := range ids {
+			if err = r.RequeueDead(queue, id); err == ErrDeadJobNotFound {
+				// 索引存在而任务体不存在的脏数据直接清理
+				r.connection.ZRem(ctx, r.deadName(queue), id)
+				continue
+			}
+			if err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+}
+
+// PurgeDead 清理指定时刻之前失败的死信任务
+func (r *redisQueue) PurgeDead(queue string, before time.Time) (count int64, err error) {
+	ctx := context.Background()
+	return r.luaScripts.PurgeDead().Run(
+		ctx,
+		r.connection,
+		[]string{r.deadName(queue), r.deadJobsName(queue)},
+		before.Unix(),
+	).Int64()
+}
+
+// AcquireSlot 尝试获取一个执行名额：lua脚本中原子的检查并发数、令牌桶，多进程共享同一限额
+func (r *redisQueue) AcquireSlot(queue, slot string, limit TaskLimit, ttl time.Duration) (ok bool, err error) {
+	now := time.Now()
+
+	ctx := context.Background()
+	ret, err := r.luaScripts.AcquireSlot().Run(
+		ctx,
+		r.connection,
+		[]string{r.limiterSlotsName(queue), r.limiterBucketName(queue)},
+		now.UnixMilli(),
+		slot,
+		now.Add(ttl).UnixMilli(),
+		limit.MaxConcurrency,
+		strconv.FormatFloat(limit.Rate, 'f', -1, 64),
+		limit.Burst,
+	).Int64()
+	if err != nil {
+		return false, err
+	}
+	return ret == 1, nil
+}
+
+// ReleaseSlot 释放执行名额
+func (r *redisQueue) ReleaseSlot(queue, slot string, limit TaskLimit, refund bool) (err error) {
+	flag := 0
+	if refund {
+		flag = 1
+	}
+
+	ctx := context.Background()
+	return r.luaScripts.ReleaseSlot().Run(
+		ctx,
+		r.connection,
+		[]string{r.limiterSlotsName(queue), r.limiterBucketName(queue)},
+		slot,
+		flag,
+		limit.Burst,
+	).Err()
+}
+
+// ExtendSlot 延长执行名额的有效期：执行中任务心跳时调用
+func (r *redisQueue) ExtendSlot(queue, slot string, ttl time.Duration) (err error) {
+	ctx := context.Background()
+	return r.luaScripts.ExtendScore().Run(
+		ctx,
+		r.connection,
+		[]string{r.limiterSlotsName(queue)},
+		slot,
+		time.Now().Add(ttl).UnixMilli(),
+	).Err()
+}
+
+// MigrateNamespace 将旧命名空间下的key逐个 RENAMENX 至当前命名空间
+//   - 队列list、延迟、保留、死信、限流相关key及唯一锁按队列迁移，定时投递、任务状态、批次等共用key全部迁移
+//   - 目标key已存在时跳过，所有冲突合并为一个错误返回
+//   - Redis Cluster 下新旧key通常位于不同hash slot，RENAMENX 返回 CROSSSLOT 错误，集群部署不适用，需停机后自行迁移数据
+func (r *redisQueue) MigrateNamespace(from string, queues []string) (moved int64, err error) {
+	ctx := context.Background()
+	old := &queueBasic{namespace: from}
+	oldPrefix := old.key("")
+
+	var keys []string
+	for _, queue := range queues {
+		keys = append(keys,
+			old.name(queue),
+			old.reservedName(queue),
+			old.delayedName(queue),
+			old.deadName(queue),
+			old.deadJobsName(queue),
+			old.limiterSlotsName(queue),
+			old.limiterBucketName(queue),
+		)
+		if keys, err = r.scanKeys(ctx, old.uniqueName(queue, "*"), keys); err != nil {
+			return moved, err
+		}
+	}
+	keys = append(keys, old.scheduleName())
+	for _, pattern := range []string{old.statusName("*"), old.batchName("*")} {
+		if keys, err = r.scanKeys(ctx, pattern, keys); err != nil {
+			return moved, err
+		}
+	}
+
+	var conflicts []error
+	for _, key := range keys {
+		exist, err := r.connection.Exists(ctx, key).Result()
+		if err != nil {
+			return moved, err
+		}
+		if exist == 0 {
+			continue
+		}
+
+		target := r.key(strings.TrimPrefix(key, oldPrefix))
+		renamed, err := r.connection.RenameNX(ctx, key, target).Result()
+		if err != nil {
+			return moved, err
+		}
+		if !renamed {
+			conflicts = append(conflicts, fmt.Errorf("%w: %s", ErrNamespaceConflict, target))
+			continue
+		}
+		moved++
+	}
+
+	return moved, errors.Join(conflicts...)
+}
+
+// scanKeys SCAN匹配pattern的所有key追加至keys
+func (r *redisQueue) scanKeys(ctx context.Context, pattern string, keys []string) ([]string, error) {
+	iter := r.connection.Scan(ctx, 0, pattern, 1000).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
+// CreateBatch 创建批次进度记录：hash存储计数器及回调任务payload
+func (r *redisQueue) CreateBatch(progress *BatchProgress, callbacks map[string][]byte, ttl time.Duration) (err error) {
+	values := map[string]interface{}{
+		"ID":         progress.ID,
+		"Total":      progress.Total,
+		"Pending":    progress.Pending,
+		"Processed":  progress.Processed,
+		"Failed":     progress.Failed,
+		"CreatedAt":  progress.CreatedAt,
+		"FinishedAt": progress.FinishedAt,
+	}
+	for kind, callback := range callbacks {
+		values[kind] = callback
+	}
+
+	ctx := context.Background()
+	_, err = r.connection.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, r.batchName(progress.ID), values)
+		pipe.Expire(ctx, r.batchName(progress.ID), ttl)
+		return nil
+	})
+	return err
+}
+
+// FinishBatchJob 批次内一个任务结束，lua脚本中原子的更新进度并返回需投递的回调任务payload
+func (r *redisQueue) FinishBatchJob(id string, succeeded bool) (callbacks [][]byte, err error) {
+	flag := 0
+	if succeeded {
+		flag = 1
+	}
+
+	ctx := context.Background()
+	ret, err := r.luaScripts.FinishBatch().Run(
+		ctx,
+		r.connection,
+		[]string{r.batchName(id)},
+		flag,
+		time.Now().Unix(),
+	).StringSlice()
+	if err == redis.Nil {
+		return nil, ErrBatchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, callback := range ret {
+		callbacks = append(callbacks, []byte(callback))
+	}
+	return callbacks, nil
+}
+
+// SetStatus 更新任务状态记录的指定字段
+func (r *redisQueue) SetStatus(id string, fields map[string]string, ttl time.Duration) (err error) {
+	ctx := context.Background()
+	values := make([]interface{}, 0, 2*len(fields))
+	for field, value := range fields {
+		values = append(values, field, value)
+	}
+
+	_, err = r.connection.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, r.statusName(id), values...)
+		pipe.Expire(ctx, r.statusName(id), ttl)
+		return nil
+	})
+	return err
+}
+
+// GetStatus 获取任务状态记录的所有字段
+func (r *redisQueue) GetStatus(id string) (fields map[string]string, err error) {
+	fields, err = r.connection.HGetAll(context.Background(), r.statusName(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, ErrJobNotFound
+	}
+	return fields, nil
+}
+
+// BatchProgress 查询批次进度
+func (r *redisQueue) BatchProgress(id string) (progress *BatchProgress, err error) {
+	ctx := context.Background()
+	values, err := r.connection.HMGet(ctx, r.batchName(id),
+		"Total", "Pending", "Processed", "Failed", "CreatedAt", "FinishedAt").Result()
+	if err != nil {
+		return nil, err
+	}
+	if values[0] == nil {
+		return nil, ErrBatchNotFound
+	}
+
+	toInt64 := func(value interface{}) int64 {
+		i64, _ := strconv.ParseInt(IFaceToString(value), 10, 64)
+		return i64
+	}
+
+	return &BatchProgress{
+		ID:         id,
+		Total:      toInt64(values[0]),
+		Pending:    toInt64(values[1]),
+		Processed:  toInt64(values[2]),
+		Failed:     toInt64(values[3]),
+		CreatedAt:  toInt64(values[4]),
+		FinishedAt: toInt64(values[5]),
+	}, nil
+}
+
 // SetConnection
 // 设置redis队列的连接器：redis client句柄指针
 func (r *redisQueue) SetConnection(connection interface{}) (err error) {
@@ -0,0 +1,241 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestRedisQueue 基于miniredis的redis驱动
+func newTestRedisQueue(t *testing.T) (*redisQueue, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	r := &redisQueue{luaScripts: &luaScripts{}}
+	if err := r.SetConnection(client); err != nil {
+		t.Fatal(err)
+	}
+	return r, mr
+}
+
+// waitSubscribed 等待通知频道订阅生效，避免订阅之前发布的通知丢失
+func waitSubscribed(t *testing.T, r *redisQueue, mr *miniredis.Miniredis, want int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for mr.PubSubNumSub(r.notifyName())[r.notifyName()] != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("notify channel subscribers != %d", want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// waitReturn 执行Wait并返回耗时
+func waitReturn(r *redisQueue, queues []string, maxWait time.Duration) time.Duration {
+	startAt := time.Now()
+	r.Wait(context.Background(), queues, maxWait)
+	return time.Since(startAt)
+}
+
+func TestRedisQueueWaitWakeup(t *testing.T) {
+	r, mr := newTestRedisQueue(t)
+
+	// 首次调用订阅后立即返回
+	if elapsed := waitReturn(r, []string{"a"}, 5*time.Second); elapsed > time.Second {
+		t.Fatalf("first Wait took %s, want return right after subscribing", elapsed)
+	}
+	waitSubscribed(t, r, mr, 1)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = r.Push("a", []byte(`{}`))
+	}()
+	if elapsed := waitReturn(r, []string{"a"}, 5*time.Second); elapsed > 2*time.Second {
+		t.Fatalf("Wait took %s, want wake up by push notify", elapsed)
+	}
+
+	// 无通知时等待至最长等待时长
+	if elapsed := waitReturn(r, []string{"a"}, 100*time.Millisecond); elapsed < 100*time.Millisecond {
+		t.Fatalf("Wait took %s, want wait for maxWait without notify", elapsed)
+	}
+}
+
+func TestRedisQueueWaitDelayedDeadline(t *testing.T) {
+	r, mr := newTestRedisQueue(t)
+
+	// 订阅之前投递延迟任务，其通知不会唤醒后续的Wait
+	if err := r.Later("a", time.Second, []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	waitReturn(r, []string{"a"}, 5*time.Second)
+	waitSubscribed(t, r, mr, 1)
+
+	if elapsed := waitReturn(r, []string{"a"}, 10*time.Second); elapsed > 3*time.Second {
+		t.Fatalf("Wait took %s, want return at the delayed job deadline", elapsed)
+	}
+
+	// 未等待的队列的延迟任务不影响等待时长
+	if elapsed := waitReturn(r, []string{"b"}, 100*time.Millisecond); elapsed < 100*time.Millisecond {
+		t.Fatalf("Wait took %s, want ignore delayed jobs of other queues", elapsed)
+	}
+}
+
+func TestRedisQueueStopWait(t *testing.T) {
+	r, mr := newTestRedisQueue(t)
+
+	// 未订阅时无需释放
+	r.StopWait()
+
+	waitReturn(r, []string{"a"}, time.Second)
+	waitSubscribed(t, r, mr, 1)
+
+	r.StopWait()
+	waitSubscribed(t, r, mr, 0)
+}
+
+func TestRedisQueueTakeJob(t *testing.T) {
+	r, _ := newTestRedisQueue(t)
+
+	// 超过单页的任务数，目标任务位于第二页
+	for i := 0; i < takeJobPageSize+10; i++ {
+		payload, _ := json.Marshal(Payload{Name: "a", ID: "p" + strconv.Itoa(i), MaxTries: 1, Timeout: 60})
+		if err := r.Push("a", payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < takeJobPageSize+10; i++ {
+		payload, _ := json.Marshal(Payload{Name: "a", ID: "d" + strconv.Itoa(i), MaxTries: 1, Timeout: 60})
+		if err := r.Later("a", time.Hour, payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	target := "p" + strconv.Itoa(takeJobPageSize+5)
+	if err := r.DeleteJob("a", JobStatePending, target); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DeleteJob("a", JobStatePending, target); err != ErrJobNotFound {
+		t.Fatalf("delete again err = %v, want ErrJobNotFound", err)
+	}
+
+	target = "d" + strconv.Itoa(takeJobPageSize+5)
+	if err := r.RequeueJob("a", JobStateDelayed, target); err != nil {
+		t.Fatal(err)
+	}
+	pending, delayed, _, err := r.Depth("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending != takeJobPageSize+10 || delayed != takeJobPageSize+9 {
+		t.Fatalf("pending %d delayed %d, want %d and %d", pending, delayed, takeJobPageSize+10, takeJobPageSize+9)
+	}
+
+	if err = r.DeleteJob("a", JobStateReserved, target); err != ErrJobNotFound {
+		t.Fatalf("delete reserved err = %v, want ErrJobNotFound", err)
+	}
+}
+
+// seedNamespace 在指定命名空间下写入队列a的待执行、延迟、唯一锁及任务状态数据
+func seedNamespace(t *testing.T, client *redis.Client, namespace string) {
+	t.Helper()
+
+	r := &redisQueue{luaScripts: &luaScripts{}}
+	r.setNamespace(namespace)
+	_ = r.SetConnection(client)
+
+	payload, _ := json.Marshal(Payload{Name: "a", ID: "1", MaxTries: 1, Timeout: 60})
+	if err := r.Push("a", payload); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Later("a", time.Hour, payload); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.PushUnique("a", "k", time.Hour, "2", payload); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetStatus("1", map[string]string{"state": JobStatusPending}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRedisQueueMigrateNamespace(t *testing.T) {
+	cases := []struct {
+		from, to string
+		want     []string
+	}{
+		{from: "", to: "app", want: []string{"app:a", "app:a:delayed", "app:a:unique:k", "app:status:1"}},
+		{from: "app", to: "", want: []string{"a", "a:delayed", "a:unique:k", "status:1"}},
+		{from: "old", to: "app", want: []string{"app:a", "app:a:delayed", "app:a:unique:k", "app:status:1"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.from+"=>"+c.to, func(t *testing.T) {
+			r, mr := newTestRedisQueue(t)
+			r.setNamespace(c.to)
+			seedNamespace(t, r.connection, c.from)
+
+			moved, err := r.MigrateNamespace(c.from, []string{"a"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if moved != int64(len(c.want)) {
+				t.Fatalf("moved = %d, want %d", moved, len(c.want))
+			}
+			keys := mr.Keys()
+			if !reflect.DeepEqual(keys, c.want) {
+				t.Fatalf("keys = %v, want %v", keys, c.want)
+			}
+			if pending, delayed, _, _ := r.Depth("a"); pending != 2 || delayed != 1 {
+				t.Fatalf("pending %d delayed %d after migrate, want 2 and 1", pending, delayed)
+			}
+		})
+	}
+}
+
+func TestRedisQueueMigrateNamespaceConflict(t *testing.T) {
+	r, mr := newTestRedisQueue(t)
+	r.setNamespace("app")
+	seedNamespace(t, r.connection, "")
+
+	// 目标命名空间下已存在待执行队列
+	if err := mr.Set("app:status:1", "exist"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mr.Lpush("app:a", "exist"); err != nil {
+		t.Fatal(err)
+	}
+
+	moved, err := r.MigrateNamespace("", []string{"a"})
+	if !errors.Is(err, ErrNamespaceConflict) {
+		t.Fatalf("err = %v, want ErrNamespaceConflict", err)
+	}
+	if !strings.Contains(err.Error(), "app:a") || !strings.Contains(err.Error(), "app:status:1") {
+		t.Fatalf("err = %v, want both conflicted keys", err)
+	}
+	if moved != 2 {
+		t.Fatalf("moved = %d, want the non-conflicted keys moved", moved)
+	}
+
+	// 冲突的key保留在旧命名空间
+	for _, key := range []string{"a", "status:1"} {
+		if !mr.Exists(key) {
+			t.Fatalf("key %s removed, want kept on conflict", key)
+		}
+	}
+	if list, _ := mr.List("app:a"); !reflect.DeepEqual(list, []string{"exist"}) {
+		t.Fatalf("target list = %v, want untouched", list)
+	}
+}
@@ -0,0 +1,190 @@
+package queuetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jjonline/share-mod-lib/queue"
+)
+
+// stepTask 记录收到的参数的任务类：fail 为true时执行失败，否则以 output 作为输出
+type stepTask struct {
+	queue.DefaultTaskSetting
+	name   string
+	output interface{}
+	fail   bool
+	got    []string
+}
+
+func (t *stepTask) Name() string   { return t.name }
+func (t *stepTask) Remark() string { return t.name }
+func (t *stepTask) Execute(ctx context.Context, job *queue.RawBody) error {
+	t.got = append(t.got, job.String())
+	if t.fail {
+		return errors.New("boom")
+	}
+	if t.output != nil {
+		job.SetOutput(t.output)
+	}
+	return nil
+}
+
+// queues 执行结果或投递记录的队列名称序列
+func queues(outcomes []Outcome) (names []string) {
+	for _, outcome := range outcomes {
+		names = append(names, outcome.Queue)
+	}
+	return
+}
+
+func equalNames(got []string, want ...string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestChainSucceeded(t *testing.T) {
+	export := &stepTask{name: "export", output: map[string]string{"file": "a.csv"}}
+	upload := &stepTask{name: "upload"}
+	notify := &stepTask{name: "notify"}
+	catch := &stepTask{name: "catch"}
+	finally := &stepTask{name: "finally"}
+	h := New(t, export, upload, notify, catch, finally)
+
+	err := h.Queue.Chain(export, 42).
+		Then(upload, nil).
+		Then(notify, "done").
+		Catch(catch).
+		Finally(finally).
+		Dispatch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 依次执行，全部成功后执行finally
+	if names := queues(h.Drain()); !equalNames(names, "export", "upload", "notify", "finally") {
+		t.Fatalf("executed = %v, want export upload notify finally", names)
+	}
+	// 未指定参数的任务以前一个任务的输出作为参数
+	if upload.got[0] != `{"file":"a.csv"}` {
+		t.Fatalf("upload payload = %s, want export output", upload.got[0])
+	}
+	if notify.got[0] != "done" {
+		t.Fatalf("notify payload = %s, want done", notify.got[0])
+	}
+	h.AssertNotDispatched(catch)
+
+	var failure queue.ChainFailure
+	if err = h.Dispatched(finally)[0].Body().Unmarshal(&failure); err != nil || failure.Error != "" {
+		t.Fatalf("finally failure = %+v err %v, want empty", failure, err)
+	}
+}
+
+func TestChainFailed(t *testing.T) {
+	export := &stepTask{name: "export"}
+	upload := &stepTask{name: "upload", fail: true}
+	notify := &stepTask{name: "notify"}
+	catch := &stepTask{name: "catch"}
+	finally := &stepTask{name: "finally"}
+	h := New(t, export, upload, notify, catch, finally)
+
+	err := h.Queue.Chain(export, 42).Then(upload, nil).Then(notify, nil).Catch(catch).Finally(finally).Dispatch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 任一任务最终失败：后续任务不再执行，先catch后finally
+	outcomes := h.Drain()
+	if names := queues(outcomes); !equalNames(names, "export", "upload", "catch", "finally") {
+		t.Fatalf("executed = %v, want export upload catch finally", names)
+	}
+	if !outcomes[1].Failed {
+		t.Fatalf("upload outcome = %+v, want failed", outcomes[1])
+	}
+	h.AssertNotDispatched(notify)
+
+	for _, task := range []*stepTask{catch, finally} {
+		var failure queue.ChainFailure
+		if err = h.Dispatched(task)[0].Body().Unmarshal(&failure); err != nil {
+			t.Fatal(err)
+		}
+		if failure.Name != "upload" || failure.ID != outcomes[1].ID || failure.Error != "boom" {
+			t.Fatalf("%s failure = %+v, want upload boom", task.name, failure)
+		}
+	}
+}
+
+func TestBatchCallbacks(t *testing.T) {
+	cases := []struct {
+		name      string
+		fails     []bool
+		executed  []string // 回调任务执行顺序
+		processed int64
+		failed    int64
+	}{
+		{name: "all succeeded", fails: []bool{false, false}, executed: []string{"then", "finally"}, processed: 2},
+		{name: "one failed", fails: []bool{false, true, false}, executed: []string{"catch", "finally"}, processed: 2, failed: 1},
+		{name: "all failed", fails: []bool{true, true}, executed: []string{"catch", "finally"}, failed: 2},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ok := &stepTask{name: "ok"}
+			bad := &stepTask{name: "bad", fail: true}
+			then := &stepTask{name: "then"}
+			catch := &stepTask{name: "catch"}
+			finally := &stepTask{name: "finally"}
+			h := New(t, ok, bad, then, catch, finally)
+
+			batch := h.Queue.Batch()
+			for _, fail := range c.fails {
+				if fail {
+					batch.Add(bad, nil)
+				} else {
+					batch.Add(ok, nil)
+				}
+			}
+			id, err := batch.Then(then).Catch(catch).Finally(finally).Dispatch()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 回调任务在批次内任务全部执行后执行，参数为批次ID
+			executed := queues(h.Drain())
+			if last := executed[len(executed)-1]; last != finally.name {
+				t.Fatalf("last executed = %s, want finally after all batch jobs", last)
+			}
+			var names []string
+			for _, name := range executed {
+				if name != ok.name && name != bad.name {
+					names = append(names, name)
+				}
+			}
+			if !equalNames(names, c.executed...) {
+				t.Fatalf("callbacks = %v, want %v", names, c.executed)
+			}
+			for _, task := range []*stepTask{then, catch, finally} {
+				for _, got := range task.got {
+					if got != id {
+						t.Fatalf("%s payload = %s, want batch id %s", task.name, got, id)
+					}
+				}
+			}
+
+			progress, err := h.Queue.BatchProgress(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !progress.Finished() || progress.Processed != c.processed || progress.Failed != c.failed {
+				t.Fatalf("progress = %+v, want processed %d failed %d", progress, c.processed, c.failed)
+			}
+		})
+	}
+}
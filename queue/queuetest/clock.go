@@ -0,0 +1,39 @@
+package queuetest
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeClock 模拟时钟：时刻仅在 Advance、Set 时变化，并发安全
+type FakeClock struct {
+	lock sync.Mutex
+	now  time.Time
+}
+
+// NewFakeClock 实例化模拟时钟
+//   - now 初始时刻
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now 当前时刻
+func (c *FakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+// Advance 时钟前进指定时长
+func (c *FakeClock) Advance(duration time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(duration)
+}
+
+// Set 设置时钟的当前时刻
+func (c *FakeClock) Set(now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = now
+}
@@ -0,0 +1,230 @@
+package queuetest
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jjonline/share-mod-lib/queue"
+)
+
+// *************************************************
+// 队列任务单元测试脚手架：无需启动worker、无需sleep
+// 1、基于memory驱动，注入模拟时钟 FakeClock，延迟任务、重试任务按模拟时钟到期
+// 2、Drain 在当前协程中同步执行所有已就绪的任务并返回执行结果
+// 3、Advance 推进模拟时钟后 Drain，确定性地触发延迟任务、重试任务
+// 4、记录所有投递的任务，AssertDispatched 等断言任务的投递情况
+// *************************************************
+
+// Epoch 模拟时钟的默认初始时刻
+var Epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Dispatched 已投递的任务
+type Dispatched struct {
+	Queue   string         // 队列名称，即任务类 Name() 返回值
+	ID      string         // 任务ID
+	Payload *queue.Payload // 任务payload
+	At      time.Time      // 投递时刻（模拟时钟）
+}
+
+// Body 任务参数
+func (d *Dispatched) Body() *queue.RawBody {
+	return d.Payload.RawBody()
+}
+
+// Outcome 任务单次执行结果
+type Outcome struct {
+	Queue    string // 队列名称，即任务类 Name() 返回值
+	ID       string // 任务ID
+	Attempts int64  // 本次为第几次尝试执行
+	Err      error  // 执行失败的错误，执行成功为nil
+	Failed   bool   // 是否最终失败：超过最大尝试次数不再重试
+}
+
+// Harness 队列任务单元测试脚手架
+type Harness struct {
+	Queue      *queue.Queue // memory驱动的队列，可直接调用其设置方法
+	Clock      *FakeClock   // 注入队列的模拟时钟
+	tb         testing.TB
+	lock       sync.Mutex
+	dispatched []*Dispatched
+	outcomes   []*Outcome
+}
+
+// New 实例化测试脚手架并注册任务类，模拟时钟初始时刻为 Epoch
+func New(tb testing.TB, tasks ...queue.TaskIFace) *Harness {
+	tb.Helper()
+
+	h := &Harness{
+		Queue: queue.New(queue.Memory, nil, &testLogger{tb: tb}, 1),
+		Clock: NewFakeClock(Epoch),
+		tb:    tb,
+	}
+	h.Queue.SetClock(h.Clock)
+	h.Queue.OnJobDispatched(h.onDispatched)
+	h.Queue.OnJobDone(h.onDone)
+	h.Queue.OnJobFailed(h.onFailed)
+
+	if err := h.Queue.Bootstrap(tasks); err != nil {
+		tb.Fatalf("queuetest: bootstrap tasks failed: %s", err.Error())
+	}
+	return h
+}
+
+// Drain 在当前协程中同步执行所有已就绪的任务，返回本次执行结果
+//   - 执行过程中投递的任务、立即重试的任务一并执行，延迟任务需 Advance 到期后才会执行
+func (h *Harness) Drain() []Outcome {
+	h.lock.Lock()
+	from := len(h.outcomes)
+	h.lock.Unlock()
+
+	h.Queue.RunReady()
+	return h.outcomesFrom(from)
+}
+
+// Advance 模拟时钟前进指定时长后 Drain，返回本次执行结果
+func (h *Harness) Advance(duration time.Duration) []Outcome {
+	h.Clock.Advance(duration)
+	return h.Drain()
+}
+
+// Outcomes 所有任务执行结果，按执行顺序
+func (h *Harness) Outcomes() []Outcome {
+	return h.outcomesFrom(0)
+}
+
+// Dispatched 指定任务类已投递的任务，按投递顺序；task 为nil时返回所有已投递的任务
+func (h *Harness) Dispatched(task queue.TaskIFace) []*Dispatched {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	var items []*Dispatched
+	for _, item := range h.dispatched {
+		if task == nil || item.Queue == task.Name() {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Reset 清空已记录的投递任务和执行结果，队列中的任务不受影响
+func (h *Harness) Reset() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.dispatched = nil
+	h.outcomes = nil
+}
+
+// AssertDispatched 断言任务类投递过参数为payload的任务
+//   - 参数比较：转换为字符串后相同，或按payload的类型解码后 reflect.DeepEqual
+func (h *Harness) AssertDispatched(task queue.TaskIFace, payload interface{}) {
+	h.tb.Helper()
+
+	for _, item := range h.Dispatched(task) {
+		if payloadEqual(item.Body(), payload) {
+			return
+		}
+	}
+	h.tb.Errorf("queuetest: task %s was not dispatched with payload %s", task.Name(), queue.IFaceToString(payload))
+}
+
+// AssertDispatchedTimes 断言任务类投递的任务数
+func (h *Harness) AssertDispatchedTimes(task queue.TaskIFace, times int) {
+	h.tb.Helper()
+
+	if count := len(h.Dispatched(task)); count != times {
+		h.tb.Errorf("queuetest: task %s was dispatched %d times, expected %d", task.Name(), count, times)
+	}
+}
+
+// AssertNotDispatched 断言任务类未投递过任务
+func (h *Harness) AssertNotDispatched(task queue.TaskIFace) {
+	h.tb.Helper()
+	h.AssertDispatchedTimes(task, 0)
+}
+
+// onDispatched 记录投递的任务
+func (h *Harness) onDispatched(ctx context.Context, event queue.JobEvent) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.dispatched = append(h.dispatched, &Dispatched{
+		Queue:   event.Queue,
+		ID:      event.ID,
+		Payload: event.Payload,
+		At:      h.Clock.Now(),
+	})
+}
+
+// onDone 记录任务单次执行结果
+func (h *Harness) onDone(ctx context.Context, event queue.JobEvent) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.outcomes = append(h.outcomes, &Outcome{
+		Queue:    event.Queue,
+		ID:       event.ID,
+		Attempts: event.Attempts,
+		Err:      event.Err,
+	})
+}
+
+// onFailed 标记任务最终失败：执行前即已超过最大尝试次数的任务无执行结果，补记一条
+func (h *Harness) onFailed(ctx context.Context, event queue.JobEvent) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if last := len(h.outcomes) - 1; last >= 0 && h.outcomes[last].ID == event.ID {
+		h.outcomes[last].Failed = true
+		return
+	}
+	h.outcomes = append(h.outcomes, &Outcome{
+		Queue:    event.Queue,
+		ID:       event.ID,
+		Attempts: event.Attempts,
+		Err:      event.Err,
+		Failed:   true,
+	})
+}
+
+// outcomesFrom 指定位置起的执行结果副本
+func (h *Harness) outcomesFrom(from int) []Outcome {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	outcomes := make([]Outcome, 0, len(h.outcomes)-from)
+	for _, outcome := range h.outcomes[from:] {
+		outcomes = append(outcomes, *outcome)
+	}
+	return outcomes
+}
+
+// payloadEqual 任务参数与期望值是否相同
+func payloadEqual(body *queue.RawBody, expected interface{}) bool {
+	if body.String() == queue.IFaceToString(expected) {
+		return true
+	}
+	if expected == nil {
+		return false
+	}
+
+	actual := reflect.New(reflect.TypeOf(expected))
+	if body.Unmarshal(actual.Interface()) != nil {
+		return false
+	}
+	return reflect.DeepEqual(actual.Elem().Interface(), expected)
+}
+
+// testLogger 队列日志输出到测试日志，仅输出警告和错误
+type testLogger struct {
+	tb testing.TB
+}
+
+func (l *testLogger) Debug(msg string, keyValues ...string) {}
+func (l *testLogger) Info(msg string, keyValues ...string)  {}
+func (l *testLogger) Warn(msg string, keyValues ...string) {
+	l.tb.Logf("queue warn: %s %v", msg, keyValues)
+}
+func (l *testLogger) Error(msg string, keyValues ...string) {
+	l.tb.Logf("queue error: %s %v", msg, keyValues)
+}
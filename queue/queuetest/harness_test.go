@@ -0,0 +1,207 @@
+package queuetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jjonline/share-mod-lib/queue"
+)
+
+type orderParam struct {
+	OrderID int64  `json:"order_id"`
+	Remark  string `json:"remark"`
+}
+
+// notifyTask 执行成功的任务类，执行时投递一个后续任务
+type notifyTask struct {
+	queue.DefaultTaskSetting
+	harness *Harness
+	next    queue.TaskIFace
+}
+
+func (t *notifyTask) Name() string   { return "notify" }
+func (t *notifyTask) Remark() string { return "notify" }
+func (t *notifyTask) Execute(ctx context.Context, job *queue.RawBody) error {
+	if t.next != nil {
+		return t.harness.Queue.Dispatch(t.next, job.String())
+	}
+	return nil
+}
+
+// auditTask 执行成功的任务类
+type auditTask struct {
+	queue.DefaultTaskSetting
+}
+
+func (t *auditTask) Name() string                                          { return "audit" }
+func (t *auditTask) Remark() string                                        { return "audit" }
+func (t *auditTask) Execute(ctx context.Context, job *queue.RawBody) error { return nil }
+
+// flakyTask 前 failures 次执行失败的任务类，失败后间隔10秒重试
+type flakyTask struct {
+	queue.DefaultTaskSetting
+	failures int64
+	tries    int64
+}
+
+func (t *flakyTask) Name() string         { return "flaky" }
+func (t *flakyTask) Remark() string       { return "flaky" }
+func (t *flakyTask) MaxTries() int64      { return t.tries }
+func (t *flakyTask) RetryInterval() int64 { return 10 }
+func (t *flakyTask) Execute(ctx context.Context, job *queue.RawBody) error {
+	if t.failures > 0 {
+		t.failures--
+		return errors.New("flaky")
+	}
+	return nil
+}
+
+// recorderTB 记录断言失败信息，用于测试断言方法本身
+type recorderTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorderTB) Helper() {}
+func (r *recorderTB) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestHarnessDrain(t *testing.T) {
+	audit := &auditTask{}
+	notify := &notifyTask{next: audit}
+	h := New(t, notify, audit)
+	notify.harness = h
+
+	for i := 0; i < 2; i++ {
+		if err := h.Queue.Dispatch(notify, "hello"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 执行过程中投递的任务一并执行
+	outcomes := h.Drain()
+	if len(outcomes) != 4 {
+		t.Fatalf("outcomes = %+v, want 4", outcomes)
+	}
+	for _, outcome := range outcomes {
+		if outcome.Err != nil || outcome.Failed || outcome.Attempts != 1 {
+			t.Fatalf("outcome = %+v, want succeeded at first attempt", outcome)
+		}
+	}
+	h.AssertDispatchedTimes(audit, 2)
+
+	if outcomes = h.Drain(); len(outcomes) != 0 {
+		t.Fatalf("outcomes = %+v, want none", outcomes)
+	}
+}
+
+func TestHarnessAdvanceDelayed(t *testing.T) {
+	audit := &auditTask{}
+	h := New(t, audit)
+
+	if err := h.Queue.Delay(audit, "later", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if outcomes := h.Drain(); len(outcomes) != 0 {
+		t.Fatalf("outcomes = %+v, want delayed job not run", outcomes)
+	}
+	if outcomes := h.Advance(59 * time.Second); len(outcomes) != 0 {
+		t.Fatalf("outcomes = %+v, want delayed job not due", outcomes)
+	}
+	if outcomes := h.Advance(time.Second); len(outcomes) != 1 || outcomes[0].Err != nil {
+		t.Fatalf("outcomes = %+v, want delayed job run once due", outcomes)
+	}
+	if at := h.Dispatched(audit)[0].At; !at.Equal(Epoch) {
+		t.Fatalf("dispatched at = %s, want fake clock time %s", at, Epoch)
+	}
+}
+
+func TestHarnessAdvanceRetry(t *testing.T) {
+	cases := []struct {
+		name   string
+		task   *flakyTask
+		last   int64 // 最后一次执行为第几次尝试
+		failed bool  // 最终是否失败
+	}{
+		{name: "succeeded after retries", task: &flakyTask{failures: 2, tries: 3}, last: 3, failed: false},
+		{name: "failed after max tries", task: &flakyTask{failures: 5, tries: 2}, last: 2, failed: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h := New(t, c.task)
+			if err := h.Queue.Dispatch(c.task, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			outcomes := h.Drain()
+			if len(outcomes) != 1 || outcomes[0].Err == nil || outcomes[0].Failed {
+				t.Fatalf("outcomes = %+v, want first attempt failed and retried", outcomes)
+			}
+
+			for attempt := int64(2); attempt <= c.last; attempt++ {
+				// 重试间隔到期前不执行
+				if outcomes = h.Advance(9 * time.Second); len(outcomes) != 0 {
+					t.Fatalf("attempt %d outcomes = %+v, want retry not due", attempt, outcomes)
+				}
+				outcomes = h.Advance(time.Second)
+				if len(outcomes) != 1 || outcomes[0].Attempts != attempt {
+					t.Fatalf("attempt %d outcomes = %+v, want retry run once due", attempt, outcomes)
+				}
+			}
+
+			last := outcomes[0]
+			if last.Failed != c.failed || (last.Err != nil) != c.failed {
+				t.Fatalf("last outcome = %+v, want failed %v", last, c.failed)
+			}
+			if outcomes = h.Advance(time.Hour); len(outcomes) != 0 {
+				t.Fatalf("outcomes = %+v, want no more attempts", outcomes)
+			}
+		})
+	}
+}
+
+func TestHarnessAssertDispatched(t *testing.T) {
+	audit := &auditTask{}
+	notify := &notifyTask{}
+	h := New(t, audit, notify)
+
+	param := orderParam{OrderID: 1, Remark: "paid"}
+	if err := h.Queue.Dispatch(audit, param); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Queue.Dispatch(audit, 42); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := &recorderTB{TB: t}
+	h.tb = recorder
+
+	h.AssertDispatched(audit, param)
+	h.AssertDispatched(audit, 42)
+	h.AssertDispatched(audit, "42")
+	h.AssertDispatchedTimes(audit, 2)
+	h.AssertNotDispatched(notify)
+	if len(recorder.errors) != 0 {
+		t.Fatalf("assert errors = %v, want none", recorder.errors)
+	}
+
+	h.AssertDispatched(audit, orderParam{OrderID: 2, Remark: "paid"})
+	h.AssertDispatched(notify, param)
+	h.AssertDispatchedTimes(audit, 1)
+	h.AssertNotDispatched(audit)
+	if len(recorder.errors) != 4 {
+		t.Fatalf("assert errors = %v, want 4", recorder.errors)
+	}
+
+	h.Reset()
+	recorder.errors = nil
+	h.AssertNotDispatched(audit)
+	if len(recorder.errors) != 0 {
+		t.Fatalf("assert errors after reset = %v, want none", recorder.errors)
+	}
+}
@@ -0,0 +1,137 @@
+package queue
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// *************************************************
+// 任务失败重试延迟策略
+// 1、任务类默认使用 RetryInterval 返回的固定间隔重试
+// 2、任务类可选实现 TaskRetryPolicyIFace 指定重试策略：固定、线性、指数退避、去相关抖动
+// 3、计算得到的延迟时长记录在 Payload.RetryDelay，随任务一并存储，底层驱动释放任务时据此计算下次可执行时刻
+// *************************************************
+
+// maxRetryDelay 重试延迟时长上限，单位：秒；max<=0即不限制最大延迟时也不超过该值，避免换算为 time.Duration 时溢出
+const maxRetryDelay = int64(math.MaxInt64 / int64(time.Second))
+
+// RetryPolicyIFace 任务失败重试延迟策略契约
+type RetryPolicyIFace interface {
+	// NextDelay 计算下次重试前的延迟时长，单位：秒
+	// @param attempts  已尝试执行的次数，首次执行失败时为1
+	// @param prevDelay 上一次重试的延迟时长，单位：秒；首次重试时为0
+	NextDelay(attempts int64, prevDelay int64) int64
+}
+
+// TaskRetryPolicyIFace 任务类可选实现的重试策略契约
+//   - 实现后任务重试的延迟时长由返回的策略计算，不再使用 RetryInterval
+type TaskRetryPolicyIFace interface {
+	RetryPolicy() RetryPolicyIFace
+}
+
+// fixedRetryPolicy 固定间隔重试
+type fixedRetryPolicy struct {
+	interval int64
+}
+
+// NewFixedRetryPolicy 固定间隔重试策略：每次重试前均延迟interval秒
+func NewFixedRetryPolicy(interval int64) RetryPolicyIFace {
+	return &fixedRetryPolicy{interval: interval}
+}
+
+func (p *fixedRetryPolicy) NextDelay(attempts int64, prevDelay int64) int64 {
+	return clampDelay(p.interval, p.interval)
+}
+
+// linearRetryPolicy 线性递增间隔重试
+type linearRetryPolicy struct {
+	base int64
+	step int64
+	max  int64
+}
+
+// NewLinearRetryPolicy 线性递增间隔重试策略：第n次重试前延迟 base + step*(n-1) 秒，最大不超过max秒，max<=0时不限制
+func NewLinearRetryPolicy(base, step, max int64) RetryPolicyIFace {
+	return &linearRetryPolicy{base: base, step: step, max: uncappedDelay(max)}
+}
+
+func (p *linearRetryPolicy) NextDelay(attempts int64, prevDelay int64) int64 {
+	if attempts < 1 {
+		attempts = 1
+	}
+	if p.step > 0 && attempts-1 > (p.max-p.base)/p.step {
+		return clampDelay(p.max, p.max)
+	}
+	return clampDelay(p.base+p.step*(attempts-1), p.max)
+}
+
+// exponentialRetryPolicy 指数退避重试
+type exponentialRetryPolicy struct {
+	base int64
+	max  int64
+}
+
+// NewExponentialRetryPolicy 指数退避重试策略：第n次重试前延迟 base * 2^(n-1) 秒，最大不超过max秒，max<=0时不限制
+func NewExponentialRetryPolicy(base, max int64) RetryPolicyIFace {
+	return &exponentialRetryPolicy{base: base, max: uncappedDelay(max)}
+}
+
+func (p *exponentialRetryPolicy) NextDelay(attempts int64, prevDelay int64) int64 {
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := float64(p.base) * math.Pow(2, float64(attempts-1))
+	if delay >= float64(p.max) {
+		return clampDelay(p.max, p.max)
+	}
+	return clampDelay(int64(delay), p.max)
+}
+
+// decorrelatedJitterRetryPolicy 去相关抖动重试
+type decorrelatedJitterRetryPolicy struct {
+	base int64
+	max  int64
+}
+
+// NewDecorrelatedJitterRetryPolicy 去相关抖动重试策略：延迟在 [base, 上次延迟*3] 区间内随机取值，最大不超过max秒，max<=0时不限制
+//   - 多个任务同时失败时可将重试时刻打散，避免集中重试再次压垮上游
+func NewDecorrelatedJitterRetryPolicy(base, max int64) RetryPolicyIFace {
+	return &decorrelatedJitterRetryPolicy{base: base, max: uncappedDelay(max)}
+}
+
+func (p *decorrelatedJitterRetryPolicy) NextDelay(attempts int64, prevDelay int64) int64 {
+	if prevDelay < p.base {
+		prevDelay = p.base
+	}
+	upper := prevDelay * 3
+	if upper > p.max || upper < prevDelay {
+		upper = p.max
+	}
+	if upper <= p.base {
+		return clampDelay(p.base, p.max)
+	}
+	return clampDelay(p.base+rand.Int63n(upper-p.base+1), p.max)
+}
+
+// uncappedDelay max<=0表示不限制最大延迟，以 maxRetryDelay 作为上限
+func uncappedDelay(max int64) int64 {
+	if max <= 0 || max > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return max
+}
+
+// clampDelay 延迟时长限定在 [0, max] 区间，max不超过 maxRetryDelay
+func clampDelay(delay, max int64) int64 {
+	if max > maxRetryDelay {
+		max = maxRetryDelay
+	}
+	if delay > max {
+		delay = max
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return delay
+}
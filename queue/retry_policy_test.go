@@ -0,0 +1,102 @@
+package queue
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyNextDelay(t *testing.T) {
+	cases := []struct {
+		name      string
+		policy    RetryPolicyIFace
+		attempts  int64
+		prevDelay int64
+		want      int64
+	}{
+		{"fixed", NewFixedRetryPolicy(10), 3, 10, 10},
+		{"fixed negative", NewFixedRetryPolicy(-1), 1, 0, 0},
+		{"linear first", NewLinearRetryPolicy(5, 10, 60), 1, 0, 5},
+		{"linear third", NewLinearRetryPolicy(5, 10, 60), 3, 0, 25},
+		{"linear capped", NewLinearRetryPolicy(5, 10, 60), 10, 0, 60},
+		{"linear uncapped", NewLinearRetryPolicy(5, 10, 0), 10, 0, 95},
+		{"linear uncapped negative max", NewLinearRetryPolicy(5, 10, -1), 2, 0, 15},
+		{"linear overflow", NewLinearRetryPolicy(5, math.MaxInt64/2, 0), math.MaxInt64, 0, maxRetryDelay},
+		{"linear attempts below 1", NewLinearRetryPolicy(5, 10, 60), 0, 0, 5},
+		{"exponential first", NewExponentialRetryPolicy(5, 600), 1, 0, 5},
+		{"exponential fourth", NewExponentialRetryPolicy(5, 600), 4, 0, 40},
+		{"exponential capped", NewExponentialRetryPolicy(5, 600), 10, 0, 600},
+		{"exponential uncapped", NewExponentialRetryPolicy(5, 0), 10, 0, 2560},
+		{"exponential overflow", NewExponentialRetryPolicy(5, 0), 1000, 0, maxRetryDelay},
+		{"exponential overflow capped", NewExponentialRetryPolicy(5, 600), math.MaxInt64, 0, 600},
+	}
+	for _, c := range cases {
+		if got := c.policy.NextDelay(c.attempts, c.prevDelay); got != c.want {
+			t.Fatalf("%s: next delay = %d, want %d", c.name, got, c.want)
+		}
+	}
+
+	// 不限制最大延迟时换算为 time.Duration 不溢出
+	if d := time.Duration(maxRetryDelay) * time.Second; d <= 0 {
+		t.Fatalf("max retry delay duration = %s, want positive", d)
+	}
+}
+
+func TestDecorrelatedJitterRetryPolicy(t *testing.T) {
+	cases := []struct {
+		name      string
+		base, max int64
+		prevDelay int64
+		low, high int64
+	}{
+		{"first retry", 5, 600, 0, 5, 15},
+		{"grows from previous", 5, 600, 100, 5, 300},
+		{"capped", 5, 600, 500, 5, 600},
+		{"uncapped", 5, 0, 1000, 5, 3000},
+		{"overflow", 5, 0, math.MaxInt64 / 2, 5, maxRetryDelay},
+		{"base above max", 100, 50, 0, 50, 50},
+	}
+	for _, c := range cases {
+		policy := NewDecorrelatedJitterRetryPolicy(c.base, c.max)
+		for i := 0; i < 100; i++ {
+			if got := policy.NextDelay(2, c.prevDelay); got < c.low || got > c.high {
+				t.Fatalf("%s: next delay = %d, want in [%d, %d]", c.name, got, c.low, c.high)
+			}
+		}
+	}
+}
+
+func TestRedisReleaseRetryDelay(t *testing.T) {
+	r, mr := newTestRedisQueue(t)
+	pushTestJob(t, r, "a", "1")
+
+	job, exist := r.Pop("a")
+	if !exist {
+		t.Fatal("no job popped")
+	}
+	now := time.Now().Unix()
+	if err := job.Release(30); err != nil {
+		t.Fatal(err)
+	}
+
+	// release脚本依据新payload中的 RetryDelay 计算可执行时刻
+	members, err := mr.ZMembers(r.delayedName("a"))
+	if err != nil || len(members) != 1 {
+		t.Fatalf("delayed = %v err %v, want 1 job", members, err)
+	}
+	var released Payload
+	if err = json.Unmarshal([]byte(members[0]), &released); err != nil {
+		t.Fatal(err)
+	}
+	if released.RetryDelay != 30 {
+		t.Fatalf("retry delay = %d, want 30", released.RetryDelay)
+	}
+	score, _ := mr.ZScore(r.delayedName("a"), members[0])
+	if at := int64(score); at < now+30 || at > now+31 {
+		t.Fatalf("available at = %d, want %d", at, now+30)
+	}
+	if members, _ = mr.ZMembers(r.reservedName("a")); len(members) != 0 {
+		t.Fatalf("reserved = %v, want none", members)
+	}
+}
@@ -0,0 +1,195 @@
+package queue
+
+import (
+	"fmt"
+	"time"
+)
+
+// *************************************************
+// 定时投递：按cron表达式周期性投递任务，无需再借助crond模块手动投递
+// 1、定时投递随 Start 启动，随队列关闭停止，通常只需在消费端注册
+// 2、底层驱动实现了 ScheduleStoreIFace（redis）时，集群中每个tick仅由一个节点投递
+// 3、停机等原因错过的tick按补偿策略处理，逾期超过 scheduleTolerance 的tick视为错过
+// *************************************************
+
+// CatchUpPolicy 错过的tick补偿策略
+type CatchUpPolicy int
+
+const (
+	CatchUpNone CatchUpPolicy = iota // 不补偿，跳过错过的tick（默认）
+	CatchUpOnce                      // 错过的tick仅补投递最近的一次
+	CatchUpAll                       // 错过的tick逐一补投递，最多 maxScheduleCatchUp 次
+)
+
+const (
+	scheduleTolerance  = 5 * time.Second // tick逾期超过该时长视为错过
+	maxScheduleCatchUp = 1000            // CatchUpAll 单次最多补投递的tick数
+)
+
+// ScheduleOption 定时投递可选设置
+type ScheduleOption func(entry *scheduleEntry)
+
+// ScheduleCatchUp 设置错过的tick补偿策略，默认 CatchUpNone
+func ScheduleCatchUp(policy CatchUpPolicy) ScheduleOption {
+	return func(entry *scheduleEntry) {
+		entry.catchUp = policy
+	}
+}
+
+// ScheduleLocation 设置cron表达式的时区，默认 time.Local
+func ScheduleLocation(location *time.Location) ScheduleOption {
+	return func(entry *scheduleEntry) {
+		entry.location = location
+	}
+}
+
+// ScheduleName 设置定时投递名称，默认为 任务名称@cron表达式
+//   - 名称用于集群中认领tick，同一任务类注册多个相同表达式的定时投递时需指定不同名称
+func ScheduleName(name string) ScheduleOption {
+	return func(entry *scheduleEntry) {
+		entry.name = name
+	}
+}
+
+// scheduleEntry 已注册的定时投递
+type scheduleEntry struct {
+	name     string                     // 定时投递名称
+	spec     string                     // cron表达式
+	cron     cronSchedule               // cron表达式解析结果
+	catchUp  CatchUpPolicy              // 错过的tick补偿策略
+	location *time.Location             // cron表达式时区
+	dispatch func(tick time.Time) error // 投递tick对应的任务
+}
+
+// Schedule 按cron表达式定时投递任务，需在 Start 之前注册
+//   - spec      cron表达式：6段 秒 分 时 日 月 周，或5段 分 时 日 月 周，或 @every 5m、@hourly 等预定义表达式
+//   - payloadFn 生成任务参数，入参为tick时刻；为nil时任务参数为nil
+//   - opts      可选设置：ScheduleCatchUp、ScheduleLocation、ScheduleName
+func (q *Queue) Schedule(task TaskIFace, spec string, payloadFn func(tick time.Time) interface{}, opts ...ScheduleOption) error {
+	entry := &scheduleEntry{name: task.Name() + "@" + spec, spec: spec}
+	for _, opt := range opts {
+		opt(entry)
+	}
+
+	cron, err := parseCron(spec, entry.location)
+	if err != nil {
+		return err
+	}
+	entry.cron = cron
+	entry.dispatch = func(tick time.Time) error {
+		var payload interface{}
+		if payloadFn != nil {
+			payload = payloadFn(tick)
+		}
+		return q.Dispatch(task, payload)
+	}
+
+	return q.manager.addSchedule(entry)
+}
+
+// addSchedule 注册定时投递
+func (m *manager) addSchedule(entry *scheduleEntry) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, exist := range m.schedules {
+		if exist.name == entry.name {
+			return fmt.Errorf("queue schedule %s already registered", entry.name)
+		}
+	}
+	m.schedules = append(m.schedules, entry)
+	return nil
+}
+
+// startSchedules 启动所有定时投递
+func (m *manager) startSchedules() {
+	m.lock.Lock()
+	schedules := make([]*scheduleEntry, len(m.schedules))
+	copy(schedules, m.schedules)
+	m.lock.Unlock()
+
+	for _, entry := range schedules {
+		go m.runSchedule(entry)
+	}
+}
+
+// runSchedule 定时投递循环：等待下一个tick，投递到期的tick直至队列关闭
+func (m *manager) runSchedule(entry *scheduleEntry) {
+	store, clustered := m.queue.(ScheduleStoreIFace)
+
+	// 上次处理到的tick：从最近认领的tick开始可补偿停机期间错过的tick
+	cursor := m.now()
+	if clustered {
+		if last, err := store.LastTick(entry.name); err == nil && last > 0 && last < cursor.Unix() {
+			cursor = time.Unix(last, 0)
+		}
+	}
+
+	for {
+		now := m.now()
+		for _, tick := range entry.dueTicks(cursor, now) {
+			m.fireSchedule(entry, store, clustered, tick)
+		}
+		if now.After(cursor) {
+			cursor = now
+		}
+
+		next := entry.cron.Next(cursor)
+		if next.IsZero() {
+			m.logger.Warn("queue schedule without next tick, stopped", "schedule", entry.name)
+			return
+		}
+
+		timer := time.NewTimer(next.Sub(m.now()))
+		select {
+		case <-m.getDoneChan():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// dueTicks 按补偿策略计算 (cursor, now] 之间需投递的tick
+func (entry *scheduleEntry) dueTicks(cursor, now time.Time) []time.Time {
+	var missed, due []time.Time
+	for tick := entry.cron.Next(cursor); !tick.IsZero() && !tick.After(now); tick = entry.cron.Next(tick) {
+		if now.Sub(tick) <= scheduleTolerance {
+			due = append(due, tick)
+			continue
+		}
+		missed = append(missed, tick)
+		// 只保留最近的 maxScheduleCatchUp 个
+		if len(missed) > maxScheduleCatchUp {
+			missed = missed[1:]
+		}
+	}
+
+	switch {
+	case len(missed) == 0:
+	case entry.catchUp == CatchUpOnce:
+		due = append(missed[len(missed)-1:], due...)
+	case entry.catchUp == CatchUpAll:
+		due = append(missed, due...)
+	}
+	return due
+}
+
+// fireSchedule 投递一个tick：集群中先认领，认领成功才投递
+func (m *manager) fireSchedule(entry *scheduleEntry, store ScheduleStoreIFace, clustered bool, tick time.Time) {
+	if clustered {
+		claimed, err := store.ClaimTick(entry.name, tick.Unix())
+		if err != nil || !claimed {
+			if err != nil {
+				m.logger.Error(textScheduleFailed, "schedule", entry.name, "tick", tick.String(), "error", err.Error())
+			}
+			return
+		}
+	}
+
+	if err := entry.dispatch(tick); err != nil {
+		m.logger.Error(textScheduleFailed, "schedule", entry.name, "tick", tick.String(), "error", err.Error())
+		return
+	}
+	m.logger.Debug("queue schedule dispatched", "schedule", entry.name, "tick", tick.String())
+}
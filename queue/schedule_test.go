@@ -0,0 +1,115 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// testClock 测试用模拟时钟
+type testClock struct {
+	lock sync.Mutex
+	now  time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(duration time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(duration)
+}
+
+func TestParseCron(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) // 周一
+
+	cases := []struct {
+		spec     string
+		location *time.Location
+		want     time.Time
+	}{
+		{"30 */5 * * * *", time.UTC, time.Date(2024, 1, 1, 0, 0, 30, 0, time.UTC)},
+		{"*/5 * * * *", time.UTC, time.Date(2024, 1, 1, 0, 5, 0, 0, time.UTC)},
+		{"0 0 3 * * SUN", time.UTC, time.Date(2024, 1, 7, 3, 0, 0, 0, time.UTC)},
+		{"0 0 3 ? JAN-MAR 0", time.UTC, time.Date(2024, 1, 7, 3, 0, 0, 0, time.UTC)},
+		{"@hourly", time.UTC, time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)},
+		{"@daily", shanghai, time.Date(2024, 1, 1, 16, 0, 0, 0, time.UTC)},
+		{"0 9 * * *", shanghai, time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)},
+		{"@every 90s", nil, time.Unix((from.Unix()/90+1)*90, 0).UTC()},
+	}
+	for _, c := range cases {
+		schedule, err := parseCron(c.spec, c.location)
+		if err != nil {
+			t.Fatalf("parse %q: %v", c.spec, err)
+		}
+		if next := schedule.Next(from); !next.Equal(c.want) {
+			t.Fatalf("%q next = %s, want %s", c.spec, next, c.want)
+		}
+	}
+
+	for _, spec := range []string{"", "* * *", "61 * * * * *", "@fortnightly"} {
+		if _, err := parseCron(spec, nil); err == nil {
+			t.Fatalf("parse %q succeeded, want error", spec)
+		}
+	}
+}
+
+func TestRunScheduleFakeClock(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 500_000_000, time.UTC)}
+	m := newManager(&memoryQueue{}, nopLogger{}, 1)
+	m.clock = clock
+
+	var (
+		lock  sync.Mutex
+		ticks []time.Time
+	)
+	cron, _ := parseCron("@every 1s", nil)
+	entry := &scheduleEntry{name: "test", cron: cron, catchUp: CatchUpAll, dispatch: func(tick time.Time) error {
+		lock.Lock()
+		defer lock.Unlock()
+		ticks = append(ticks, tick)
+		return nil
+	}}
+
+	done := make(chan struct{})
+	go func() {
+		m.runSchedule(entry)
+		close(done)
+	}()
+
+	// 模拟时钟前进10秒：逾期超过容忍时长的tick逐一补投递
+	time.Sleep(50 * time.Millisecond)
+	clock.Advance(10 * time.Second)
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		lock.Lock()
+		count := len(ticks)
+		lock.Unlock()
+		if count >= 10 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	m.lock.Lock()
+	m.closeDoneChanLocked()
+	m.lock.Unlock()
+	<-done
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(ticks) != 10 {
+		t.Fatalf("ticks = %v, want 10 ticks driven by fake clock", ticks)
+	}
+	for i, tick := range ticks {
+		if want := time.Date(2024, 1, 1, 0, 0, i+1, 0, time.UTC); !tick.Equal(want) {
+			t.Fatalf("tick %d = %s, want %s", i, tick, want)
+		}
+	}
+}
@@ -0,0 +1,397 @@
+package queue
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// *************************************************
+// looper调度器：决定每一轮looper中各任务队列的pop顺序与次数
+// 1、默认调度器：兼容原有行为，高优先级任务先被轮询，其余任务随机顺序轮询，每轮每个任务最多pop一次
+// 2、严格优先级调度器：始终优先pop优先级最高且有job的任务，同优先级任务之间轮转
+// 3、加权轮询调度器：按任务权重平滑加权轮询，有job堆积的任务之间按权重比例分配吞吐
+// 4、公平调度器：有job堆积的任务之间均分吞吐，空闲任务不累积额度
+// *************************************************
+
+// WeightedTaskIFace 任务类可选实现：任务权重，加权轮询调度器按权重比例分配吞吐
+type WeightedTaskIFace interface {
+	Weight() int64 // 任务权重，小于等于0时按1处理
+}
+
+// TaskPriorityIFace 任务类可选实现：任务优先级，严格优先级调度器优先pop优先级高的任务
+type TaskPriorityIFace interface {
+	Priority() int64 // 任务优先级，值越大越优先，默认0
+}
+
+// SchedulerTask 调度器中注册的任务信息
+type SchedulerTask struct {
+	Name     string // 任务名称即队列名称
+	Weight   int64  // 任务权重 WeightedTaskIFace，默认1
+	Priority int64  // 任务优先级 TaskPriorityIFace，默认0
+	High     bool   // 是否通过 SetHighPriorityTask 指定为高优先级任务
+}
+
+// SchedulerIFace looper调度器
+//   - looper每一轮先调用 NewRound，然后循环调用 Next 获取下一个尝试pop的任务，并通过 Done 反馈是否pop到了job
+//   - Next 返回ok为false时本轮结束，本轮一个job都未pop到时looper休眠
+//   - 同一个任务名称多次 Register 时以最后一次为准
+type SchedulerIFace interface {
+	Register(task SchedulerTask)   // 注册任务
+	NewRound()                     // 开始新一轮调度
+	Next() (name string, ok bool)  // 本轮下一个尝试pop的任务
+	Done(name string, popped bool) // 反馈任务本次是否pop到了job
+}
+
+// schedulerTask 由任务类实例构造调度器注册信息
+func schedulerTask(task TaskIFace, high bool) SchedulerTask {
+	item := SchedulerTask{Name: task.Name(), Weight: 1, High: high}
+	if t, ok := task.(WeightedTaskIFace); ok && t.Weight() > 0 {
+		item.Weight = t.Weight()
+	}
+	if t, ok := task.(TaskPriorityIFace); ok {
+		item.Priority = t.Priority()
+	}
+	return item
+}
+
+// schedulerTasks 调度器已注册任务集合
+type schedulerTasks struct {
+	lock  sync.Mutex
+	tasks []SchedulerTask
+}
+
+// register 注册任务，同名任务覆盖
+func (s *schedulerTasks) register(task SchedulerTask) {
+	if task.Weight <= 0 {
+		task.Weight = 1
+	}
+	for idx := range s.tasks {
+		if s.tasks[idx].Name == task.Name {
+			s.tasks[idx] = task
+			return
+		}
+	}
+	s.tasks = append(s.tasks, task)
+}
+
+// region 默认调度器
+
+// defaultScheduler 默认调度器：高优先级任务随机顺序先被轮询，然后全部任务随机顺序轮询
+type defaultScheduler struct {
+	schedulerTasks
+	round []string // 本轮轮询序列
+}
+
+// NewDefaultScheduler 默认调度器，兼容原有looper行为
+func NewDefaultScheduler() SchedulerIFace {
+	return &defaultScheduler{}
+}
+
+// Register 注册任务
+func (s *defaultScheduler) Register(task SchedulerTask) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.register(task)
+}
+
+// NewRound 开始新一轮调度
+func (s *defaultScheduler) NewRound() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	high := make([]string, 0)
+	all := make([]string, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		if task.High {
+			high = append(high, task.Name)
+		}
+		all = append(all, task.Name)
+	}
+	rand.Shuffle(len(high), func(i, j int) { high[i], high[j] = high[j], high[i] })
+	rand.Shuffle(len(all), func(i, j int) { all[i], all[j] = all[j], all[i] })
+
+	s.round = append(high, all...)
+}
+
+// Next 本轮下一个尝试pop的任务
+func (s *defaultScheduler) Next() (name string, ok bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.round) == 0 {
+		return "", false
+	}
+	name, s.round = s.round[0], s.round[1:]
+	return name, true
+}
+
+// Done 反馈任务本次是否pop到了job
+func (s *defaultScheduler) Done(name string, popped bool) {}
+
+// endregion
+
+// region 严格优先级调度器
+
+// strictPriorityScheduler 严格优先级调度器
+//   - 排序规则：High 优先，然后 Priority 降序
+//   - 任意任务pop到job后立即结束本轮，下一轮重新从最高优先级开始，保障高优先级任务的job被优先消费完
+//   - 同优先级任务之间轮转，避免同级任务饿死
+type strictPriorityScheduler struct {
+	schedulerTasks
+	round  []string         // 本轮轮询序列
+	cursor map[string]int64 // 同级任务轮转计数：任务名称 => 最近一次pop到job的序号
+	serial int64            // pop序号
+}
+
+// NewStrictPriorityScheduler 严格优先级调度器，任务类实现 TaskPriorityIFace 指定优先级
+//   - 注意：高优先级任务持续有job时低优先级任务将得不到执行
+func NewStrictPriorityScheduler() SchedulerIFace {
+	return &strictPriorityScheduler{cursor: make(map[string]int64)}
+}
+
+// Register 注册任务
+func (s *strictPriorityScheduler) Register(task SchedulerTask) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.register(task)
+}
+
+// NewRound 开始新一轮调度
+func (s *strictPriorityScheduler) NewRound() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	tasks := make([]SchedulerTask, len(s.tasks))
+	copy(tasks, s.tasks)
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if tasks[i].High != tasks[j].High {
+			return tasks[i].High
+		}
+		if tasks[i].Priority != tasks[j].Priority {
+			return tasks[i].Priority > tasks[j].Priority
+		}
+		// 同级任务：最久未pop到job的优先
+		return s.cursor[tasks[i].Name] < s.cursor[tasks[j].Name]
+	})
+
+	s.round = s.round[:0]
+	for _, task := range tasks {
+		s.round = append(s.round, task.Name)
+	}
+}
+
+// Next 本轮下一个尝试pop的任务
+func (s *strictPriorityScheduler) Next() (name string, ok bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.round) == 0 {
+		return "", false
+	}
+	name, s.round = s.round[0], s.round[1:]
+	return name, true
+}
+
+// Done 反馈任务本次是否pop到了job：pop到job则结束本轮
+func (s *strictPriorityScheduler) Done(name string, popped bool) {
+	if !popped {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.serial++
+	s.cursor[name] = s.serial
+	s.round = s.round[:0]
+}
+
+// endregion
+
+// region 加权轮询调度器
+
+// weightedScheduler 平滑加权轮询调度器（smooth weighted round-robin）
+//   - 每轮最多调度所有任务权重之和次，本轮未pop到job的任务在本轮剩余调度中被跳过
+//   - 有job堆积的任务之间按权重比例分配吞吐，且同一任务不会被连续集中调度
+type weightedScheduler struct {
+	schedulerTasks
+	current map[string]int64    // 平滑加权当前权重：任务名称 => 当前权重
+	idle    map[string]struct{} // 本轮未pop到job的任务
+	remain  int64               // 本轮剩余调度次数
+}
+
+// NewWeightedScheduler 加权轮询调度器，任务类实现 WeightedTaskIFace 指定权重
+func NewWeightedScheduler() SchedulerIFace {
+	return &weightedScheduler{
+		current: make(map[string]int64),
+		idle:    make(map[string]struct{}),
+	}
+}
+
+// Register 注册任务
+func (s *weightedScheduler) Register(task SchedulerTask) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.register(task)
+}
+
+// NewRound 开始新一轮调度
+func (s *weightedScheduler) NewRound() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.idle = make(map[string]struct{})
+	s.remain = 0
+	for _, task := range s.tasks {
+		s.remain += task.Weight
+	}
+}
+
+// Next 本轮下一个尝试pop的任务
+func (s *weightedScheduler) Next() (name string, ok bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.remain <= 0 {
+		return "", false
+	}
+
+	var total, best int64
+	for _, task := range s.tasks {
+		if _, idle := s.idle[task.Name]; idle {
+			continue
+		}
+		s.current[task.Name] += task.Weight
+		total += task.Weight
+		if !ok || s.current[task.Name] > best {
+			name, best, ok = task.Name, s.current[task.Name], true
+		}
+	}
+	if !ok {
+		return "", false
+	}
+
+	s.current[name] -= total
+	s.remain--
+	return name, true
+}
+
+// Done 反馈任务本次是否pop到了job：未pop到job的任务本轮不再调度
+func (s *weightedScheduler) Done(name string, popped bool) {
+	if popped {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.idle[name] = struct{}{}
+	s.current[name] = 0
+}
+
+// endregion
+
+// region 公平调度器
+
+// fairScheduler 公平调度器
+//   - 每个任务记录已调度次数（虚拟时间），每轮按虚拟时间升序轮询，任意任务pop到job后结束本轮
+//   - 虚拟时间相同的任务最久未pop到job的优先，保障繁忙任务之间依次轮转
+//   - 空闲任务的虚拟时间在新一轮开始时追平繁忙任务的最小虚拟时间，避免空闲期间累积额度后长时间独占
+type fairScheduler struct {
+	schedulerTasks
+	served map[string]int64    // 任务名称 => 虚拟时间
+	last   map[string]int64    // 任务名称 => 最近一次pop到job的序号
+	serial int64               // pop序号
+	idle   map[string]struct{} // 上一次未pop到job的任务
+	round  []string            // 本轮轮询序列
+}
+
+// NewFairScheduler 公平调度器，有job堆积的任务之间均分吞吐
+func NewFairScheduler() SchedulerIFace {
+	return &fairScheduler{
+		served: make(map[string]int64),
+		last:   make(map[string]int64),
+		idle:   make(map[string]struct{}),
+	}
+}
+
+// Register 注册任务
+func (s *fairScheduler) Register(task SchedulerTask) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.register(task)
+	s.idle[task.Name] = struct{}{}
+}
+
+// NewRound 开始新一轮调度
+func (s *fairScheduler) NewRound() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// step1、繁忙任务的最小虚拟时间
+	var minServed int64
+	busy := false
+	for _, task := range s.tasks {
+		if _, idle := s.idle[task.Name]; idle {
+			continue
+		}
+		if !busy || s.served[task.Name] < minServed {
+			minServed, busy = s.served[task.Name], true
+		}
+	}
+
+	// step2、空闲任务追平
+	if busy {
+		for name := range s.idle {
+			if s.served[name] < minServed {
+				s.served[name] = minServed
+			}
+		}
+	}
+
+	// step3、按虚拟时间升序，相同时最久未pop到job的优先，仍相同时随机
+	names := make([]string, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		names = append(names, task.Name)
+	}
+	rand.Shuffle(len(names), func(i, j int) { names[i], names[j] = names[j], names[i] })
+	sort.SliceStable(names, func(i, j int) bool {
+		if s.served[names[i]] != s.served[names[j]] {
+			return s.served[names[i]] < s.served[names[j]]
+		}
+		return s.last[names[i]] < s.last[names[j]]
+	})
+	s.round = names
+}
+
+// Next 本轮下一个尝试pop的任务
+func (s *fairScheduler) Next() (name string, ok bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.round) == 0 {
+		return "", false
+	}
+	name, s.round = s.round[0], s.round[1:]
+	return name, true
+}
+
+// Done 反馈任务本次是否pop到了job：pop到job则结束本轮
+func (s *fairScheduler) Done(name string, popped bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !popped {
+		s.idle[name] = struct{}{}
+		return
+	}
+
+	delete(s.idle, name)
+	s.served[name]++
+	s.serial++
+	s.last[name] = s.serial
+	s.round = s.round[:0]
+}
+
+// endregion
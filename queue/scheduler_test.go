@@ -0,0 +1,194 @@
+package queue
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// simulate 以memory驱动模拟looper调度：各队列预先投递 jobs[name] 个job，返回前n次pop到job的队列序列
+func simulate(t *testing.T, scheduler SchedulerIFace, tasks []SchedulerTask, jobs map[string]int, n int) []string {
+	t.Helper()
+
+	mq := &memoryQueue{}
+	for name, count := range jobs {
+		for i := 0; i < count; i++ {
+			payload, _ := json.Marshal(Payload{Name: name, ID: name + strconv.Itoa(i), MaxTries: 1, Timeout: 60})
+			if err := mq.Push(name, payload); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for _, task := range tasks {
+		scheduler.Register(task)
+	}
+
+	popped := make([]string, 0, n)
+	for len(popped) < n {
+		scheduler.NewRound()
+		progressed := false
+		for len(popped) < n {
+			name, ok := scheduler.Next()
+			if !ok {
+				break
+			}
+			job, exist := mq.Pop(name)
+			if exist {
+				_ = job.Delete()
+				popped = append(popped, name)
+				progressed = true
+			}
+			scheduler.Done(name, exist)
+		}
+		if !progressed {
+			break
+		}
+	}
+	return popped
+}
+
+func TestStrictPrioritySchedulerOrder(t *testing.T) {
+	cases := []struct {
+		name  string
+		tasks []SchedulerTask
+		jobs  map[string]int
+		want  []string
+	}{
+		{
+			name:  "higher priority drained first, same priority rotates",
+			tasks: []SchedulerTask{{Name: "a", Priority: 2}, {Name: "b", Priority: 1}, {Name: "c", Priority: 1}},
+			jobs:  map[string]int{"a": 2, "b": 2, "c": 2},
+			want:  []string{"a", "a", "b", "c", "b", "c"},
+		},
+		{
+			name:  "high priority task beats any priority",
+			tasks: []SchedulerTask{{Name: "a", Priority: 5}, {Name: "h", High: true}},
+			jobs:  map[string]int{"a": 2, "h": 2},
+			want:  []string{"h", "h", "a", "a"},
+		},
+		{
+			name:  "empty higher priority queue does not block lower ones",
+			tasks: []SchedulerTask{{Name: "a", Priority: 2}, {Name: "b", Priority: 1}},
+			jobs:  map[string]int{"b": 3},
+			want:  []string{"b", "b", "b"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := simulate(t, NewStrictPriorityScheduler(), c.tasks, c.jobs, len(c.want))
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("pick order = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestWeightedSchedulerOrder(t *testing.T) {
+	cases := []struct {
+		name  string
+		tasks []SchedulerTask
+		jobs  map[string]int
+		want  []string
+	}{
+		{
+			name:  "smooth weighted round-robin",
+			tasks: []SchedulerTask{{Name: "a", Weight: 3}, {Name: "b", Weight: 1}},
+			jobs:  map[string]int{"a": 10, "b": 10},
+			want:  []string{"a", "a", "b", "a", "a", "a", "b", "a"},
+		},
+		{
+			name:  "equal weights alternate",
+			tasks: []SchedulerTask{{Name: "a", Weight: 1}, {Name: "b", Weight: 1}},
+			jobs:  map[string]int{"a": 3, "b": 3},
+			want:  []string{"a", "b", "a", "b", "a", "b"},
+		},
+		{
+			name:  "idle task skipped, others take its share",
+			tasks: []SchedulerTask{{Name: "a", Weight: 3}, {Name: "b", Weight: 1}},
+			jobs:  map[string]int{"a": 1, "b": 4},
+			want:  []string{"a", "b", "b", "b", "b"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := simulate(t, NewWeightedScheduler(), c.tasks, c.jobs, len(c.want))
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("pick order = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestFairSchedulerOrder(t *testing.T) {
+	cases := []struct {
+		name   string
+		tasks  []SchedulerTask
+		jobs   map[string]int
+		n      int
+		window []string // 每个窗口内各出现一次的队列，窗口长度为其长度
+	}{
+		{
+			name:   "busy queues share evenly",
+			tasks:  []SchedulerTask{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+			jobs:   map[string]int{"a": 5, "b": 5, "c": 5},
+			n:      15,
+			window: []string{"a", "b", "c"},
+		},
+		{
+			name:   "idle queue does not accumulate credit",
+			tasks:  []SchedulerTask{{Name: "a"}, {Name: "b"}, {Name: "idle"}},
+			jobs:   map[string]int{"a": 4, "b": 4},
+			n:      8,
+			window: []string{"a", "b"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := simulate(t, NewFairScheduler(), c.tasks, c.jobs, c.n)
+			if len(got) != c.n {
+				t.Fatalf("popped %d jobs, want %d", len(got), c.n)
+			}
+			size := len(c.window)
+			for i := 0; i+size <= len(got); i += size {
+				window := append([]string(nil), got[i:i+size]...)
+				sort.Strings(window)
+				if !reflect.DeepEqual(window, c.window) {
+					t.Fatalf("pick order = %v, window %d = %v, want each of %v once", got, i/size, window, c.window)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultSchedulerRound(t *testing.T) {
+	s := NewDefaultScheduler()
+	s.Register(SchedulerTask{Name: "a"})
+	s.Register(SchedulerTask{Name: "h", High: true})
+	s.Register(SchedulerTask{Name: "b"})
+
+	for i := 0; i < 10; i++ {
+		s.NewRound()
+		var round []string
+		for {
+			name, ok := s.Next()
+			if !ok {
+				break
+			}
+			round = append(round, name)
+			s.Done(name, false)
+		}
+		if len(round) != 4 || round[0] != "h" {
+			t.Fatalf("round = %v, want high priority task first then all tasks", round)
+		}
+		rest := append([]string(nil), round[1:]...)
+		sort.Strings(rest)
+		if !reflect.DeepEqual(rest, []string{"a", "b", "h"}) {
+			t.Fatalf("round = %v, want every task once after high priority tasks", round)
+		}
+	}
+}
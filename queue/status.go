@@ -0,0 +1,209 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// *************************************************
+// 可追踪任务：记录任务状态、执行进度和执行结果，便于HTTP接口轮询长耗时任务的完成情况
+// 1、通过 DispatchTracked 投递的任务才会记录状态，返回的任务ID用于 Status 查询
+// 2、任务类中通过 RawBody.Progress 上报进度，RawBody.SetResult 保存执行结果
+// 3、状态记录存储于底层驱动：redis使用hash，memory使用map，每次更新重新计算有效期
+// *************************************************
+
+// 可追踪任务的状态
+const (
+	JobStatusPending   = "pending"   // 待执行
+	JobStatusRunning   = "running"   // 执行中
+	JobStatusRetrying  = "retrying"  // 执行失败等待重试
+	JobStatusSucceeded = "succeeded" // 执行成功
+	JobStatusFailed    = "failed"    // 最终失败
+)
+
+// JobStatus 可追踪任务的状态
+type JobStatus struct {
+	ID        string `json:"id"`         // 任务ID
+	Queue     string `json:"queue"`      // 队列名称
+	State     string `json:"state"`      // 任务状态：JobStatusPending 等
+	Attempts  int64  `json:"attempts"`   // 已尝试执行次数
+	Percent   int64  `json:"percent"`    // 执行进度百分比
+	Message   string `json:"message"`    // 执行进度说明
+	Result    string `json:"result"`     // 执行结果，标量直接转字符串，其他类型json序列化
+	Error     string `json:"error"`      // 最近一次执行失败的错误信息
+	UpdatedAt int64  `json:"updated_at"` // 最近更新时刻时间戳
+}
+
+// Finished 任务是否已结束：执行成功或最终失败
+func (s *JobStatus) Finished() bool {
+	return s.State == JobStatusSucceeded || s.State == JobStatusFailed
+}
+
+// UnmarshalResult 执行结果Unmarshal为 SetResult 时的结构类型
+func (s *JobStatus) UnmarshalResult(result interface{}) error {
+	return json.Unmarshal([]byte(s.Result), result)
+}
+
+// statusReporter 任务类中上报进度、保存结果的状态记录器
+type statusReporter struct {
+	store StatusStoreIFace
+	id    string
+	ttl   time.Duration // 记录有效期，SetResult 指定有效期后以其为准
+	lock  sync.Mutex
+}
+
+// update 更新状态记录的指定字段
+func (s *statusReporter) update(fields map[string]string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	fields["updated_at"] = strconv.FormatInt(time.Now().Unix(), 10)
+	return s.store.SetStatus(s.id, fields, s.ttl)
+}
+
+// Progress 上报任务执行进度，非 DispatchTracked 投递的任务调用无任何效果
+//   - percent 进度百分比，取值0-100
+//   - message 进度说明
+func (rawBody *RawBody) Progress(percent int64, message string) error {
+	if rawBody.status == nil {
+		return nil
+	}
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+	return rawBody.status.update(map[string]string{
+		"percent": strconv.FormatInt(percent, 10),
+		"message": message,
+	})
+}
+
+// SetResult 保存任务执行结果，非 DispatchTracked 投递的任务调用无任何效果
+//   - result 执行结果，转换规则与投递任务参数一致：标量直接转字符串，其他类型json序列化
+//   - ttl    状态记录（含结果）的有效期，小于等于0时使用 SetStatusTTL 设置的有效期
+func (rawBody *RawBody) SetResult(result interface{}, ttl time.Duration) error {
+	if rawBody.status == nil {
+		return nil
+	}
+	if ttl > 0 {
+		rawBody.status.lock.Lock()
+		rawBody.status.ttl = ttl
+		rawBody.status.lock.Unlock()
+	}
+	return rawBody.status.update(map[string]string{"result": IFaceToString(result)})
+}
+
+// SetStatusTTL 设置可追踪任务状态记录的有效期，小于等于0时使用 DefaultStatusTTL
+func (q *Queue) SetStatusTTL(ttl time.Duration) {
+	q.manager.statusTTL = ttl
+}
+
+// DispatchTracked 投递一个可追踪的队列Job任务，返回任务ID用于 Status 查询
+//   - 队列底层驱动未实现 StatusStoreIFace 时返回 ErrStatusNotSupported
+func (q *Queue) DispatchTracked(task TaskIFace, payload interface{}) (string, error) {
+	store, ok := q.queue.(StatusStoreIFace)
+	if !ok {
+		return "", ErrStatusNotSupported
+	}
+
+	queuePayload, err := q.newTaskPayload(task, payload)
+	if nil != err {
+		return "", fmt.Errorf("queue %s job param marshal failed: %s", task.Name(), err.Error())
+	}
+	queuePayload.Tracked = true
+	raw, err := json.Marshal(queuePayload)
+	if nil != err {
+		return "", fmt.Errorf("queue %s job param marshal failed: %s", task.Name(), err.Error())
+	}
+
+	// 先写入状态记录再投递，投递后即可查询
+	if err = store.SetStatus(queuePayload.ID, map[string]string{
+		"queue":      task.Name(),
+		"state":      JobStatusPending,
+		"attempts":   "0",
+		"updated_at": strconv.FormatInt(time.Now().Unix(), 10),
+	}, q.manager.getStatusTTL()); err != nil {
+		return "", err
+	}
+
+	if err = q.queue.Push(task.Name(), raw); err != nil {
+		return "", err
+	}
+	q.manager.jobDispatched(task.Name(), raw)
+	return queuePayload.ID, nil
+}
+
+// Status 查询可追踪任务的状态，任务不存在或状态记录已过期返回 ErrJobNotFound
+func (q *Queue) Status(jobID string) (*JobStatus, error) {
+	store, ok := q.queue.(StatusStoreIFace)
+	if !ok {
+		return nil, ErrStatusNotSupported
+	}
+
+	fields, err := store.GetStatus(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	toInt64 := func(value string) int64 {
+		i64, _ := strconv.ParseInt(value, 10, 64)
+		return i64
+	}
+	return &JobStatus{
+		ID:        jobID,
+		Queue:     fields["queue"],
+		State:     fields["state"],
+		Attempts:  toInt64(fields["attempts"]),
+		Percent:   toInt64(fields["percent"]),
+		Message:   fields["message"],
+		Result:    fields["result"],
+		Error:     fields["error"],
+		UpdatedAt: toInt64(fields["updated_at"]),
+	}, nil
+}
+
+// region manager记录任务状态
+
+// getStatusTTL 状态记录有效期
+func (m *manager) getStatusTTL() time.Duration {
+	if m.statusTTL <= 0 {
+		return DefaultStatusTTL
+	}
+	return m.statusTTL
+}
+
+// statusReporter 可追踪任务的状态记录器，非可追踪任务 或 底层驱动未实现 StatusStoreIFace 时为nil
+func (m *manager) statusReporter(job JobIFace) *statusReporter {
+	store, ok := m.queue.(StatusStoreIFace)
+	if !ok || !job.Payload().Tracked {
+		return nil
+	}
+	return &statusReporter{store: store, id: job.Payload().ID, ttl: m.getStatusTTL()}
+}
+
+// trackJob 更新可追踪任务的状态
+//   - reporter 任务执行时的状态记录器，沿用 SetResult 指定的有效期；为nil时以 SetStatusTTL 设置的有效期新建
+func (m *manager) trackJob(job JobIFace, reporter *statusReporter, state string, err error) {
+	if reporter == nil {
+		if reporter = m.statusReporter(job); reporter == nil {
+			return
+		}
+	}
+
+	fields := map[string]string{
+		"state":    state,
+		"attempts": strconv.FormatInt(job.Attempts(), 10),
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	if state == JobStatusSucceeded {
+		fields["percent"] = "100"
+	}
+	_ = reporter.update(fields)
+}
+
+// endregion
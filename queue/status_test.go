@@ -0,0 +1,82 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// resultTask 保存执行结果后执行失败的任务类
+type resultTask struct {
+	DefaultTaskSetting
+	tries int64
+}
+
+func (t *resultTask) Name() string   { return "result" }
+func (t *resultTask) Remark() string { return "result" }
+func (t *resultTask) MaxTries() int64 {
+	return t.tries
+}
+func (t *resultTask) RetryInterval() int64 {
+	return 3600
+}
+func (t *resultTask) Execute(ctx context.Context, job *RawBody) error {
+	if err := job.SetResult("partial", 24*time.Hour); err != nil {
+		return err
+	}
+	return errors.New("failed")
+}
+
+func TestTrackedJobKeepsResultTTL(t *testing.T) {
+	cases := []struct {
+		tries int64
+		state string
+	}{
+		{tries: 2, state: JobStatusRetrying},
+		{tries: 1, state: JobStatusFailed},
+	}
+
+	for _, c := range cases {
+		t.Run(c.state, func(t *testing.T) {
+			q := New(Memory, nil, nopLogger{}, 1)
+			mq := q.queue.(*memoryQueue)
+			q.SetStatusTTL(time.Minute)
+			if err := q.BootstrapOne(&resultTask{tries: c.tries}); err != nil {
+				t.Fatal(err)
+			}
+
+			id, err := q.DispatchTracked(&resultTask{tries: c.tries}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = q.Start(); err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+				_ = q.ShutDown(ctx)
+			}()
+
+			deadline := time.Now().Add(3 * time.Second)
+			for {
+				status, err := q.Status(id)
+				if err == nil && status.State == c.state {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("status = %+v, want state %s", status, c.state)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			mq.lock.Lock()
+			expireAt := mq.statuses[id].expireAt
+			mq.lock.Unlock()
+			if ttl := time.Until(expireAt); ttl < time.Hour {
+				t.Fatalf("status ttl = %s, want the ttl set by SetResult", ttl)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+-- 数据库驱动任务表（MySQL 8.0+），亦可调用 queue.MigrateDatabase 自动创建
+CREATE TABLE `jobs` (
+     `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+     `queue` varchar(191) NOT NULL,
+     `job_id` varchar(64) NOT NULL,
+     `payload` longtext NOT NULL,
+     `attempts` int unsigned NOT NULL DEFAULT 0,
+     `reserved_until` int unsigned DEFAULT NULL,
+     `available_at` int unsigned NOT NULL,
+     `created_at` int unsigned NOT NULL,
+     PRIMARY KEY (`id`),
+     KEY `idx_queue_available` (`queue`, `available_at`),
+     KEY `idx_queue_reserved` (`queue`, `reserved_until`),
+     KEY `idx_job_id` (`job_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
@@ -0,0 +1,162 @@
+package queue
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowTask 执行超时的任务类：cooperative 为true时响应context退出，否则阻塞直至 release 关闭
+type slowTask struct {
+	DefaultTaskSetting
+	cooperative bool
+	release     chan struct{}
+	returned    chan struct{}
+	runs        int32
+}
+
+func (t *slowTask) Name() string           { return "slow" }
+func (t *slowTask) Remark() string         { return "slow" }
+func (t *slowTask) MaxTries() int64        { return 5 }
+func (t *slowTask) RetryInterval() int64   { return 10 }
+func (t *slowTask) Timeout() time.Duration { return time.Second }
+func (t *slowTask) Execute(ctx context.Context, job *RawBody) error {
+	if atomic.AddInt32(&t.runs, 1) > 1 {
+		return nil
+	}
+	if t.cooperative {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	<-t.release
+	close(t.returned)
+	return nil
+}
+
+// newTimeoutQueue 基于模拟时钟的memory队列，投递一个 slowTask 任务
+func newTimeoutQueue(t *testing.T, task *slowTask, grace time.Duration) (*Queue, *memoryQueue, *testClock) {
+	t.Helper()
+
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	q := New(Memory, nil, nopLogger{}, 1)
+	q.SetClock(clock)
+	q.SetExecuteGrace(grace)
+	if err := q.BootstrapOne(task); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Dispatch(task, nil); err != nil {
+		t.Fatal(err)
+	}
+	return q, q.queue.(*memoryQueue), clock
+}
+
+// runPopped 取出并在当前协程执行一个job
+func runPopped(t *testing.T, q *Queue, name string) JobIFace {
+	t.Helper()
+
+	job, exist := q.queue.Pop(name)
+	if !exist {
+		t.Fatal("no job popped")
+	}
+	q.manager.runJob(job, 1)
+	return job
+}
+
+// delayedHistory 读取memory队列中延迟job的失败尝试记录
+func delayedHistory(mq *memoryQueue, name string) (history []AttemptRecord) {
+	mq.lock.Lock()
+	defer mq.lock.Unlock()
+	for _, item := range mq.delayed[name] {
+		history = item.Payload.History
+	}
+	return
+}
+
+// memoryCounts 统计memory队列中待执行、延迟、保留的job数
+func memoryCounts(mq *memoryQueue, name string) (list, delayed, reserved int) {
+	mq.lock.Lock()
+	defer mq.lock.Unlock()
+	if mq.list[name] != nil {
+		list = mq.list[name].Len()
+	}
+	return list, len(mq.delayed[name]), len(mq.reserved[name])
+}
+
+func TestRunJobTimeoutWithinGrace(t *testing.T) {
+	task := &slowTask{cooperative: true}
+	q, mq, _ := newTimeoutQueue(t, task, time.Second)
+
+	// 宽限期内退出：按执行超时失败收尾，释放重试
+	job := runPopped(t, q, task.Name())
+	if _, exist := q.manager.inWorkingMap.Load(job.Payload().ID); exist {
+		t.Fatal("in working marker not cleared")
+	}
+	if !job.IsReleased() || job.IsDeleted() {
+		t.Fatal("job not released for retry")
+	}
+	if list, delayed, reserved := memoryCounts(mq, task.Name()); list != 0 || delayed != 1 || reserved != 0 {
+		t.Fatalf("list %d delayed %d reserved %d, want job delayed", list, delayed, reserved)
+	}
+	if history := delayedHistory(mq, task.Name()); len(history) != 1 || !strings.Contains(history[0].Error, ErrJobExecuteTimeout.Error()) {
+		t.Fatalf("history = %+v, want one timeout attempt", history)
+	}
+}
+
+func TestRunJobAbandonedPastGrace(t *testing.T) {
+	task := &slowTask{release: make(chan struct{}), returned: make(chan struct{})}
+	q, mq, clock := newTimeoutQueue(t, task, 10*time.Millisecond)
+
+	// 宽限期内未退出：放弃等待，释放重试，运行中标记由执行协程持有
+	job := runPopped(t, q, task.Name())
+	id := job.Payload().ID
+	if _, exist := q.manager.inWorkingMap.Load(id); !exist {
+		t.Fatal("in working marker cleared while execution still running")
+	}
+	if !job.IsReleased() {
+		t.Fatal("abandoned job not released for retry")
+	}
+	if history := delayedHistory(mq, task.Name()); len(history) != 1 || !strings.Contains(history[0].Error, ErrJobExecuteTimeout.Error()) {
+		t.Fatalf("history = %+v, want one timeout attempt", history)
+	}
+
+	// 执行仍未返回时多次再次取出：释放保留中的job，不产生重复的job
+	for i := 0; i < 3; i++ {
+		clock.Advance(time.Hour)
+		again := runPopped(t, q, task.Name())
+		if again.Payload().ID != id || !again.IsReleased() {
+			t.Fatalf("re-popped job %s released %v, want %s released", again.Payload().ID, again.IsReleased(), id)
+		}
+		if list, delayed, reserved := memoryCounts(mq, task.Name()); list+delayed+reserved != 1 || delayed != 1 {
+			t.Fatalf("cycle %d list %d delayed %d reserved %d, want one delayed job", i, list, delayed, reserved)
+		}
+	}
+
+	// 迟到的执行结果被丢弃：不删除job，仅清理运行中标记
+	close(task.release)
+	<-task.returned
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, exist := q.manager.inWorkingMap.Load(id); !exist {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("late return did not clear in working marker")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, delayed, _ := memoryCounts(mq, task.Name()); delayed != 1 {
+		t.Fatal("late result settled the job")
+	}
+
+	// 再次取出正常执行
+	clock.Advance(time.Hour)
+	last := runPopped(t, q, task.Name())
+	if !last.IsDeleted() {
+		t.Fatal("job not deleted after successful run")
+	}
+	if list, delayed, reserved := memoryCounts(mq, task.Name()); list+delayed+reserved != 0 {
+		t.Fatalf("list %d delayed %d reserved %d, want empty", list, delayed, reserved)
+	}
+}
@@ -0,0 +1,79 @@
+package queue
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"testing"
+	"time"
+)
+
+// uniqueTask 唯一任务类
+type uniqueTask struct {
+	DefaultTaskSetting
+}
+
+func (t *uniqueTask) Name() string                                    { return "unique" }
+func (t *uniqueTask) Remark() string                                  { return "unique" }
+func (t *uniqueTask) Execute(ctx context.Context, job *RawBody) error { return nil }
+
+// uniqueDrivers 实现唯一任务的底层驱动：返回队列与令唯一锁有效期流逝的方法
+var uniqueDrivers = map[string]func(t *testing.T) (*Queue, func(time.Duration)){
+	"memory": func(t *testing.T) (*Queue, func(time.Duration)) {
+		clock := &testClock{now: time.Now()}
+		q := New(Memory, nil, nopLogger{}, 1)
+		q.SetClock(clock)
+		return q, clock.Advance
+	},
+	"redis": func(t *testing.T) (*Queue, func(time.Duration)) {
+		mr := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() {
+			_ = client.Close()
+		})
+		return New(Redis, client, nopLogger{}, 1), mr.FastForward
+	},
+}
+
+func TestDispatchUnique(t *testing.T) {
+	for name, newDriver := range uniqueDrivers {
+		t.Run(name, func(t *testing.T) {
+			q, elapse := newDriver(t)
+			task := &uniqueTask{}
+
+			dispatch := func(want bool) {
+				t.Helper()
+				pushed, err := q.DispatchUnique(task, "order", "order:1", time.Minute)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if pushed != want {
+					t.Fatalf("pushed = %v, want %v", pushed, want)
+				}
+			}
+
+			// 唯一锁持有期间重复投递被拒绝
+			dispatch(true)
+			dispatch(false)
+			if size := q.queue.Size(task.Name()); size != 1 {
+				t.Fatalf("size = %d, want 1", size)
+			}
+
+			// 任务删除后释放唯一锁
+			job, exist := q.queue.Pop(task.Name())
+			if !exist {
+				t.Fatal("no job popped")
+			}
+			dispatch(false)
+			if err := job.Delete(); err != nil {
+				t.Fatal(err)
+			}
+			dispatch(true)
+
+			// 唯一锁到期后可再次投递
+			dispatch(false)
+			elapse(time.Minute + time.Second)
+			dispatch(true)
+		})
+	}
+}
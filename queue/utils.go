@@ -2,6 +2,7 @@ package queue
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/google/uuid"
 	"strconv"
 	"time"
@@ -77,3 +78,13 @@ func IFaceToString(value interface{}) string {
 
 	return key
 }
+
+// panicToError recover得到的panic值转换为包装了 ErrJobExecutePanic 的error
+func panicToError(rec interface{}) error {
+	switch t := rec.(type) {
+	case error:
+		return fmt.Errorf("%w: %w", ErrJobExecutePanic, t)
+	default:
+		return fmt.Errorf("%w: %v", ErrJobExecutePanic, t)
+	}
+}
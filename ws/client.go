@@ -5,6 +5,7 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/tidwall/gjson"
 	"strconv"
+	"sync"
 	"time"
 )
 
@@ -19,6 +20,7 @@ type Client struct {
 	isClosed         atomicBool        // 是否已关闭
 	receiveMessageCh chan *connMessage // conn接收消息channel
 	sendMessageCh    chan *connMessage // conn发送消息channel
+	rooms            sync.Map          // 已加入的房间
 }
 
 func (c *Client) GetFd() string {
@@ -5,12 +5,23 @@ import "time"
 const (
 	shutdownPollIntervalMax  = 500 * time.Millisecond // 优雅关闭进程最大重复尝试间隔时长
 	heartbeatTimeoutDuration = time.Second * 100      // 连接心跳超时时间，强制关闭连接
+	transportBatchSize       = 100                    // 消息投递单批最多接收的消息数
+	transportBlockTimeout    = time.Second            // 消息投递无消息时阻塞等待的最长时长
+	transportStreamMaxLen    = 100000                 // 消息流最多保留的消息数（近似值）
+	transportStreamGroup     = "ws"                   // 消息流的消费者组名称
 
 	ServerFd         = "server"
 	ServerList       = "ws:server_list:%s"        // 记录ws服务器列表：ws:server_list:{app_id}
 	ServerMsgPool    = "ws:server_msg_pool:%s"    // 记录服务器消息列表：ws:server_msg_pool:{server_id} => [fd_or_server:content]
-	ServerClientList = "ws:server_client_list:%s" // 记录服务器的连接记录hash表：ws:server_conn_list:{server_id} => uid => fd:connect_time:last_active_time
-	ClientInfoKey    = "ws:%s:client:%s"          // 记录集群用户连接信息（有效期十分钟，需要在心跳时不断续期）：ws:{appid}:client:{uid} => server_id:fd
+	ServerMsgChannel = "ws:server_msg_channel:%s" // 服务器消息发布订阅频道：ws:server_msg_channel:{server_id} => fd_or_server:content
+	ServerMsgStream  = "ws:server_msg_stream:%s"  // 记录服务器消息流：ws:server_msg_stream:{server_id} => [message => fd_or_server:content]
+	ServerConnList   = "ws:server_conn_list:%s"   // 记录服务器的连接记录hash表：ws:server_conn_list:{server_id} => uid:fd => fd:connect_time:last_active_time:device
+	ServerClientList = "ws:server_client_list:%s" // Deprecated: 单连接版本的连接记录 ws:server_client_list:{server_id} => uid => fd:connect_time:last_active_time，已由 ServerConnList 替代，兼容旧版本服务器保留一个版本
+	ClientInfoKey    = "ws:%s:client:%s"          // Deprecated: 单连接版本的用户连接信息 ws:{appid}:client:{uid} => server_id:fd，已由 ClientSetKey 替代，兼容旧版本服务器保留一个版本
+	ClientSetKey     = "ws:%s:clients:%s"         // 记录集群用户所有连接（有效期十分钟，需要在心跳时不断续期）：ws:{appid}:clients:{uid} => set(server_id:fd:device)
+	RoomMemberList   = "ws:%s:room:%s"            // 记录集群房间成员：ws:{appid}:room:{room} => set(server_id:fd:uid)
+	RoomServerList   = "ws:%s:room_servers:%s"    // 记录集群房间成员所在的服务器：ws:{appid}:room_servers:{room} => set(server_id)
+	ServerRoomList   = "ws:server_room_list:%s"   // 记录服务器的连接加入过的房间：ws:server_room_list:{server_id} => set(room)
 
 	EventConnect    = "connect" // 上线通知（触发消息事件）
 	EventOffline    = "offline" // 下线通知（触发消息事件）
@@ -18,6 +29,10 @@ const (
 	EventPing       = "ping"    // ping（目标：服务器）
 	EventPong       = "pong"    // pong（目标：客户端）
 
+	EventBroadcast = "broadcast"  // 广播（目标：服务器）
+	EventRoomJoin  = "room_join"  // 加入房间（目标：服务器）
+	EventRoomLeave = "room_leave" // 离开房间（目标：服务器）
+
 	LangTc = "tc" // 繁体
 	LangEn = "en" // 英文
 )
@@ -1,6 +1,7 @@
 package ws
 
 import (
+	"context"
 	"net/http"
 	"sync/atomic"
 )
@@ -25,6 +26,19 @@ type Logger interface {
 	Error(msg string, keyValue ...string)
 }
 
+// Transport 集群服务器间消息投递：消息格式 fd:content，fd为 ServerFd 时为发给服务器的消息
+type Transport interface {
+	// Publish 投递消息至指定服务器
+	Publish(ctx context.Context, serverID, message string) error
+	// Consume 持续批量接收投递至指定服务器的消息，阻塞直至ctx结束或出错
+	//   - handler 按投递顺序处理一批消息，返回后消息视为已投递
+	Consume(ctx context.Context, serverID string, handler func(messages []string)) error
+	// Renew 续期指定服务器的消息存储有效期
+	Renew(ctx context.Context, serverID string) error
+	// Remove 删除指定服务器的消息存储：服务器关闭、掉线时调用
+	Remove(ctx context.Context, serverID string) error
+}
+
 type atomicBool int32
 
 func (b *atomicBool) isTrue() bool { return atomic.LoadInt32((*int32)(b)) != 0 }
@@ -69,6 +83,16 @@ type offlinePayload struct {
 	Remark string `json:"remark"`
 }
 
+type roomPayload struct {
+	Fd   string `json:"fd"`
+	Room string `json:"room"`
+}
+
+type broadcastPayload struct {
+	Room    string `json:"room"`    // 房间，为空时推送至服务器的所有连接
+	Message string `json:"message"` // 推送给客户端的消息
+}
+
 // Request 接收用户端消息结构体
 type Request struct {
 	ID        int64  `json:"id"`         // 消息id，ws服务器生成（微秒时间戳）
@@ -106,10 +130,18 @@ type ServerInfo struct {
 type ConnectInfo struct {
 	Fd             string `json:"fd"`               // fd连接id
 	Uid            string `json:"uid"`              // 用户id
+	Device         string `json:"device"`           // Device (app h5 stb)
 	ConnectTime    int64  `json:"connect_time"`     // 连接时间
 	LastActiveTime int64  `json:"last_active_time"` // 最后存活时间（客户端发送消息、发送心跳会更新）
 }
 
+// RoomMember 房间成员
+type RoomMember struct {
+	ServerID string `json:"server_id"` // 连接所在服务器id
+	Fd       string `json:"fd"`        // fd连接id
+	Uid      string `json:"uid"`       // 用户id
+}
+
 type ConnectionResult struct {
 	Total       int64         `json:"total"`
 	Cursor      int64         `json:"cursor"`
@@ -9,4 +9,5 @@ var (
 	ErrWsClientDoNotExist  = errors.New("ws.error.websocket.client.do.not.exist")
 	ErrWsClientInfoError   = errors.New("ws.error.websocket.client.info.error")
 	ErrWsUserNotLoginError = errors.New("ws.error.websocket.user.not_login.error")
+	ErrWsTransportClosed   = errors.New("ws.error.websocket.transport.closed")
 )
@@ -0,0 +1,281 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"strings"
+	"time"
+)
+
+// 房间成员：集合成员格式 server_id:fd:uid
+func roomMember(serverID, fd, uid string) string {
+	return fmt.Sprintf("%s:%s:%s", serverID, fd, uid)
+}
+
+// 解析房间成员：server_id:fd:uid
+func parseRoomMember(member string) (rm RoomMember, ok bool) {
+	arr := strings.SplitN(member, ":", 3)
+	if len(arr) != 3 || arr[0] == "" || arr[1] == "" {
+		return rm, false
+	}
+	return RoomMember{ServerID: arr[0], Fd: arr[1], Uid: arr[2]}, true
+}
+
+// 当前服务器的房间成员：room => set(fd)
+type localRooms map[string]map[string]struct{}
+
+// Join 加入房间，可在事件处理器中调用
+func (c *Client) Join(room string) (err error) {
+	if c.isClosed.isTrue() {
+		return ErrWsClientClosed
+	}
+
+	if err = c.server.joinRoom(c, room); err != nil {
+		return
+	}
+	c.rooms.Store(room, struct{}{})
+
+	// 加入期间连接已关闭：下线清理可能早于加入完成
+	if c.isClosed.isTrue() {
+		_ = c.server.leaveRoom(c, room)
+		return ErrWsClientClosed
+	}
+	return
+}
+
+// Leave 离开房间，可在事件处理器中调用
+func (c *Client) Leave(room string) (err error) {
+	c.rooms.Delete(room)
+	return c.server.leaveRoom(c, room)
+}
+
+// Rooms 获取已加入的房间
+func (c *Client) Rooms() (rooms []string) {
+	rooms = make([]string, 0)
+	c.rooms.Range(func(key, value any) bool {
+		rooms = append(rooms, key.(string))
+		return true
+	})
+	return
+}
+
+// 是否已加入房间
+func (c *Client) inRoom(room string) bool {
+	_, ok := c.rooms.Load(room)
+	return ok
+}
+
+// 保存房间成员：ws:{appid}:room:{room} => set(server_id:fd:uid)
+//   - 同时记录房间成员所在的服务器便于广播，记录当前服务器的房间便于服务器掉线时清理
+func (s *Server) joinRoom(client *Client, room string) (err error) {
+	s.logger.Debug("websocket service client join room",
+		"appid", s.appid, "server_id", s.id, "fd", client.fd, "uid", client.GetUid(), "room", room)
+
+	s.roomLock.Lock()
+	defer s.roomLock.Unlock()
+
+	pipe := s.redis.TxPipeline()
+	pipe.SAdd(s.ctx, fmt.Sprintf(RoomMemberList, s.appid, room), roomMember(s.id, client.fd, client.GetUid()))
+	pipe.SAdd(s.ctx, fmt.Sprintf(RoomServerList, s.appid, room), s.id)
+	pipe.SAdd(s.ctx, fmt.Sprintf(ServerRoomList, s.id), room)
+	if _, err = pipe.Exec(s.ctx); err != nil {
+		return
+	}
+
+	if s.roomClients[room] == nil {
+		s.roomClients[room] = make(map[string]struct{})
+	}
+	s.roomClients[room][client.fd] = struct{}{}
+	return
+}
+
+// 删除房间成员：当前服务器已无该房间的成员时一并删除房间所在服务器记录
+func (s *Server) leaveRoom(client *Client, room string) (err error) {
+	s.logger.Debug("websocket service client leave room",
+		"appid", s.appid, "server_id", s.id, "fd", client.fd, "uid", client.GetUid(), "room", room)
+
+	s.roomLock.Lock()
+	defer s.roomLock.Unlock()
+
+	delete(s.roomClients[room], client.fd)
+
+	pipe := s.redis.TxPipeline()
+	pipe.SRem(s.ctx, fmt.Sprintf(RoomMemberList, s.appid, room), roomMember(s.id, client.fd, client.GetUid()))
+	if len(s.roomClients[room]) == 0 {
+		delete(s.roomClients, room)
+		pipe.SRem(s.ctx, fmt.Sprintf(RoomServerList, s.appid, room), s.id)
+	}
+	_, err = pipe.Exec(s.ctx)
+	return
+}
+
+// 离开已加入的所有房间：连接下线时清理
+func (s *Server) leaveAllRooms(client *Client) {
+	for _, room := range client.Rooms() {
+		_ = client.Leave(room)
+	}
+}
+
+// 删除指定服务器上的所有房间成员：依据服务器的房间记录 ws:server_room_list:{server_id} => set(room)
+func (s *Server) removeServerRooms(serverID string) {
+	key := fmt.Sprintf(ServerRoomList, serverID)
+	rooms, err := s.redis.SMembers(s.ctx, key).Result()
+	if err != nil {
+		return
+	}
+
+	for _, room := range rooms {
+		roomKey := fmt.Sprintf(RoomMemberList, s.appid, room)
+		iter := s.redis.SScan(s.ctx, roomKey, 0, serverID+":*", 100).Iterator()
+		for iter.Next(s.ctx) {
+			s.redis.SRem(s.ctx, roomKey, iter.Val())
+		}
+		s.redis.SRem(s.ctx, fmt.Sprintf(RoomServerList, s.appid, room), serverID)
+	}
+	s.redis.Del(s.ctx, key)
+}
+
+// JoinRoom 用户的所有连接加入房间：连接可位于集群内任意服务器
+func (s *Server) JoinRoom(uid, room string) (err error) {
+	return s.dispatchRoomMessage(uid, room, EventRoomJoin)
+}
+
+// LeaveRoom 用户的所有连接离开房间：连接可位于集群内任意服务器
+func (s *Server) LeaveRoom(uid, room string) (err error) {
+	return s.dispatchRoomMessage(uid, room, EventRoomLeave)
+}
+
+// 向用户每个连接所在服务器发送加入、离开房间消息
+func (s *Server) dispatchRoomMessage(uid, room, event string) (err error) {
+	sessions, err := s.getClientSessions(uid)
+	if err != nil {
+		return
+	}
+
+	if len(sessions) == 0 {
+		return ErrWsUserNotLoginError
+	}
+
+	var errs []error
+	for _, cs := range sessions {
+		msg := Response{
+			ID:       time.Now().UnixMicro(),
+			From:     ServerFd,
+			To:       ServerFd,
+			Device:   cs.device,
+			Event:    event,
+			Payload:  roomPayload{Fd: cs.fd, Room: room},
+			SendTime: time.Now().Unix(),
+		}
+		if err := s.dispatchMessage(cs.serverID, ServerFd, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RoomMembers 获取房间内的所有连接（集群）
+func (s *Server) RoomMembers(room string) (members []RoomMember, err error) {
+	members = make([]RoomMember, 0)
+
+	result, err := s.redis.SMembers(s.ctx, fmt.Sprintf(RoomMemberList, s.appid, room)).Result()
+	if err != nil {
+		return
+	}
+
+	for _, member := range result {
+		if rm, ok := parseRoomMember(member); ok {
+			members = append(members, rm)
+		}
+	}
+	return
+}
+
+// Broadcast 向房间内的所有连接推送消息：消息写入房间成员所在的每个server对应的消息池
+func (s *Server) Broadcast(room, event string, payload interface{}) (err error) {
+	servers, err := s.redis.SMembers(s.ctx, fmt.Sprintf(RoomServerList, s.appid, room)).Result()
+	if err != nil {
+		return
+	}
+
+	return s.broadcast(servers, room, event, payload)
+}
+
+// BroadcastAll 向集群内的所有连接推送消息：消息写入每个server对应的消息池
+func (s *Server) BroadcastAll(event string, payload interface{}) (err error) {
+	serverList, err := s.GetServerList()
+	if err != nil {
+		return
+	}
+
+	servers := make([]string, 0, len(serverList))
+	for _, server := range serverList {
+		servers = append(servers, server.ServerID)
+	}
+
+	return s.broadcast(servers, "", event, payload)
+}
+
+// 向指定服务器发送广播消息，room为空时推送至服务器的所有连接
+func (s *Server) broadcast(servers []string, room, event string, payload interface{}) (err error) {
+	b, err := json.Marshal(Response{
+		ID:       time.Now().UnixMicro(),
+		From:     ServerFd,
+		To:       room,
+		Event:    event,
+		Payload:  payload,
+		SendTime: time.Now().Unix(),
+	})
+	if err != nil {
+		return
+	}
+
+	s.logger.Debug("websocket service broadcast message",
+		"appid", s.appid, "server_id", s.id, "room", room, "event", event, "servers", strings.Join(servers, ","))
+
+	msg := Response{
+		ID:       time.Now().UnixMicro(),
+		From:     ServerFd,
+		To:       ServerFd,
+		Event:    EventBroadcast,
+		Payload:  broadcastPayload{Room: room, Message: string(b)},
+		SendTime: time.Now().Unix(),
+	}
+
+	var errs []error
+	for _, serverID := range servers {
+		if err := s.dispatchMessage(serverID, ServerFd, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// 推送广播消息至当前服务器的连接，room为空时推送至所有连接
+func (s *Server) broadcastLocal(room string, message []byte) {
+	if room == "" {
+		s.clients.Range(func(key, value any) bool {
+			if client, ok := value.(*Client); ok {
+				_ = client.write(websocket.TextMessage, message)
+			}
+			return true
+		})
+		return
+	}
+
+	// 按房间成员索引查找连接：复制后释放锁，避免推送阻塞房间成员变更
+	s.roomLock.Lock()
+	fds := make([]string, 0, len(s.roomClients[room]))
+	for fd := range s.roomClients[room] {
+		fds = append(fds, fd)
+	}
+	s.roomLock.Unlock()
+
+	for _, fd := range fds {
+		if client, err := s.getClientByFd(fd); err == nil {
+			_ = client.write(websocket.TextMessage, message)
+		}
+	}
+}
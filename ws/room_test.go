@@ -0,0 +1,198 @@
+package ws
+
+import (
+	"fmt"
+	"github.com/alicebob/miniredis/v2"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// roomServers 读取房间成员所在的服务器记录
+func roomServers(mr *miniredis.Miniredis, room string) string {
+	servers, _ := mr.Members(fmt.Sprintf(RoomServerList, "app", room))
+	sort.Strings(servers)
+	return strings.Join(servers, ",")
+}
+
+func TestParseRoomMember(t *testing.T) {
+	cases := []struct {
+		member string
+		want   RoomMember
+		ok     bool
+	}{
+		{"s1:fd1:u1", RoomMember{ServerID: "s1", Fd: "fd1", Uid: "u1"}, true},
+		{"s1:fd1:", RoomMember{ServerID: "s1", Fd: "fd1"}, true},
+		{"s1:fd1:u1:x", RoomMember{ServerID: "s1", Fd: "fd1", Uid: "u1:x"}, true},
+		{"s1:fd1", RoomMember{}, false},
+		{":fd1:u1", RoomMember{}, false},
+		{"s1::u1", RoomMember{}, false},
+	}
+	for _, c := range cases {
+		rm, ok := parseRoomMember(c.member)
+		if ok != c.ok || rm != c.want {
+			t.Fatalf("parse %q = %+v %v, want %+v %v", c.member, rm, ok, c.want, c.ok)
+		}
+	}
+	if member := roomMember("s1", "fd1", "u1"); member != "s1:fd1:u1" {
+		t.Fatalf("member = %q, want s1:fd1:u1", member)
+	}
+}
+
+func TestRoomJoinLeave(t *testing.T) {
+	mr := miniredis.RunT(t)
+	s1 := newTestServer(t, mr, "s1")
+	s2 := newTestServer(t, mr, "s2")
+	a := newTestClient(s1, "fd-a", "u1", "app")
+	b := newTestClient(s1, "fd-b", "u2", "app")
+	c := newTestClient(s2, "fd-c", "u3", "app")
+
+	for _, client := range []*Client{a, b, c} {
+		if err := client.Join("lobby"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 重复加入不影响成员记录
+	if err := a.Join("lobby"); err != nil {
+		t.Fatal(err)
+	}
+	if servers := roomServers(mr, "lobby"); servers != "s1,s2" {
+		t.Fatalf("room servers = %q, want s1,s2", servers)
+	}
+	if members, _ := s1.RoomMembers("lobby"); len(members) != 3 {
+		t.Fatalf("room members = %+v, want 3", members)
+	}
+
+	// 服务器仍有其他成员时保留房间所在服务器记录
+	if err := a.Leave("lobby"); err != nil {
+		t.Fatal(err)
+	}
+	if servers := roomServers(mr, "lobby"); servers != "s1,s2" {
+		t.Fatalf("room servers = %q, want s1,s2 while b in room", servers)
+	}
+	if err := b.Leave("lobby"); err != nil {
+		t.Fatal(err)
+	}
+	if servers := roomServers(mr, "lobby"); servers != "s2" {
+		t.Fatalf("room servers = %q, want s2", servers)
+	}
+	if a.inRoom("lobby") || len(a.Rooms()) != 0 {
+		t.Fatalf("rooms = %v, want none", a.Rooms())
+	}
+
+	// 广播仅投递至房间成员所在的服务器
+	if err := s1.Broadcast("lobby", "notice", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if messages, _ := mr.List(fmt.Sprintf(ServerMsgPool, "s2")); len(messages) != 1 {
+		t.Fatalf("s2 messages = %v, want broadcast", messages)
+	}
+	if mr.Exists(fmt.Sprintf(ServerMsgPool, "s1")) {
+		t.Fatal("broadcast dispatched to s1 without room members")
+	}
+}
+
+func TestRemoveServerRooms(t *testing.T) {
+	mr := miniredis.RunT(t)
+	s1 := newTestServer(t, mr, "s1")
+	s2 := newTestServer(t, mr, "s2")
+
+	for _, room := range []string{"lobby", "vip"} {
+		if err := newTestClient(s2, "fd-"+room, "u2", "app").Join(room); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := newTestClient(s1, "fd-a", "u1", "app").Join("lobby"); err != nil {
+		t.Fatal(err)
+	}
+
+	// s2掉线后由s1清理
+	s1.removeServerRooms("s2")
+
+	members, _ := s1.RoomMembers("lobby")
+	if len(members) != 1 || members[0].ServerID != "s1" {
+		t.Fatalf("lobby members = %+v, want s1 member only", members)
+	}
+	if members, _ = s1.RoomMembers("vip"); len(members) != 0 {
+		t.Fatalf("vip members = %+v, want none", members)
+	}
+	if servers := roomServers(mr, "lobby"); servers != "s1" {
+		t.Fatalf("lobby servers = %q, want s1", servers)
+	}
+	if servers := roomServers(mr, "vip"); servers != "" {
+		t.Fatalf("vip servers = %q, want none", servers)
+	}
+	if mr.Exists(fmt.Sprintf(ServerRoomList, "s2")) {
+		t.Fatal("s2 room list not deleted")
+	}
+}
+
+func TestJoinRoomFailed(t *testing.T) {
+	mr := miniredis.RunT(t)
+	s := newTestServer(t, mr, "s1")
+	a := newTestClient(s, "fd-a", "u1", "app")
+
+	// 写入失败时不记录已加入的房间
+	mr.SetError("ERR unavailable")
+	if err := a.Join("lobby"); err == nil {
+		t.Fatal("join succeeded while redis unavailable")
+	}
+	if a.inRoom("lobby") || len(s.roomClients["lobby"]) != 0 {
+		t.Fatalf("rooms = %v local %v, want none", a.Rooms(), s.roomClients)
+	}
+
+	// 重复加入失败不影响已加入的房间
+	mr.SetError("")
+	if err := a.Join("lobby"); err != nil {
+		t.Fatal(err)
+	}
+	mr.SetError("ERR unavailable")
+	if err := a.Join("lobby"); err == nil {
+		t.Fatal("join succeeded while redis unavailable")
+	}
+	if _, ok := s.roomClients["lobby"]["fd-a"]; !a.inRoom("lobby") || !ok {
+		t.Fatalf("rooms = %v local %v, want lobby kept", a.Rooms(), s.roomClients)
+	}
+}
+
+func TestBroadcastLocal(t *testing.T) {
+	mr := miniredis.RunT(t)
+	s := newTestServer(t, mr, "s1")
+
+	a := newTestClient(s, "fd-a", "u1", "app")
+	b := newTestClient(s, "fd-b", "u2", "app")
+	c := newTestClient(s, "fd-c", "u3", "app")
+	for _, client := range []*Client{a, b, c} {
+		client.sendMessageCh = make(chan *connMessage, 10)
+		s.clients.Store(client.fd, client)
+	}
+	// 已断开但仍在房间成员索引中的连接
+	gone := newTestClient(s, "fd-gone", "u4", "app")
+	for _, client := range []*Client{a, c, gone} {
+		if err := client.Join("lobby"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Leave("lobby"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 仅推送至房间成员
+	s.broadcastLocal("lobby", []byte("room"))
+	if got := sentMessages(a); len(got) != 1 || got[0] != "room" {
+		t.Fatalf("fd-a messages = %v, want room", got)
+	}
+	for _, client := range []*Client{b, c} {
+		if got := sentMessages(client); len(got) != 0 {
+			t.Fatalf("%s messages = %v, want none", client.fd, got)
+		}
+	}
+
+	// 房间为空时推送至所有连接
+	s.broadcastLocal("", []byte("all"))
+	for _, client := range []*Client{a, b, c} {
+		if got := sentMessages(client); len(got) != 1 || got[0] != "all" {
+			t.Fatalf("%s messages = %v, want all", client.fd, got)
+		}
+	}
+}
@@ -24,12 +24,16 @@ type Server struct {
 	redis               *redis.Client            // redis客户端
 	clients             sync.Map                 // 保存当前服务器的客户端
 	heartBeatTicker     *time.Ticker             // 客户端心跳检测ticker
-	messagePoolTicker   *time.Ticker             // 消息池ticker
+	transport           Transport                // 集群服务器间消息投递
+	stopConsume         context.CancelFunc       // 停止接收投递消息
 	selfCheckingTicker  *time.Ticker             // 服务自检ticker
 	upgrader            websocket.Upgrader       // upgrader
 	authFunc            authFunc                 // 鉴权func
 	eventHandlers       sync.Map                 // 保存注册的事件处理器
 	acceptClientCh      chan *Client             // 客户端连接channel
+	sessionPolicy       SessionPolicy            // 用户连接策略
+	roomLock            sync.Mutex               // 房间成员变更锁
+	roomClients         localRooms               // 当前服务器的房间成员
 	logger              Logger                   // logger
 	messageRequestHook  *messageRequestHookFunc  // 接收到客户端消息hook：可用于保存消息记录
 	messageResponseHook *messageResponseHookFunc // 发送消息给客户端hook：可用于保存消息记录
@@ -43,9 +47,10 @@ func NewServer(appid string, subProtocols []string, redisCli *redis.Client, logg
 		redis:              redisCli,
 		clients:            sync.Map{},
 		heartBeatTicker:    time.NewTicker(time.Second * 10),
-		messagePoolTicker:  time.NewTicker(time.Millisecond * 100),
+		transport:          NewListTransport(redisCli),
 		selfCheckingTicker: time.NewTicker(time.Second * 30),
 		acceptClientCh:     make(chan *Client, 5),
+		roomClients:        make(localRooms),
 		logger:             logger,
 		upgrader: websocket.Upgrader{
 			HandshakeTimeout:  5 * time.Second,
@@ -71,7 +76,7 @@ func (s *Server) Serve() error {
 	s.logger.Info("websocket service start")
 
 	s.register()
-	go s.tickMessagePool()
+	s.startConsumeMessage()
 	go s.checkHeartbeatTimeout()
 	go s.selfChecking()
 	s.accept()
@@ -141,7 +146,7 @@ func (s *Server) Shutdown(ctx context.Context) (err error) {
 	s.shutdown.setTrue()
 	s.stopAccept()
 	s.stopHeartbeatCheck()
-	s.stopMessagePoolTicker()
+	s.stopConsumeMessage()
 	s.closeAllClient()
 	s.deleteAllClientConnectLog()
 	s.stopSelfChecking()
@@ -200,7 +205,9 @@ func (s *Server) unregister() {
 	// 从服务器集群中删除
 	s.redis.HDel(s.ctx, fmt.Sprintf(ServerList, s.appid), s.id)
 	// 删除该服务器对应的消息池
-	s.redis.Del(s.ctx, fmt.Sprintf(ServerMsgPool, s.id))
+	_ = s.transport.Remove(s.ctx, s.id)
+	// 删除该服务器的房间记录
+	s.redis.Del(s.ctx, fmt.Sprintf(ServerRoomList, s.id))
 }
 
 // GetServerList 获取集群内服务器列表（相同appid）：服务器id => 服务器最后存活时间
@@ -213,7 +220,7 @@ func (s *Server) GetServerList() (servers []ServerInfo, err error) {
 	}
 
 	for serverID, lastActiveTime := range result {
-		connectNum, _ := s.redis.HLen(s.ctx, fmt.Sprintf(ServerClientList, serverID)).Result()
+		connectNum, _ := s.redis.HLen(s.ctx, s.connectLogKey(serverID)).Result()
 		servers = append(servers, ServerInfo{
 			AppID:          s.appid,
 			ServerID:       serverID,
@@ -239,9 +246,10 @@ func (s *Server) selfChecking() {
 		s.redis.HSet(s.ctx, fmt.Sprintf(ServerList, s.appid), s.id, time.Now().Unix())
 
 		// 续期：当前服务器消息池有效期
-		s.redis.Expire(s.ctx, fmt.Sprintf(ServerMsgPool, s.id), time.Minute*10)
+		_ = s.transport.Renew(s.ctx, s.id)
 
 		// 续期：当前服务器连接记录有效期
+		s.redis.Expire(s.ctx, fmt.Sprintf(ServerConnList, s.id), time.Minute*10)
 		s.redis.Expire(s.ctx, fmt.Sprintf(ServerClientList, s.id), time.Minute*10)
 	}
 
@@ -273,37 +281,89 @@ func (s *Server) removeOfflineServer() (err error) {
 			// 从服务器集群中删除
 			s.redis.HDel(s.ctx, fmt.Sprintf(ServerList, s.appid), server.ServerID)
 			// 删除该服务器对应的消息池
-			s.redis.Del(s.ctx, fmt.Sprintf(ServerMsgPool, server.ServerID))
+			_ = s.transport.Remove(s.ctx, server.ServerID)
+			// 删除该服务器上的用户连接关系、房间成员
+			s.removeServerSessions(server.ServerID)
+			s.removeServerRooms(server.ServerID)
 			// 删除该服务器的连接记录
-			s.redis.Del(s.ctx, fmt.Sprintf(ServerClientList, server.ServerID))
+			s.redis.Del(s.ctx, fmt.Sprintf(ServerConnList, server.ServerID), fmt.Sprintf(ServerClientList, server.ServerID))
 		}
 	}
 	return
 }
 
-func (s *Server) tickMessagePool() {
-	defer s.messagePoolTicker.Stop()
+// SetTransport 设置集群服务器间消息投递方式，默认 NewListTransport，需在 Serve 之前调用
+//   - 集群内所有服务器需使用相同的投递方式
+func (s *Server) SetTransport(transport Transport) {
+	s.logger.Info("websocket service set transport", "appid", s.appid, "server_id", s.id)
 
-	s.logger.Info("websocket service start tick message pool", "appid", s.appid, "server_id", s.id)
+	s.transport = transport
+}
+
+// 开始接收投递至当前服务器的消息
+func (s *Server) startConsumeMessage() {
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.stopConsume = cancel
+
+	go s.consumeMessage(ctx)
+}
+
+// 持续批量接收消息，出错时稍后重试
+func (s *Server) consumeMessage(ctx context.Context) {
+	s.logger.Info("websocket service start consume message", "appid", s.appid, "server_id", s.id)
+
+	for {
+		err := s.transport.Consume(ctx, s.id, s.handleMessages)
+		if ctx.Err() != nil {
+			return
+		}
 
-	for range s.messagePoolTicker.C {
-		result, err := s.redis.RPop(s.ctx, fmt.Sprintf(ServerMsgPool, s.id)).Result()
 		if err != nil {
-			continue
+			s.logger.Error("websocket service consume message failed",
+				"appid", s.appid, "server_id", s.id, "err", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
 		}
+	}
+}
 
+// 处理一批消息：fd:message
+//   - 按fd分组并发处理，同一fd的消息按投递顺序处理，全部处理完毕后返回（返回后消息视为已投递）
+func (s *Server) handleMessages(messages []string) {
+	fds := make([]string, 0)
+	groups := make(map[string][][]byte)
+	for _, result := range messages {
 		fd, message, found := strings.Cut(result, ":")
 		if !found {
 			continue
 		}
 
-		// 发给服务器的消息
-		if fd == ServerFd {
-			go s.serverMsgHandler([]byte(message))
-		} else {
-			go s.send(fd, []byte(message))
+		if _, ok := groups[fd]; !ok {
+			fds = append(fds, fd)
 		}
+		groups[fd] = append(groups[fd], []byte(message))
+	}
+
+	var wg sync.WaitGroup
+	for _, fd := range fds {
+		wg.Add(1)
+		go func(fd string, messages [][]byte) {
+			defer wg.Done()
+			for _, message := range messages {
+				// 发给服务器的消息
+				if fd == ServerFd {
+					_ = s.serverMsgHandler(message)
+				} else {
+					_ = s.send(fd, message)
+				}
+			}
+		}(fd, groups[fd])
 	}
+	wg.Wait()
 }
 
 // 发送消息
@@ -315,11 +375,13 @@ func (s *Server) send(fd string, message []byte) (err error) {
 	return client.write(websocket.TextMessage, message)
 }
 
-func (s *Server) stopMessagePoolTicker() {
-	s.logger.Info("websocket service stop message pool tick", "appid", s.appid, "server_id", s.id)
+func (s *Server) stopConsumeMessage() {
+	s.logger.Info("websocket service stop consume message", "appid", s.appid, "server_id", s.id)
 
-	s.messagePoolTicker.Stop()
-	s.redis.Del(s.ctx, fmt.Sprintf(ServerMsgPool, s.id))
+	if s.stopConsume != nil {
+		s.stopConsume()
+	}
+	_ = s.transport.Remove(s.ctx, s.id)
 }
 
 // 处理连接
@@ -361,7 +423,7 @@ func (s *Server) checkHeartbeatTimeout() {
 			}
 
 			// 更新连接记录
-			_ = s.writeClientConnectLog(client.fd, client.GetUid(), client.connectTime, client.lastActiveTime)
+			_ = s.writeClientConnectLog(client.fd, client.GetUid(), client.GetUserDevice(), client.connectTime, client.lastActiveTime)
 
 			return true
 		})
@@ -421,30 +483,9 @@ func (s *Server) emitEvent(event string, client *Client, msg Request) {
 	}
 }
 
-// ForceOffline 强制下线
+// ForceOffline 强制下线用户的所有连接
 func (s *Server) ForceOffline(uid, remark string) (err error) {
-	serverID, fd, err := s.getClientInfoByUid(uid)
-	if err != nil {
-		return
-	}
-
-	if serverID == "" || fd == "" {
-		return
-	}
-
-	s.logger.Info("websocket service send force offline message",
-		"appid", s.appid, "server_id", s.id, "uid", uid, "remark", remark)
-
-	// 发送强制下线消息
-	msg := Response{
-		ID:       time.Now().UnixMicro(),
-		From:     ServerFd,
-		To:       ServerFd,
-		Event:    EventOffline,
-		Payload:  offlinePayload{Fd: fd, Remark: remark},
-		SendTime: time.Now().Unix(),
-	}
-	return s.dispatchMessage(serverID, ServerFd, msg)
+	return s.ForceOfflineDevice(uid, "", remark)
 }
 
 // 系统消息处理
@@ -467,10 +508,33 @@ func (s *Server) serverMsgHandler(content []byte) (err error) {
 			return
 		}
 
-		// 通知用户并强制下线
+		// 通知用户并强制下线：延迟关闭连接不阻塞后续消息处理
 		_ = s.send(offlineMsg.Fd, content)
-		time.Sleep(time.Second)
-		err = s.closeClientByFd(offlineMsg.Fd, offlineMsg.Remark)
+		go func() {
+			time.Sleep(time.Second)
+			_ = s.closeClientByFd(offlineMsg.Fd, offlineMsg.Remark)
+		}()
+	case EventRoomJoin, EventRoomLeave: // 加入、离开房间
+		var roomMsg roomPayload
+		if err = json.Unmarshal(payloadBytes, &roomMsg); err != nil {
+			return
+		}
+
+		var client *Client
+		if client, err = s.getClientByFd(roomMsg.Fd); err != nil {
+			return
+		}
+		if resp.Event == EventRoomJoin {
+			return client.Join(roomMsg.Room)
+		}
+		return client.Leave(roomMsg.Room)
+	case EventBroadcast: // 广播
+		var broadcastMsg broadcastPayload
+		if err = json.Unmarshal(payloadBytes, &broadcastMsg); err != nil {
+			return
+		}
+
+		s.broadcastLocal(broadcastMsg.Room, []byte(broadcastMsg.Message))
 	}
 
 	return
@@ -480,54 +544,42 @@ func (s *Server) registerClient(client *Client) {
 	s.logger.Info("websocket service register client",
 		"appid", s.appid, "server_id", s.id, "fd", client.fd, "uid", client.GetUid())
 
-	// 强制下线用户已有连接
-	_ = s.ForceOffline(client.GetUid(), "user login elsewhere, force offline")
+	// 按连接策略强制下线用户已有连接
+	switch s.sessionPolicy {
+	case SessionSingle:
+		_ = s.ForceOffline(client.GetUid(), "user login elsewhere, force offline")
+	case SessionPerDevice:
+		_ = s.ForceOfflineDevice(client.GetUid(), client.GetUserDevice(), "user login elsewhere, force offline")
+	}
 
 	// 保存连接记录
-	_ = s.writeClientConnectLog(client.fd, client.GetUid(), client.connectTime, client.lastActiveTime)
+	_ = s.writeClientConnectLog(client.fd, client.GetUid(), client.GetUserDevice(), client.connectTime, client.lastActiveTime)
 
 	// 接收加入新ws-client
 	s.clients.Store(client.fd, client)
 
-	// 用户连接关系: ws:{appid}:client:{uid} => server_id:fd
-	s.redis.Set(s.ctx,
-		fmt.Sprintf(ClientInfoKey, s.appid, client.GetUid()),
-		fmt.Sprintf("%s:%s", s.id, client.fd),
-		time.Minute*10)
-}
-
-// 获取用户连接信息
-func (s *Server) getClientInfoByUid(uid string) (serverID, fd string, err error) {
-	// 根据uid获取所在服务器&fd
-	result, err := s.redis.Get(s.ctx, fmt.Sprintf(ClientInfoKey, s.appid, uid)).Result()
-	if err != nil {
-		return
-	}
-
-	serverID, fd, found := strings.Cut(result, ":")
-	if result == "" || !found {
-		err = ErrWsClientInfoError
-		return
-	}
-	return
+	// 用户连接关系: ws:{appid}:clients:{uid} => set(server_id:fd:device)
+	s.addClientSession(client)
 }
 
 // 更新用户连接信息有效期
 func (s *Server) updateClientTTL(uid string) {
+	s.redis.Expire(s.ctx, fmt.Sprintf(ClientSetKey, s.appid, uid), time.Minute*10)
 	s.redis.Expire(s.ctx, fmt.Sprintf(ClientInfoKey, s.appid, uid), time.Minute*10)
 }
 
 // 清除用户连接信息
 func (s *Server) deleteClient(uid, fd string) {
+	client, err := s.getClientByFd(fd)
 	s.clients.Delete(fd)
 
 	// 删除连接记录
-	_ = s.deleteClientConnectLog(uid)
+	_ = s.deleteClientConnectLog(uid, fd)
 
-	// 删除用户连接关系
-	serverID, oldFd, _ := s.getClientInfoByUid(uid)
-	if serverID == s.id && oldFd == fd {
-		_, _ = s.redis.Del(s.ctx, fmt.Sprintf(ClientInfoKey, s.appid, uid)).Result()
+	// 删除用户连接关系、房间成员
+	if err == nil {
+		s.removeClientSession(uid, clientSession{serverID: s.id, fd: fd, device: client.GetUserDevice()})
+		s.leaveAllRooms(client)
 	}
 	return
 }
@@ -577,8 +629,8 @@ func (s *Server) GetServerConnections(serverID string, cursor, limit int64) (res
 
 	fmt.Println(strings.Repeat("=", 20), cursor, limit)
 
-	// 根据serverID获取连接列表：uid => fd:connect_time:last_active_time
-	key := fmt.Sprintf(ServerClientList, serverID)
+	// 根据serverID获取连接列表：uid:fd => fd:connect_time:last_active_time:device
+	key := s.connectLogKey(serverID)
 
 	// 获取总数
 	resp.Total, err = s.redis.HLen(s.ctx, key).Result()
@@ -595,7 +647,8 @@ func (s *Server) GetServerConnections(serverID string, cursor, limit int64) (res
 	for i, value := range result {
 		if i%2 == 1 {
 			connectInfo := s.parseClientConnectLog(value)
-			connectInfo.Uid = result[i-1]
+			// 旧版本服务器的连接记录字段为uid
+			connectInfo.Uid, _, _ = strings.Cut(result[i-1], ":")
 			resp.Connections = append(resp.Connections, connectInfo)
 		}
 	}
@@ -604,28 +657,51 @@ func (s *Server) GetServerConnections(serverID string, cursor, limit int64) (res
 	return
 }
 
-// 写入连接记录
-func (s *Server) writeClientConnectLog(fd, uid string, connectTime, lastActiveTime time.Time) (err error) {
-	key := fmt.Sprintf(ServerClientList, s.id)
-	_, err = s.redis.HSet(s.ctx, key, uid, fmt.Sprintf("%s:%d:%d", fd, connectTime.Unix(), lastActiveTime.Unix())).Result()
+// 获取服务器的连接记录key：旧版本服务器仅有 ServerClientList 连接记录
+func (s *Server) connectLogKey(serverID string) string {
+	key := fmt.Sprintf(ServerConnList, serverID)
+	if exists, _ := s.redis.Exists(s.ctx, key).Result(); exists > 0 {
+		return key
+	}
+	return fmt.Sprintf(ServerClientList, serverID)
+}
+
+// 写入连接记录：uid:fd => fd:connect_time:last_active_time:device
+//   - 同时写入旧版本格式 uid => fd:connect_time:last_active_time，供滚动升级期间的旧版本服务器读取
+func (s *Server) writeClientConnectLog(fd, uid, device string, connectTime, lastActiveTime time.Time) (err error) {
+	_, err = s.redis.TxPipelined(s.ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(s.ctx, fmt.Sprintf(ServerConnList, s.id), uid+":"+fd, fmt.Sprintf("%s:%d:%d:%s", fd, connectTime.Unix(), lastActiveTime.Unix(), device))
+		pipe.HSet(s.ctx, fmt.Sprintf(ServerClientList, s.id), uid, fmt.Sprintf("%s:%d:%d", fd, connectTime.Unix(), lastActiveTime.Unix()))
+		return nil
+	})
 	return
 }
 
-// 删除连接记录
-func (s *Server) deleteClientConnectLog(uid string) (err error) {
-	key := fmt.Sprintf(ServerClientList, s.id)
-	_, err = s.redis.HDel(s.ctx, key, uid).Result()
+// 删除连接记录：旧版本格式的连接记录仍为该连接时一并删除
+func (s *Server) deleteClientConnectLog(uid, fd string) (err error) {
+	_, err = s.redis.HDel(s.ctx, fmt.Sprintf(ServerConnList, s.id), uid+":"+fd).Result()
+	if err != nil {
+		return
+	}
+
+	legacyKey := fmt.Sprintf(ServerClientList, s.id)
+	if legacy, _ := s.redis.HGet(s.ctx, legacyKey, uid).Result(); s.parseClientConnectLog(legacy).Fd == fd {
+		_, err = s.redis.HDel(s.ctx, legacyKey, uid).Result()
+	}
 	return
 }
 
-// 解析连接log信息：uid:connect_time:last_active_time
+// 解析连接log信息：fd:connect_time:last_active_time[:device]
 func (s *Server) parseClientConnectLog(str string) (info ConnectInfo) {
-	arr := strings.Split(str, ":")
-	if len(arr) == 3 {
+	arr := strings.SplitN(str, ":", 4)
+	if len(arr) >= 3 {
 		info.Fd = arr[0]
 		info.ConnectTime = cast.ToInt64(arr[1])
 		info.LastActiveTime = cast.ToInt64(arr[2])
 	}
+	if len(arr) == 4 {
+		info.Device = arr[3]
+	}
 	return
 }
 
@@ -633,38 +709,12 @@ func (s *Server) parseClientConnectLog(str string) (info ConnectInfo) {
 func (s *Server) deleteAllClientConnectLog() {
 	s.logger.Info("websocket service delete client connect log", "appid", s.appid, "server_id", s.id)
 
-	key := fmt.Sprintf(ServerClientList, s.id)
-	_, _ = s.redis.Del(s.ctx, key).Result()
+	_, _ = s.redis.Del(s.ctx, fmt.Sprintf(ServerConnList, s.id), fmt.Sprintf(ServerClientList, s.id)).Result()
 }
 
-// SendMessage 向用户推送消息：将消息写入每个server对应的消息池
+// SendMessage 向用户推送消息：将消息写入用户每个连接所在server对应的消息池
 func (s *Server) SendMessage(uid string, event string, payload interface{}) (err error) {
-	msg := Response{
-		ID:       time.Now().UnixMicro(),
-		From:     ServerFd,
-		To:       uid,
-		Event:    event,
-		Payload:  payload,
-		SendTime: time.Now().Unix(),
-	}
-
-	s.logger.Debug("websocket service send message to client",
-		"appid", s.appid, "server_id", s.id, "uid", uid, "event", event)
-
-	// 触发响应钩子（用户不在线也需要保存消息记录）
-	go s.emitMessageResponseHook(msg)
-
-	// 根据uid获取所在服务器&fd
-	serverID, fd, err := s.getClientInfoByUid(uid)
-	if err != nil {
-		return err
-	}
-
-	if serverID == "" || fd == "" {
-		return ErrWsUserNotLoginError
-	}
-
-	return s.dispatchMessage(serverID, fd, msg)
+	return s.SendMessageToDevice(uid, "", event, payload)
 }
 
 func (s *Server) dispatchMessage(serverID, fd string, msg Response) (err error) {
@@ -677,9 +727,7 @@ func (s *Server) dispatchMessage(serverID, fd string, msg Response) (err error)
 		"appid", s.appid, "server_id", s.id, "target_server", serverID, "fd", fd)
 
 	// 消息内容格式：fd:message
-	_, err = s.redis.LPush(s.ctx, fmt.Sprintf(ServerMsgPool, serverID),
-		fmt.Sprintf("%s:%s", fd, string(b))).Result()
-	return
+	return s.transport.Publish(s.ctx, serverID, fmt.Sprintf("%s:%s", fd, string(b)))
 }
 
 // RegisterMessageRequestHook 注册钩子：接收到客户端消息hook：可用于保存消息记录
@@ -0,0 +1,80 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/alicebob/miniredis/v2"
+	"testing"
+)
+
+// sentMessages 读取已写入客户端发送通道的消息
+func sentMessages(client *Client) (messages []string) {
+	for {
+		select {
+		case msg := <-client.sendMessageCh:
+			messages = append(messages, string(msg.message))
+		default:
+			return
+		}
+	}
+}
+
+func TestHandleMessagesOrder(t *testing.T) {
+	mr := miniredis.RunT(t)
+	s := newTestServer(t, mr, "s1")
+
+	a := newTestClient(s, "fd-a", "u1", "app")
+	b := newTestClient(s, "fd-b", "u2", "app")
+	for _, client := range []*Client{a, b} {
+		client.sendMessageCh = make(chan *connMessage, 100)
+		s.clients.Store(client.fd, client)
+	}
+	if err := b.Join("lobby"); err != nil {
+		t.Fatal(err)
+	}
+
+	broadcast, _ := json.Marshal(Response{
+		Event:   EventBroadcast,
+		Payload: broadcastPayload{Room: "lobby", Message: "room"},
+	})
+	messages := []string{
+		"fd-a:a0",
+		"fd-b:b0",
+		"fd-a:a1",
+		ServerFd + ":" + string(broadcast),
+		"fd-a:a2",
+		"fd-b:b1",
+		"invalid",
+		"fd-unknown:x",
+	}
+	for i := 3; i < 50; i++ {
+		messages = append(messages, fmt.Sprintf("fd-a:a%d", i))
+	}
+
+	// 返回时该批消息已全部处理，同一fd按投递顺序
+	s.handleMessages(messages)
+
+	got := sentMessages(a)
+	if len(got) != 50 {
+		t.Fatalf("fd-a messages = %d, want 50", len(got))
+	}
+	for i, message := range got {
+		if want := fmt.Sprintf("a%d", i); message != want {
+			t.Fatalf("fd-a message %d = %q, want %q", i, message, want)
+		}
+	}
+
+	// 服务器消息与发给fd的消息分组处理，二者之间不保证顺序
+	direct := make([]string, 0)
+	rooms := 0
+	for _, message := range sentMessages(b) {
+		if message == "room" {
+			rooms++
+		} else {
+			direct = append(direct, message)
+		}
+	}
+	if len(direct) != 2 || direct[0] != "b0" || direct[1] != "b1" || rooms != 1 {
+		t.Fatalf("fd-b messages = %v rooms %d, want b0 b1 and room broadcast", direct, rooms)
+	}
+}
@@ -0,0 +1,214 @@
+package ws
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"strings"
+	"time"
+)
+
+// SessionPolicy 用户连接策略：同一用户新建连接时如何处理已有连接
+type SessionPolicy int
+
+const (
+	SessionSingle    SessionPolicy = iota // 单连接（默认）：新连接强制下线该用户的所有已有连接
+	SessionPerDevice                      // 每种设备类型单连接：新连接强制下线该用户相同设备类型的已有连接
+	SessionUnlimited                      // 不限制：同一用户可同时保持任意数量的连接
+)
+
+// clientSession 用户的单个连接：集合成员格式 server_id:fd:device
+type clientSession struct {
+	serverID string // 连接所在服务器id
+	fd       string // fd连接id
+	device   string // 设备类型(app h5 stb)
+}
+
+func (cs clientSession) member() string {
+	return fmt.Sprintf("%s:%s:%s", cs.serverID, cs.fd, cs.device)
+}
+
+// 解析连接集合成员：server_id:fd:device
+func parseClientSession(member string) (cs clientSession, ok bool) {
+	arr := strings.SplitN(member, ":", 3)
+	if len(arr) != 3 || arr[0] == "" || arr[1] == "" {
+		return cs, false
+	}
+	return clientSession{serverID: arr[0], fd: arr[1], device: arr[2]}, true
+}
+
+// SetSessionPolicy 设置用户连接策略，默认 SessionSingle，需在 Serve 之前调用
+//   - 集群内所有服务器需使用相同的连接策略
+func (s *Server) SetSessionPolicy(policy SessionPolicy) {
+	s.logger.Info("websocket service set session policy",
+		"appid", s.appid, "server_id", s.id, "policy", fmt.Sprint(policy))
+
+	s.sessionPolicy = policy
+}
+
+// 获取用户的所有连接
+func (s *Server) getClientSessions(uid string) (sessions []clientSession, err error) {
+	members, err := s.redis.SMembers(s.ctx, fmt.Sprintf(ClientSetKey, s.appid, uid)).Result()
+	if err != nil {
+		return
+	}
+
+	for _, member := range members {
+		if cs, ok := parseClientSession(member); ok {
+			sessions = append(sessions, cs)
+		}
+	}
+
+	// 兼容旧版本服务器：仅写入了 ClientInfoKey 的连接，设备类型未知
+	serverID, fd, err := s.getClientInfoByUid(uid)
+	if errors.Is(err, redis.Nil) {
+		return sessions, nil
+	}
+	if err != nil {
+		return
+	}
+	for _, cs := range sessions {
+		if cs.serverID == serverID && cs.fd == fd {
+			return
+		}
+	}
+	sessions = append(sessions, clientSession{serverID: serverID, fd: fd})
+	return
+}
+
+// 获取旧版本格式的用户连接信息：ws:{appid}:client:{uid} => server_id:fd
+func (s *Server) getClientInfoByUid(uid string) (serverID, fd string, err error) {
+	result, err := s.redis.Get(s.ctx, fmt.Sprintf(ClientInfoKey, s.appid, uid)).Result()
+	if err != nil {
+		return
+	}
+
+	serverID, fd, found := strings.Cut(result, ":")
+	if serverID == "" || fd == "" || !found {
+		err = ErrWsClientInfoError
+	}
+	return
+}
+
+// 获取用户指定设备类型的连接，device为空时获取所有连接
+func (s *Server) getDeviceSessions(uid, device string) (sessions []clientSession, err error) {
+	all, err := s.getClientSessions(uid)
+	if err != nil || device == "" {
+		return all, err
+	}
+
+	for _, cs := range all {
+		if cs.device == device {
+			sessions = append(sessions, cs)
+		}
+	}
+	return
+}
+
+// 保存用户连接：ws:{appid}:clients:{uid} => set(server_id:fd:device)
+//   - 同时写入旧版本格式 ws:{appid}:client:{uid} => server_id:fd，供滚动升级期间的旧版本服务器读取
+func (s *Server) addClientSession(client *Client) {
+	key := fmt.Sprintf(ClientSetKey, s.appid, client.GetUid())
+	cs := clientSession{serverID: s.id, fd: client.fd, device: client.GetUserDevice()}
+
+	pipe := s.redis.TxPipeline()
+	pipe.SAdd(s.ctx, key, cs.member())
+	pipe.Expire(s.ctx, key, time.Minute*10)
+	pipe.Set(s.ctx, fmt.Sprintf(ClientInfoKey, s.appid, client.GetUid()), fmt.Sprintf("%s:%s", cs.serverID, cs.fd), time.Minute*10)
+	_, _ = pipe.Exec(s.ctx)
+}
+
+// 删除用户连接：旧版本格式的用户连接信息仍为该连接时一并删除
+func (s *Server) removeClientSession(uid string, cs clientSession) {
+	s.redis.SRem(s.ctx, fmt.Sprintf(ClientSetKey, s.appid, uid), cs.member())
+
+	serverID, fd, _ := s.getClientInfoByUid(uid)
+	if serverID == cs.serverID && fd == cs.fd {
+		s.redis.Del(s.ctx, fmt.Sprintf(ClientInfoKey, s.appid, uid))
+	}
+}
+
+// 删除指定服务器上的所有用户连接：依据服务器的连接记录 uid:fd => fd:connect_time:last_active_time:device
+//   - 旧版本服务器的用户连接信息 ClientInfoKey 到期自动删除
+func (s *Server) removeServerSessions(serverID string) {
+	result, err := s.redis.HGetAll(s.ctx, fmt.Sprintf(ServerConnList, serverID)).Result()
+	if err != nil {
+		return
+	}
+
+	for field, value := range result {
+		uid, fd, found := strings.Cut(field, ":")
+		if !found {
+			continue
+		}
+		info := s.parseClientConnectLog(value)
+		s.removeClientSession(uid, clientSession{serverID: serverID, fd: fd, device: info.Device})
+	}
+}
+
+// ForceOfflineDevice 强制下线用户指定设备类型的所有连接，device为空时下线用户的所有连接
+func (s *Server) ForceOfflineDevice(uid, device, remark string) (err error) {
+	sessions, err := s.getDeviceSessions(uid, device)
+	if err != nil {
+		return
+	}
+
+	var errs []error
+	for _, cs := range sessions {
+		s.logger.Info("websocket service send force offline message",
+			"appid", s.appid, "server_id", s.id, "uid", uid, "fd", cs.fd, "device", cs.device, "remark", remark)
+
+		// 发送强制下线消息
+		msg := Response{
+			ID:       time.Now().UnixMicro(),
+			From:     ServerFd,
+			To:       ServerFd,
+			Device:   cs.device,
+			Event:    EventOffline,
+			Payload:  offlinePayload{Fd: cs.fd, Remark: remark},
+			SendTime: time.Now().Unix(),
+		}
+		if err := s.dispatchMessage(cs.serverID, ServerFd, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SendMessageToDevice 向用户指定设备类型的连接推送消息，device为空时推送至用户的所有连接
+func (s *Server) SendMessageToDevice(uid, device, event string, payload interface{}) (err error) {
+	msg := Response{
+		ID:       time.Now().UnixMicro(),
+		From:     ServerFd,
+		To:       uid,
+		Device:   device,
+		Event:    event,
+		Payload:  payload,
+		SendTime: time.Now().Unix(),
+	}
+
+	s.logger.Debug("websocket service send message to client",
+		"appid", s.appid, "server_id", s.id, "uid", uid, "device", device, "event", event)
+
+	// 触发响应钩子（用户不在线也需要保存消息记录）
+	go s.emitMessageResponseHook(msg)
+
+	// 根据uid获取所在服务器&fd
+	sessions, err := s.getDeviceSessions(uid, device)
+	if err != nil {
+		return err
+	}
+
+	if len(sessions) == 0 {
+		return ErrWsUserNotLoginError
+	}
+
+	var errs []error
+	for _, cs := range sessions {
+		msg.Device = cs.device
+		if err := s.dispatchMessage(cs.serverID, cs.fd, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
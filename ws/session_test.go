@@ -0,0 +1,175 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// nopLogger 测试用不输出的logger
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, keyValue ...string) {}
+func (nopLogger) Info(msg string, keyValue ...string)  {}
+func (nopLogger) Warn(msg string, keyValue ...string)  {}
+func (nopLogger) Error(msg string, keyValue ...string) {}
+
+// newTestServer 基于miniredis创建未启动的服务器
+func newTestServer(t *testing.T, mr *miniredis.Miniredis, id string) *Server {
+	t.Helper()
+
+	redisCli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	s := NewServer("app", nil, redisCli, nopLogger{})
+	s.id = id
+	t.Cleanup(func() {
+		s.heartBeatTicker.Stop()
+		s.selfCheckingTicker.Stop()
+		_ = redisCli.Close()
+	})
+	return s
+}
+
+// newTestClient 创建未建立连接的客户端
+func newTestClient(s *Server, fd, uid, device string) *Client {
+	return &Client{server: s, fd: fd, userInfo: UserInfo{Uid: uid, DeviceType: device}}
+}
+
+// offlineFds 读取投递至服务器消息池的强制下线消息的目标fd
+func offlineFds(t *testing.T, mr *miniredis.Miniredis, serverID string) (fds []string) {
+	t.Helper()
+
+	messages, _ := mr.List(fmt.Sprintf(ServerMsgPool, serverID))
+	for _, message := range messages {
+		fd, content, _ := strings.Cut(message, ":")
+		var resp struct {
+			Event   string         `json:"event"`
+			Payload offlinePayload `json:"payload"`
+		}
+		if err := json.Unmarshal([]byte(content), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if fd == ServerFd && resp.Event == EventOffline {
+			fds = append(fds, resp.Payload.Fd)
+		}
+	}
+	sort.Strings(fds)
+	return
+}
+
+func TestParseClientSession(t *testing.T) {
+	cases := []struct {
+		member string
+		want   clientSession
+		ok     bool
+	}{
+		{"s1:fd1:app", clientSession{serverID: "s1", fd: "fd1", device: "app"}, true},
+		{"s1:fd1:", clientSession{serverID: "s1", fd: "fd1"}, true},
+		{"s1:fd1:h5:extra", clientSession{serverID: "s1", fd: "fd1", device: "h5:extra"}, true},
+		{"s1:fd1", clientSession{}, false},
+		{":fd1:app", clientSession{}, false},
+		{"s1::app", clientSession{}, false},
+		{"", clientSession{}, false},
+	}
+	for _, c := range cases {
+		cs, ok := parseClientSession(c.member)
+		if ok != c.ok || cs != c.want {
+			t.Fatalf("parse %q = %+v %v, want %+v %v", c.member, cs, ok, c.want, c.ok)
+		}
+		if ok && strings.Count(c.member, ":") == 2 && cs.member() != c.member {
+			t.Fatalf("member = %q, want %q", cs.member(), c.member)
+		}
+	}
+}
+
+func TestSessionPolicy(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy SessionPolicy
+		want   []string // 被强制下线的fd
+	}{
+		{name: "single", policy: SessionSingle, want: []string{"fd-app", "fd-h5"}},
+		{name: "per device", policy: SessionPerDevice, want: []string{"fd-app"}},
+		{name: "unlimited", policy: SessionUnlimited, want: nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			s := newTestServer(t, mr, "s1")
+			s.SetSessionPolicy(c.policy)
+
+			s.registerClient(newTestClient(s, "fd-app", "u1", "app"))
+			s.registerClient(newTestClient(s, "fd-h5", "u1", "h5"))
+			mr.Del(fmt.Sprintf(ServerMsgPool, s.id))
+
+			s.registerClient(newTestClient(s, "fd-new", "u1", "app"))
+			if fds := offlineFds(t, mr, s.id); strings.Join(fds, ",") != strings.Join(c.want, ",") {
+				t.Fatalf("force offline fds = %v, want %v", fds, c.want)
+			}
+
+			sessions, err := s.getClientSessions("u1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sessions) != 3 {
+				t.Fatalf("sessions = %+v, want 3 until offline messages consumed", sessions)
+			}
+		})
+	}
+}
+
+func TestClientSessionLegacyCompat(t *testing.T) {
+	mr := miniredis.RunT(t)
+	s := newTestServer(t, mr, "s1")
+	s.SetSessionPolicy(SessionUnlimited)
+
+	// 同时写入旧版本格式的用户连接信息与连接记录
+	s.registerClient(newTestClient(s, "fd1", "u1", "app"))
+	if got, _ := mr.Get(fmt.Sprintf(ClientInfoKey, s.appid, "u1")); got != "s1:fd1" {
+		t.Fatalf("legacy client info = %q, want s1:fd1", got)
+	}
+	if got := mr.HGet(fmt.Sprintf(ServerClientList, s.id), "u1"); !strings.HasPrefix(got, "fd1:") || strings.Count(got, ":") != 2 {
+		t.Fatalf("legacy connect log = %q, want fd1:connect_time:last_active_time", got)
+	}
+	if sessions, _ := s.getClientSessions("u1"); len(sessions) != 1 {
+		t.Fatalf("sessions = %+v, want legacy client info deduplicated", sessions)
+	}
+
+	// 旧版本格式仍指向其他连接时不删除
+	s.registerClient(newTestClient(s, "fd2", "u1", "h5"))
+	s.deleteClient("u1", "fd1")
+	if got, _ := mr.Get(fmt.Sprintf(ClientInfoKey, s.appid, "u1")); got != "s1:fd2" {
+		t.Fatalf("legacy client info = %q, want s1:fd2", got)
+	}
+	s.deleteClient("u1", "fd2")
+	if mr.Exists(fmt.Sprintf(ClientInfoKey, s.appid, "u1")) || mr.Exists(fmt.Sprintf(ServerClientList, s.id)) {
+		t.Fatal("legacy records not deleted with the last connection")
+	}
+
+	// 旧版本服务器仅写入的连接信息
+	mr.Set(fmt.Sprintf(ClientInfoKey, s.appid, "u2"), "old:fd9")
+	mr.HSet(fmt.Sprintf(ServerClientList, "old"), "u2", "fd9:100:200")
+	if err := s.SendMessage("u2", "notice", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if messages, _ := mr.List(fmt.Sprintf(ServerMsgPool, "old")); len(messages) != 1 || !strings.HasPrefix(messages[0], "fd9:") {
+		t.Fatalf("old server messages = %v, want message to fd9", messages)
+	}
+	resp, err := s.GetServerConnections("old", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Connections) != 1 || resp.Connections[0].Uid != "u2" || resp.Connections[0].Fd != "fd9" {
+		t.Fatalf("old server connections = %+v, want u2 fd9", resp.Connections)
+	}
+
+	// 格式错误的旧版本连接信息
+	mr.Set(fmt.Sprintf(ClientInfoKey, s.appid, "u3"), "broken")
+	if _, err := s.getClientSessions("u3"); err != ErrWsClientInfoError {
+		t.Fatalf("err = %v, want ErrWsClientInfoError", err)
+	}
+}
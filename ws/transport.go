@@ -0,0 +1,228 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"strings"
+	"time"
+)
+
+// NewListTransport 基于redis列表的消息投递：消息写入服务器消息池 ServerMsgPool，兼容旧版本服务器
+//   - 无消息时阻塞等待，有消息时单次最多批量取出 transportBatchSize 条
+func NewListTransport(redisCli *redis.Client) Transport {
+	return &listTransport{redis: redisCli}
+}
+
+// NewPubSubTransport 基于redis发布订阅的消息投递：投递延迟最低，服务器未订阅期间投递的消息将丢失
+func NewPubSubTransport(redisCli *redis.Client) Transport {
+	return &pubSubTransport{redis: redisCli}
+}
+
+// NewStreamTransport 基于redis消息流的消息投递：消费者组读取，处理后确认并删除消息
+//   - 仅同一服务器进程内消费中断（如redis连接断开）后重新消费时，优先处理已读取未确认的消息
+//   - 服务器ID在每次启动时生成，重启后不再消费原消息流，原消息流随离线服务器清理删除，未确认的消息丢弃（其目标连接已随原服务器断开）
+//   - 需要redis 5.0及以上版本
+func NewStreamTransport(redisCli *redis.Client) Transport {
+	return &streamTransport{redis: redisCli}
+}
+
+// region 列表
+
+type listTransport struct {
+	redis *redis.Client
+}
+
+func (t *listTransport) Publish(ctx context.Context, serverID, message string) (err error) {
+	return t.redis.LPush(ctx, fmt.Sprintf(ServerMsgPool, serverID), message).Err()
+}
+
+func (t *listTransport) Consume(ctx context.Context, serverID string, handler func(messages []string)) (err error) {
+	key := fmt.Sprintf(ServerMsgPool, serverID)
+
+	for ctx.Err() == nil {
+		// 阻塞等待最早的一条消息
+		result, err := t.redis.BRPop(ctx, transportBlockTimeout, key).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		messages := []string{result[1]}
+
+		// 批量取出其余消息：LRANGE+LTRIM事务，兼容不支持 RPOP count 的redis版本
+		var rangeCmd *redis.StringSliceCmd
+		_, err = t.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			rangeCmd = pipe.LRange(ctx, key, -(transportBatchSize - 1), -1)
+			pipe.LTrim(ctx, key, 0, -transportBatchSize)
+			return nil
+		})
+
+		// 列表头部为最新的消息：逆序后按投递顺序处理
+		rest := rangeCmd.Val()
+		for i := len(rest) - 1; i >= 0; i-- {
+			messages = append(messages, rest[i])
+		}
+		handler(messages)
+
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *listTransport) Renew(ctx context.Context, serverID string) (err error) {
+	return t.redis.Expire(ctx, fmt.Sprintf(ServerMsgPool, serverID), time.Minute*10).Err()
+}
+
+func (t *listTransport) Remove(ctx context.Context, serverID string) (err error) {
+	return t.redis.Del(ctx, fmt.Sprintf(ServerMsgPool, serverID)).Err()
+}
+
+// endregion
+
+// region 发布订阅
+
+type pubSubTransport struct {
+	redis *redis.Client
+}
+
+func (t *pubSubTransport) Publish(ctx context.Context, serverID, message string) (err error) {
+	return t.redis.Publish(ctx, fmt.Sprintf(ServerMsgChannel, serverID), message).Err()
+}
+
+func (t *pubSubTransport) Consume(ctx context.Context, serverID string, handler func(messages []string)) (err error) {
+	pubSub := t.redis.Subscribe(ctx, fmt.Sprintf(ServerMsgChannel, serverID))
+	defer func() {
+		_ = pubSub.Close()
+	}()
+
+	// 等待订阅成功
+	if _, err = pubSub.Receive(ctx); err != nil {
+		return
+	}
+
+	ch := pubSub.Channel(redis.WithChannelSize(transportBatchSize * 10))
+	for {
+		var msg *redis.Message
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok = <-ch:
+			if !ok {
+				return ErrWsTransportClosed
+			}
+		}
+		messages := []string{msg.Payload}
+
+		// 批量取出已到达的其余消息
+	batch:
+		for len(messages) < transportBatchSize {
+			select {
+			case msg, ok = <-ch:
+				if !ok {
+					break batch
+				}
+				messages = append(messages, msg.Payload)
+			default:
+				break batch
+			}
+		}
+		handler(messages)
+	}
+}
+
+// Renew 发布订阅无消息存储
+func (t *pubSubTransport) Renew(ctx context.Context, serverID string) (err error) {
+	return nil
+}
+
+// Remove 发布订阅无消息存储
+func (t *pubSubTransport) Remove(ctx context.Context, serverID string) (err error) {
+	return nil
+}
+
+// endregion
+
+// region 消息流
+
+type streamTransport struct {
+	redis *redis.Client
+}
+
+func (t *streamTransport) Publish(ctx context.Context, serverID, message string) (err error) {
+	return t.redis.XAdd(ctx, &redis.XAddArgs{
+		Stream: fmt.Sprintf(ServerMsgStream, serverID),
+		MaxLen: transportStreamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{"message": message},
+	}).Err()
+}
+
+func (t *streamTransport) Consume(ctx context.Context, serverID string, handler func(messages []string)) (err error) {
+	key := fmt.Sprintf(ServerMsgStream, serverID)
+
+	// 创建消费者组：从头读取，服务器开始消费前投递的消息也能收到
+	err = t.redis.XGroupCreateMkStream(ctx, key, transportStreamGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	// 先读取已读取未确认的消息（本进程内上次消费中断），读取完毕后读取新消息
+	id := "0"
+	for ctx.Err() == nil {
+		streams, err := t.redis.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    transportStreamGroup,
+			Consumer: serverID,
+			Streams:  []string{key, id},
+			Count:    transportBatchSize,
+			Block:    transportBlockTimeout,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(streams) == 0 || len(streams[0].Messages) == 0 {
+			id = ">"
+			continue
+		}
+
+		messages := make([]string, 0, len(streams[0].Messages))
+		ids := make([]string, 0, len(streams[0].Messages))
+		for _, msg := range streams[0].Messages {
+			ids = append(ids, msg.ID)
+			if message, ok := msg.Values["message"].(string); ok {
+				messages = append(messages, message)
+			}
+		}
+		handler(messages)
+
+		// 确认并删除已处理的消息
+		_, err = t.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.XAck(ctx, key, transportStreamGroup, ids...)
+			pipe.XDel(ctx, key, ids...)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *streamTransport) Renew(ctx context.Context, serverID string) (err error) {
+	return t.redis.Expire(ctx, fmt.Sprintf(ServerMsgStream, serverID), time.Minute*10).Err()
+}
+
+func (t *streamTransport) Remove(ctx context.Context, serverID string) (err error) {
+	return t.redis.Del(ctx, fmt.Sprintf(ServerMsgStream, serverID)).Err()
+}
+
+// endregion
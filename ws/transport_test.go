@@ -0,0 +1,152 @@
+package ws
+
+import (
+	"context"
+	"fmt"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"testing"
+	"time"
+)
+
+// consumeBatches 持续接收消息直至收到want条且 settled 成立（为nil时不等待），返回每批消息
+func consumeBatches(t *testing.T, transport Transport, serverID string, want int, settled func() bool) (batches [][]string) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := make(chan []string, want)
+	done := make(chan struct{})
+	go func() {
+		_ = transport.Consume(ctx, serverID, func(messages []string) {
+			ch <- messages
+		})
+		close(done)
+	}()
+
+	received := 0
+	timeout := time.After(5 * time.Second)
+	for received < want {
+		select {
+		case messages := <-ch:
+			if len(messages) > transportBatchSize {
+				t.Fatalf("batch size = %d, want at most %d", len(messages), transportBatchSize)
+			}
+			batches = append(batches, messages)
+			received += len(messages)
+		case <-timeout:
+			t.Fatalf("received %d messages, want %d", received, want)
+		}
+	}
+
+	// 等待最后一批处理完毕后的确认
+	for settled != nil && !settled() {
+		select {
+		case <-timeout:
+			t.Fatal("consume not settled")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	cancel()
+	<-done
+	return
+}
+
+// assertOrdered 断言各批消息合并后按投递顺序排列
+func assertOrdered(t *testing.T, batches [][]string, want int) {
+	t.Helper()
+
+	i := 0
+	for _, messages := range batches {
+		for _, message := range messages {
+			if expect := fmt.Sprintf("fd:%d", i); message != expect {
+				t.Fatalf("message %d = %q, want %q", i, message, expect)
+			}
+			i++
+		}
+	}
+	if i != want {
+		t.Fatalf("received %d messages, want %d", i, want)
+	}
+}
+
+func publishN(t *testing.T, transport Transport, serverID string, from, to int) {
+	t.Helper()
+
+	for i := from; i < to; i++ {
+		if err := transport.Publish(context.Background(), serverID, fmt.Sprintf("fd:%d", i)); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+}
+
+func TestListTransportBatchOrder(t *testing.T) {
+	mr := miniredis.RunT(t)
+	transport := NewListTransport(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	publishN(t, transport, "s1", 0, 250)
+	batches := consumeBatches(t, transport, "s1", 250, nil)
+	assertOrdered(t, batches, 250)
+
+	// 已积压的消息按批量上限分批取出
+	if len(batches) != 3 || len(batches[0]) != transportBatchSize || len(batches[2]) != 50 {
+		t.Fatalf("batch count = %d, want 100+100+50", len(batches))
+	}
+	if mr.Exists(fmt.Sprintf(ServerMsgPool, "s1")) {
+		t.Fatal("message pool not empty")
+	}
+}
+
+func TestPubSubTransportBatchOrder(t *testing.T) {
+	mr := miniredis.RunT(t)
+	transport := NewPubSubTransport(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	// 订阅成功后再投递
+	go func() {
+		for mr.PubSubNumSub(fmt.Sprintf(ServerMsgChannel, "s1"))[fmt.Sprintf(ServerMsgChannel, "s1")] == 0 {
+			time.Sleep(5 * time.Millisecond)
+		}
+		publishN(t, transport, "s1", 0, 250)
+	}()
+
+	assertOrdered(t, consumeBatches(t, transport, "s1", 250, nil), 250)
+}
+
+func TestStreamTransportBatchOrder(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisCli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	transport := NewStreamTransport(redisCli)
+	key := fmt.Sprintf(ServerMsgStream, "s1")
+	ctx := context.Background()
+
+	// 模拟本进程内上次消费中断：已读取未确认的消息
+	publishN(t, transport, "s1", 0, 2)
+	if err := redisCli.XGroupCreateMkStream(ctx, key, transportStreamGroup, "0").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := redisCli.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    transportStreamGroup,
+		Consumer: "s1",
+		Streams:  []string{key, ">"},
+		Count:    2,
+	}).Err(); err != nil {
+		t.Fatal(err)
+	}
+	publishN(t, transport, "s1", 2, 150)
+
+	// 处理后确认并删除
+	batches := consumeBatches(t, transport, "s1", 150, func() bool {
+		length, _ := redisCli.XLen(ctx, key).Result()
+		pending, _ := redisCli.XPending(ctx, key, transportStreamGroup).Result()
+		return length == 0 && pending != nil && pending.Count == 0
+	})
+	assertOrdered(t, batches, 150)
+
+	// 未确认的消息优先处理
+	if len(batches[0]) != 2 {
+		t.Fatalf("first batch = %v, want pending messages", batches[0])
+	}
+}